@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"score-splitter/backend/gen/go/scoreconnect"
+
+	"connectrpc.com/connect"
+)
+
+// jobKind は同時実行数を制限する重い処理の種類です
+type jobKind string
+
+const (
+	jobKindTrim  jobKind = "trim"
+	jobKindVideo jobKind = "video"
+)
+
+// heavyProcedures は同時実行数を制限するRPCと処理の種類の対応です
+var heavyProcedures = map[string]jobKind{
+	scoreconnect.ScoreServiceTrimScoreProcedure:             jobKindTrim,
+	scoreconnect.ScoreServiceTrimScoreWithProgressProcedure: jobKindTrim,
+	scoreconnect.ScoreServiceBatchTrimScoresProcedure:       jobKindTrim,
+	scoreconnect.ScoreServiceGenerateScrollVideoProcedure:   jobKindVideo,
+}
+
+// admissionLimit は処理の種類ごとの同時実行数の上限です
+type admissionLimit struct {
+	global    int
+	perClient int
+}
+
+// admissionController は重い処理の同時実行数を全体とクライアントごとに制限します。
+// 上限に達している場合はキューで待たせ、キューも一杯であれば拒否します。
+type admissionController struct {
+	limits       map[jobKind]admissionLimit
+	queueSize    int
+	queueTimeout time.Duration
+	retryAfter   time.Duration
+
+	mu      sync.Mutex
+	running map[jobKind]int
+	clients map[string]int
+	waiting map[jobKind]int
+	// wake は処理枠が解放されるたびにcloseされ、新しいチャネルに差し替えられます
+	wake chan struct{}
+}
+
+func newAdmissionController(cfg *serverConfig) *admissionController {
+	return &admissionController{
+		limits: map[jobKind]admissionLimit{
+			jobKindTrim:  {global: cfg.TrimWorkers, perClient: cfg.PerClientTrimJobs},
+			jobKindVideo: {global: cfg.VideoWorkers, perClient: cfg.PerClientVideoJobs},
+		},
+		queueSize:    cfg.AdmissionQueueSize,
+		queueTimeout: cfg.AdmissionQueueTimeout,
+		retryAfter:   cfg.RetryAfter,
+		running:      make(map[jobKind]int),
+		clients:      make(map[string]int),
+		waiting:      make(map[jobKind]int),
+		wake:         make(chan struct{}),
+	}
+}
+
+func clientSlotKey(kind jobKind, client string) string {
+	return string(kind) + "|" + client
+}
+
+// acquire は処理枠を確保し、解放用の関数を返します
+func (a *admissionController) acquire(ctx context.Context, kind jobKind, client string) (func(), error) {
+	limit := a.limits[kind]
+	key := clientSlotKey(kind, client)
+
+	var timeout <-chan time.Time
+	queued := false
+	dequeue := func() {
+		a.mu.Lock()
+		a.waiting[kind]--
+		a.mu.Unlock()
+	}
+
+	for {
+		a.mu.Lock()
+		if a.running[kind] < limit.global && a.clients[key] < limit.perClient {
+			a.running[kind]++
+			a.clients[key]++
+			if queued {
+				a.waiting[kind]--
+			}
+			a.mu.Unlock()
+			return func() { a.release(kind, key) }, nil
+		}
+		if !queued {
+			if a.waiting[kind] >= a.queueSize {
+				a.mu.Unlock()
+				return nil, a.rejectError(kind, "処理待ちのキューが一杯です")
+			}
+			a.waiting[kind]++
+			queued = true
+			timer := time.NewTimer(a.queueTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		wake := a.wake
+		a.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timeout:
+			dequeue()
+			return nil, a.rejectError(kind, "処理枠が空くのを待つ間にタイムアウトしました")
+		case <-ctx.Done():
+			dequeue()
+			return nil, connect.NewError(connect.CodeCanceled, ctx.Err())
+		}
+	}
+}
+
+// wait は非同期ジョブのために処理枠を確保します。acquireと違い、キューの長さや待ち時間の上限はなく、
+// 処理枠が空くかctxが終了するまで待ちます。受け付けるジョブの数はjobManagerが制限します。
+func (a *admissionController) wait(ctx context.Context, kind jobKind, client string) (func(), error) {
+	limit := a.limits[kind]
+	key := clientSlotKey(kind, client)
+	for {
+		a.mu.Lock()
+		if a.running[kind] < limit.global && a.clients[key] < limit.perClient {
+			a.running[kind]++
+			a.clients[key]++
+			a.mu.Unlock()
+			return func() { a.release(kind, key) }, nil
+		}
+		wake := a.wake
+		a.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (a *admissionController) release(kind jobKind, key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running[kind]--
+	if a.clients[key]--; a.clients[key] <= 0 {
+		delete(a.clients, key)
+	}
+	close(a.wake)
+	a.wake = make(chan struct{})
+}
+
+// rejectError はRetry-Afterの目安を付けたCodeResourceExhaustedのエラーを返します
+func (a *admissionController) rejectError(kind jobKind, reason string) error {
+	seconds := int(a.retryAfter.Round(time.Second) / time.Second)
+	err := connect.NewError(
+		connect.CodeResourceExhausted,
+		fmt.Errorf("%s（%s）。%d秒後に再試行してください", reason, kind, seconds),
+	)
+	err.Meta().Set("Retry-After", strconv.Itoa(seconds))
+	return err
+}
+
+// stats は処理の種類ごとの実行中と待機中の数を返します
+func (a *admissionController) stats(kind jobKind) (running, waiting int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.running[kind], a.waiting[kind]
+}
+
+// admissionInterceptor は重いRPCの前に処理枠を確保するconnectのインターセプターです
+type admissionInterceptor struct {
+	admission    *admissionController
+	trustProxies bool
+}
+
+var _ connect.Interceptor = (*admissionInterceptor)(nil)
+
+func (i *admissionInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		kind, heavy := heavyProcedures[req.Spec().Procedure]
+		if !heavy {
+			return next(ctx, req)
+		}
+		release, err := i.admission.acquire(ctx, kind, clientID(ctx, req.Header(), req.Peer(), i.trustProxies))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return next(ctx, req)
+	}
+}
+
+func (i *admissionInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *admissionInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		kind, heavy := heavyProcedures[conn.Spec().Procedure]
+		if !heavy {
+			return next(ctx, conn)
+		}
+		release, err := i.admission.acquire(ctx, kind, clientID(ctx, conn.RequestHeader(), conn.Peer(), i.trustProxies))
+		if err != nil {
+			return err
+		}
+		defer release()
+		return next(ctx, conn)
+	}
+}
+
+// clientID はクライアントを識別する文字列を返します。
+// 認証済みであればAPIキーやトークンの主体を、そうでなければ接続元IPを使います。
+func clientID(ctx context.Context, header http.Header, peer connect.Peer, trustProxies bool) string {
+	if p := principalFromContext(ctx); p != nil {
+		return p.subject
+	}
+	return "ip:" + clientIP(header, peer.Addr, trustProxies)
+}
+
+// clientIP は接続元IPを返します。リバースプロキシを信頼する設定の場合は
+// X-Forwarded-For / X-Real-IP を優先します。X-Forwarded-For の左側はクライアントが自由に書けるので、
+// 信頼するプロキシが最後に追加した右端の値を使います。
+func clientIP(header http.Header, remoteAddr string, trustProxies bool) string {
+	if trustProxies {
+		if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(forwarded[len(forwarded)-1]); ip != "" {
+				return ip
+			}
+		}
+		if realIP := strings.TrimSpace(header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// newTestAdmission は処理枠がglobal、クライアントごとにperClient、キューがqueueSizeの受付制御を返します
+func newTestAdmission(global, perClient, queueSize int, queueTimeout time.Duration) *admissionController {
+	cfg := defaultConfig()
+	cfg.TrimWorkers = global
+	cfg.PerClientTrimJobs = perClient
+	cfg.AdmissionQueueSize = queueSize
+	cfg.AdmissionQueueTimeout = queueTimeout
+	return newAdmissionController(cfg)
+}
+
+// acquireAsync はacquireを別のgoroutineで呼び、結果を返すチャネルを返します
+func acquireAsync(a *admissionController, ctx context.Context, client string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := a.acquire(ctx, jobKindTrim, client)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// waitForWaiting は待機中の数がwantになるまで待ちます
+func waitForWaiting(t *testing.T, a *admissionController, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, waiting := a.stats(jobKindTrim); waiting == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting never reached %d", want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// mustAcquire は処理枠をすぐに確保できることを確認します
+func mustAcquire(t *testing.T, a *admissionController, client string) func() {
+	t.Helper()
+	release, err := a.acquire(context.Background(), jobKindTrim, client)
+	if err != nil {
+		t.Fatalf("acquire for %s: %v", client, err)
+	}
+	return release
+}
+
+// assertBlocked はdoneがまだ終わっていないことを確認します
+func assertBlocked(t *testing.T, done <-chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		t.Fatalf("acquire returned %v while the limit is reached", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func assertAcquired(t *testing.T, done <-chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("acquire did not return after a slot was released")
+	}
+}
+
+func TestAdmissionGlobalLimit(t *testing.T) {
+	a := newTestAdmission(2, 2, 4, 5*time.Second)
+	releaseA := mustAcquire(t, a, "a")
+	defer mustAcquire(t, a, "b")()
+
+	// 全体の上限に達していれば、別のクライアントでも待つ
+	done := acquireAsync(a, context.Background(), "c")
+	waitForWaiting(t, a, 1)
+	assertBlocked(t, done)
+	if running, _ := a.stats(jobKindTrim); running != 2 {
+		t.Errorf("running = %d, want 2", running)
+	}
+
+	releaseA()
+	assertAcquired(t, done)
+	if _, waiting := a.stats(jobKindTrim); waiting != 0 {
+		t.Errorf("waiting = %d after acquire, want 0", waiting)
+	}
+
+	// 別の種類の処理枠には影響しない
+	release, err := a.acquire(context.Background(), jobKindVideo, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestAdmissionPerClientLimit(t *testing.T) {
+	a := newTestAdmission(4, 1, 4, 5*time.Second)
+	releaseA := mustAcquire(t, a, "a")
+
+	// 同じクライアントの2つ目は待ち、他のクライアントはすぐに実行できる
+	done := acquireAsync(a, context.Background(), "a")
+	waitForWaiting(t, a, 1)
+	mustAcquire(t, a, "b")()
+	assertBlocked(t, done)
+
+	releaseA()
+	assertAcquired(t, done)
+}
+
+func TestAdmissionRejectsWhenQueueIsFull(t *testing.T) {
+	a := newTestAdmission(1, 1, 1, 5*time.Second)
+	a.retryAfter = 1500 * time.Millisecond
+	release := mustAcquire(t, a, "a")
+
+	done := acquireAsync(a, context.Background(), "b")
+	waitForWaiting(t, a, 1)
+
+	// キューが一杯なら待たずに拒否し、Retry-Afterを秒に丸めて返す
+	_, err := a.acquire(context.Background(), jobKindTrim, "c")
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeResourceExhausted {
+		t.Fatalf("err = %v, want resource_exhausted", err)
+	}
+	if got := connectErr.Meta().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+
+	release()
+	assertAcquired(t, done)
+}
+
+func TestAdmissionQueueTimeout(t *testing.T) {
+	a := newTestAdmission(1, 1, 4, 20*time.Millisecond)
+	defer mustAcquire(t, a, "a")()
+
+	start := time.Now()
+	err := <-acquireAsync(a, context.Background(), "b")
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("err = %v, want resource_exhausted", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("rejected after %v, before the queue timeout", elapsed)
+	}
+	if got := err.(*connect.Error).Meta().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want 10", got)
+	}
+	if _, waiting := a.stats(jobKindTrim); waiting != 0 {
+		t.Errorf("waiting = %d after timeout, want 0", waiting)
+	}
+
+	// 待っている間にリクエストが終了した場合はキャンセルとして返す
+	ctx, cancel := context.WithCancel(context.Background())
+	done := acquireAsync(a, ctx, "b")
+	waitForWaiting(t, a, 1)
+	cancel()
+	if err := <-done; connect.CodeOf(err) != connect.CodeCanceled {
+		t.Errorf("err = %v, want canceled", err)
+	}
+	waitForWaiting(t, a, 0)
+}
+
+func TestAdmissionWaitHasNoQueueLimit(t *testing.T) {
+	a := newTestAdmission(1, 1, 0, time.Millisecond)
+	release := mustAcquire(t, a, "a")
+
+	// キューの長さと待ち時間の上限を使わず、処理枠が空くまで待つ
+	results := make(chan error, 2)
+	for range 2 {
+		go func() {
+			release, err := a.wait(context.Background(), jobKindTrim, "b")
+			if err == nil {
+				release()
+			}
+			results <- err
+		}()
+	}
+	assertBlocked(t, results)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := a.wait(ctx, jobKindTrim, "c"); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with cancelled context: err = %v, want context.Canceled", err)
+	}
+
+	release()
+	assertAcquired(t, results)
+	assertAcquired(t, results)
+	if running, _ := a.stats(jobKindTrim); running != 0 {
+		t.Errorf("running = %d after release, want 0", running)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       http.Header
+		trustProxies bool
+		want         string
+	}{
+		{name: "remote address", header: http.Header{}, want: "192.0.2.1"},
+		{name: "proxy headers ignored", header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, want: "192.0.2.1"},
+		{name: "forwarded", header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, trustProxies: true, want: "198.51.100.7"},
+		// クライアントが送った値の後ろにプロキシが接続元を追加するので、右端を使う
+		{name: "spoofed forwarded", header: http.Header{"X-Forwarded-For": {"203.0.113.99, 198.51.100.7"}}, trustProxies: true, want: "198.51.100.7"},
+		{name: "spoofed header line", header: http.Header{"X-Forwarded-For": {"203.0.113.99", "198.51.100.7"}}, trustProxies: true, want: "198.51.100.7"},
+		{name: "real ip", header: http.Header{"X-Real-Ip": {"198.51.100.8"}}, trustProxies: true, want: "198.51.100.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientIP(tt.header, "192.0.2.1:54321", tt.trustProxies); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmissionSpoofedForwardedForSharesClientLimit(t *testing.T) {
+	a := newTestAdmission(4, 1, 0, time.Millisecond)
+	client := func(spoofed string) string {
+		header := http.Header{"X-Forwarded-For": {spoofed + ", 198.51.100.7"}}
+		return clientID(context.Background(), header, connect.Peer{Addr: "10.0.0.2:443"}, true)
+	}
+
+	// 左端を毎回変えても同じクライアントとして数え、クライアントごとの上限で拒否する
+	release := mustAcquire(t, a, client("203.0.113.1"))
+	defer release()
+	if _, err := a.acquire(context.Background(), jobKindTrim, client("203.0.113.2")); connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("err = %v, want resource_exhausted for a spoofed client", err)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+)
+
+// maxAudioOffsetMs は音声をずらせるミリ秒の上限です
+const maxAudioOffsetMs = 10 * 60 * 1000
+
+// backingAudio は検証済みの伴奏の音声です
+type backingAudio struct {
+	data []byte
+	ext  string
+	// offset は動画の先頭から音声を始めるまでの秒数です。負なら音声の先頭を飛ばします。
+	offset      float64
+	fitDuration bool
+}
+
+// backingAudioFromRequest は伴奏の音声の設定を検証します。指定がなければnilを返します。
+func backingAudioFromRequest(msg *score.GenerateScrollVideoRequest) (*backingAudio, error) {
+	data := msg.GetAudioFile()
+	if len(data) == 0 {
+		if msg.GetAudioOffsetMs() != 0 || msg.GetDurationFromAudio() {
+			return nil, errors.New("audio_offset_ms / duration_from_audio は audio_file と一緒に指定してください")
+		}
+		return nil, nil
+	}
+	ext := detectAudioFormat(data)
+	if ext == "" {
+		return nil, errors.New("音声ファイルの形式に対応していません（WAV / MP3 / OGG）")
+	}
+	offset := msg.GetAudioOffsetMs()
+	if offset < -maxAudioOffsetMs || offset > maxAudioOffsetMs {
+		return nil, fmt.Errorf("音声のずれ%dミリ秒は±%dミリ秒の範囲で指定してください", offset, maxAudioOffsetMs)
+	}
+	return &backingAudio{
+		data:        data,
+		ext:         ext,
+		offset:      float64(offset) / 1000,
+		fitDuration: msg.GetDurationFromAudio(),
+	}, nil
+}
+
+// detectAudioFormat はファイルの先頭のバイト列から音声の形式を判定し、拡張子を返します。対応していなければ空を返します。
+func detectAudioFormat(data []byte) string {
+	switch {
+	case len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "ogg"
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return "mp3"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		// ID3タグの無いMP3はフレームの同期ワードから始まります
+		return "mp3"
+	}
+	return ""
+}
+
+// videoAudio は動画に入れる音声のファイルです
+type videoAudio struct {
+	path string
+	// offset は動画の先頭から音声を始めるまでの秒数です。負なら音声の先頭を飛ばします。
+	offset float64
+}
+
+// prepareAudio はタイムラインから動画の秒数を決め、動画に入れる音声をファイルに書き出して返します。
+// メトロノームのカウントインがある場合はタイムラインをその分だけ遅らせます。
+// 伴奏の長さに合わせる場合は、伴奏が終わる時刻を動画の秒数にします。
+// 秒数が上限を超える場合は、クリック音を作る前にエラーを返します。
+func prepareAudio(ctx context.Context, opts videoOptions, timeline *scrollTimeline, workDir string) (int, []videoAudio, error) {
+	clicks := metronomeClicks(opts, timeline)
+	duration := int(math.Ceil(timeline.end)) + videoTailSeconds
+
+	var audio []videoAudio
+	if b := opts.backing; b != nil {
+		path := filepath.Join(workDir, "backing."+b.ext)
+		if err := os.WriteFile(path, b.data, 0600); err != nil {
+			return 0, nil, err
+		}
+		if b.fitDuration {
+			length, err := probeDuration(ctx, path)
+			if err != nil {
+				return 0, nil, err
+			}
+			end := b.offset + length
+			if end <= 0 {
+				return 0, nil, errors.New("音声のずれが音声の長さを超えています")
+			}
+			duration = int(math.Ceil(end))
+		}
+		audio = append(audio, videoAudio{path: path, offset: b.offset})
+	}
+	if err := opts.checkDuration(duration); err != nil {
+		return 0, nil, err
+	}
+	if opts.metronome != nil {
+		path := filepath.Join(workDir, "click.wav")
+		if err := writeClickTrack(path, clicks, duration, opts.metronome.volume); err != nil {
+			return 0, nil, err
+		}
+		audio = append(audio, videoAudio{path: path})
+	}
+	return duration, audio, nil
+}
+
+// probeDuration はffprobeで音声ファイルの長さ（秒）を調べます
+func probeDuration(ctx context.Context, path string) (float64, error) {
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return 0, errors.New("音声の長さを調べるのに必要なffprobeが見つかりません")
+	}
+	out, err := exec.CommandContext(ctx, ffprobe, "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+	if err != nil {
+		return 0, fmt.Errorf("音声ファイルを読み込めません: %w", err)
+	}
+	length, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || length <= 0 {
+		return 0, errors.New("音声ファイルの長さを取得できません")
+	}
+	return length, nil
+}
+
+// audioArgs は音声の入力と、映像と合わせて出力するためのffmpegの引数を返します。index は最初の音声の入力の番号です。
+// 複数の音声は1つに重ね、ずれはフィルタで調整します。
+func audioArgs(audio []videoAudio, index int) (inputs, outputs []string) {
+	if len(audio) == 1 && audio[0].offset == 0 {
+		return []string{"-i", audio[0].path}, []string{"-map", fmt.Sprintf("%d:a", index)}
+	}
+	var filter strings.Builder
+	for i, a := range audio {
+		inputs = append(inputs, "-i", a.path)
+		chain := "anull"
+		switch {
+		case a.offset > 0:
+			chain = fmt.Sprintf("adelay=delays=%d:all=1", int(math.Round(a.offset*1000)))
+		case a.offset < 0:
+			chain = fmt.Sprintf("atrim=start=%.3f,asetpts=PTS-STARTPTS", -a.offset)
+		}
+		fmt.Fprintf(&filter, "[%d:a]%s[a%d];", index+i, chain, i)
+	}
+	if len(audio) == 1 {
+		return inputs, []string{"-filter_complex", strings.TrimSuffix(filter.String(), "[a0];") + "[a]", "-map", "[a]"}
+	}
+	for i := range audio {
+		fmt.Fprintf(&filter, "[a%d]", i)
+	}
+	// 音量を下げないように normalize=0 で足し合わせます
+	fmt.Fprintf(&filter, "amix=inputs=%d:duration=longest:normalize=0[a]", len(audio))
+	return inputs, []string{"-filter_complex", filter.String(), "-map", "[a]"}
+}
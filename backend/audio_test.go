@@ -0,0 +1,135 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	score "score-splitter/backend/gen/go"
+)
+
+func TestDetectAudioFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "wav", data: []byte("RIFF\x24\x00\x00\x00WAVEfmt "), want: "wav"},
+		{name: "ogg", data: []byte("OggS\x00\x02"), want: "ogg"},
+		{name: "mp3 with id3", data: []byte("ID3\x04\x00\x00"), want: "mp3"},
+		{name: "mp3 frame sync", data: []byte{0xFF, 0xFB, 0x90, 0x64}, want: "mp3"},
+		// RIFFでもWAVE以外（AVIなど）は対応しない
+		{name: "riff avi", data: []byte("RIFF\x24\x00\x00\x00AVI LIST")},
+		{name: "short riff", data: []byte("RIFF")},
+		{name: "flac", data: []byte("fLaC\x00\x00")},
+		{name: "single sync byte", data: []byte{0xFF}},
+		{name: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectAudioFormat(tt.data); got != tt.want {
+				t.Errorf("detectAudioFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudioArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		audio       []videoAudio
+		wantInputs  []string
+		wantOutputs []string
+	}{
+		{
+			name:        "single without offset",
+			audio:       []videoAudio{{path: "backing.mp3"}},
+			wantInputs:  []string{"-i", "backing.mp3"},
+			wantOutputs: []string{"-map", "1:a"},
+		},
+		{
+			name:        "positive offset",
+			audio:       []videoAudio{{path: "backing.mp3", offset: 1.5}},
+			wantInputs:  []string{"-i", "backing.mp3"},
+			wantOutputs: []string{"-filter_complex", "[1:a]adelay=delays=1500:all=1[a]", "-map", "[a]"},
+		},
+		{
+			name:        "negative offset",
+			audio:       []videoAudio{{path: "backing.mp3", offset: -0.25}},
+			wantInputs:  []string{"-i", "backing.mp3"},
+			wantOutputs: []string{"-filter_complex", "[1:a]atrim=start=0.250,asetpts=PTS-STARTPTS[a]", "-map", "[a]"},
+		},
+		{
+			name:       "mix backing and click",
+			audio:      []videoAudio{{path: "backing.wav", offset: 2}, {path: "click.wav"}},
+			wantInputs: []string{"-i", "backing.wav", "-i", "click.wav"},
+			wantOutputs: []string{
+				"-filter_complex",
+				"[1:a]adelay=delays=2000:all=1[a0];[2:a]anull[a1];[a0][a1]amix=inputs=2:duration=longest:normalize=0[a]",
+				"-map", "[a]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, outputs := audioArgs(tt.audio, 1)
+			if !slices.Equal(inputs, tt.wantInputs) {
+				t.Errorf("inputs = %q, want %q", inputs, tt.wantInputs)
+			}
+			if !slices.Equal(outputs, tt.wantOutputs) {
+				t.Errorf("outputs = %q, want %q", outputs, tt.wantOutputs)
+			}
+		})
+	}
+}
+
+func TestBackingAudioFromRequest(t *testing.T) {
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt ")
+	tests := []struct {
+		name    string
+		msg     *score.GenerateScrollVideoRequest
+		want    *backingAudio
+		wantErr bool
+	}{
+		{name: "no audio", msg: &score.GenerateScrollVideoRequest{}},
+		{
+			name: "offset",
+			msg:  &score.GenerateScrollVideoRequest{AudioFile: wav, AudioOffsetMs: -1500, DurationFromAudio: true},
+			want: &backingAudio{ext: "wav", offset: -1.5, fitDuration: true},
+		},
+		{
+			name: "max offset",
+			msg:  &score.GenerateScrollVideoRequest{AudioFile: wav, AudioOffsetMs: maxAudioOffsetMs},
+			want: &backingAudio{ext: "wav", offset: maxAudioOffsetMs / 1000},
+		},
+		{name: "offset too large", msg: &score.GenerateScrollVideoRequest{AudioFile: wav, AudioOffsetMs: maxAudioOffsetMs + 1}, wantErr: true},
+		{name: "offset too small", msg: &score.GenerateScrollVideoRequest{AudioFile: wav, AudioOffsetMs: -maxAudioOffsetMs - 1}, wantErr: true},
+		// 音声が無いのにずれや長さの指定があるのは誤り
+		{name: "offset without file", msg: &score.GenerateScrollVideoRequest{AudioOffsetMs: 500}, wantErr: true},
+		{name: "duration without file", msg: &score.GenerateScrollVideoRequest{DurationFromAudio: true}, wantErr: true},
+		{name: "unknown format", msg: &score.GenerateScrollVideoRequest{AudioFile: []byte("not audio")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := backingAudioFromRequest(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("backingAudio = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("backingAudio = nil")
+			}
+			if got.ext != tt.want.ext || got.offset != tt.want.offset || got.fitDuration != tt.want.fitDuration {
+				t.Errorf("backingAudio = {ext: %q, offset: %g, fitDuration: %v}, want {ext: %q, offset: %g, fitDuration: %v}",
+					got.ext, got.offset, got.fitDuration, tt.want.ext, tt.want.offset, tt.want.fitDuration)
+			}
+			if string(got.data) != string(tt.msg.GetAudioFile()) {
+				t.Error("backingAudio.data differs from audio_file")
+			}
+		})
+	}
+}
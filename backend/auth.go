@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"score-splitter/backend/gen/go/scoreconnect"
+
+	"connectrpc.com/connect"
+)
+
+// 権限スコープ
+const (
+	scopeRead   = "read"
+	scopeTrim   = "trim"
+	scopeUpload = "upload"
+	scopeVideo  = "video"
+)
+
+var knownScopes = []string{scopeRead, scopeTrim, scopeUpload, scopeVideo}
+
+// procedureScopes は各RPCの呼び出しに必要なスコープです
+var procedureScopes = map[string]string{
+	scoreconnect.ScoreServiceUploadScoreProcedure:           scopeUpload,
+	scoreconnect.ScoreServiceTrimScoreProcedure:             scopeTrim,
+	scoreconnect.ScoreServiceTrimScoreWithProgressProcedure: scopeTrim,
+	scoreconnect.ScoreServiceSearchYoutubeVideosProcedure:   scopeRead,
+	scoreconnect.ScoreServiceGenerateScrollVideoProcedure:   scopeVideo,
+	scoreconnect.ScoreServiceListScoresProcedure:            scopeRead,
+	scoreconnect.ScoreServiceGetScoreProcedure:              scopeRead,
+	scoreconnect.ScoreServiceDeleteScoreProcedure:           scopeUpload,
+	scoreconnect.ScoreServiceBeginUploadProcedure:           scopeUpload,
+	scoreconnect.ScoreServiceAppendUploadChunkProcedure:     scopeUpload,
+	scoreconnect.ScoreServiceGetUploadStatusProcedure:       scopeUpload,
+	scoreconnect.ScoreServiceCommitUploadProcedure:          scopeUpload,
+	scoreconnect.ScoreServiceSubmitTrimJobProcedure:         scopeTrim,
+	scoreconnect.ScoreServiceSubmitVideoJobProcedure:        scopeVideo,
+	scoreconnect.ScoreServiceGetJobProcedure:                scopeRead,
+	scoreconnect.ScoreServiceWatchJobProcedure:              scopeRead,
+	scoreconnect.ScoreServiceGetJobResultProcedure:          scopeRead,
+	scoreconnect.ScoreServiceDownloadJobResultProcedure:     scopeRead,
+	scoreconnect.ScoreServiceBatchTrimScoresProcedure:       scopeTrim,
+	scoreconnect.ScoreServiceSaveTemplateProcedure:          scopeUpload,
+	scoreconnect.ScoreServiceListTemplatesProcedure:         scopeRead,
+	scoreconnect.ScoreServiceGetTemplateProcedure:           scopeRead,
+	scoreconnect.ScoreServiceDeleteTemplateProcedure:        scopeUpload,
+}
+
+// jwtLeeway はトークンの有効期限を判定する際に許容する時刻のずれです
+const jwtLeeway = 30 * time.Second
+
+// authConfig は認証の設定です。APIキーとJWTの秘密鍵のどちらも未設定の場合は認証を行いません。
+type authConfig struct {
+	APIKeys     []apiKeyConfig `yaml:"api_keys"`
+	JWTSecret   string         `yaml:"jwt_secret"`
+	JWTIssuer   string         `yaml:"jwt_issuer"`
+	JWTAudience string         `yaml:"jwt_audience"`
+}
+
+// apiKeyConfig は静的なAPIキーの設定です。平文のkeyの代わりにSHA-256のハッシュを指定できます。
+// tenantを省略した場合はnameをテナントとして扱います。
+type apiKeyConfig struct {
+	Name      string   `yaml:"name"`
+	Key       string   `yaml:"key"`
+	KeySHA256 string   `yaml:"key_sha256"`
+	Tenant    string   `yaml:"tenant"`
+	Scopes    []string `yaml:"scopes"`
+}
+
+func (c authConfig) enabled() bool {
+	return len(c.APIKeys) > 0 || c.JWTSecret != ""
+}
+
+func (c authConfig) validate() error {
+	names := make(map[string]struct{}, len(c.APIKeys))
+	for i, key := range c.APIKeys {
+		if key.Name == "" {
+			return fmt.Errorf("APIキー%dの名前がありません", i+1)
+		}
+		if _, dup := names[key.Name]; dup {
+			return fmt.Errorf("APIキー%sが重複しています", key.Name)
+		}
+		names[key.Name] = struct{}{}
+		if (key.Key == "") == (key.KeySHA256 == "") {
+			return fmt.Errorf("APIキー%sにはkeyかkey_sha256のどちらか一方を指定してください", key.Name)
+		}
+		if key.KeySHA256 != "" {
+			if sum, err := hex.DecodeString(key.KeySHA256); err != nil || len(sum) != sha256.Size {
+				return fmt.Errorf("APIキー%sのkey_sha256が不正です", key.Name)
+			}
+		}
+		for _, scope := range key.Scopes {
+			if !isKnownScope(scope) {
+				return fmt.Errorf("APIキー%sのスコープ%sは不明です", key.Name, scope)
+			}
+		}
+	}
+	return nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range knownScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// principal は認証済みの呼び出し元です
+type principal struct {
+	subject string
+	// tenant は保存データを分離する単位です
+	tenant string
+	scopes map[string]bool
+}
+
+func (p *principal) hasScope(scope string) bool {
+	return p.scopes[scope]
+}
+
+type principalContextKey struct{}
+
+func withPrincipal(ctx context.Context, p *principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// principalFromContext は認証済みの呼び出し元を返します。認証が無効の場合はnilです。
+func principalFromContext(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalContextKey{}).(*principal)
+	return p
+}
+
+// defaultTenant は認証が無効の場合に全ての呼び出し元が属するテナントです
+const defaultTenant = "default"
+
+// tenantFromContext は呼び出し元のテナントを返します
+func tenantFromContext(ctx context.Context) string {
+	if p := principalFromContext(ctx); p != nil && p.tenant != "" {
+		return p.tenant
+	}
+	return defaultTenant
+}
+
+// authenticator はAPIキーまたはHMAC署名のJWTで呼び出し元を認証します
+type authenticator struct {
+	apiKeys     []apiKeyEntry
+	jwtSecret   []byte
+	jwtIssuer   string
+	jwtAudience string
+	now         func() time.Time
+}
+
+type apiKeyEntry struct {
+	name   string
+	tenant string
+	sum    []byte
+	scopes map[string]bool
+}
+
+func newAuthenticator(cfg authConfig) *authenticator {
+	a := &authenticator{
+		jwtSecret:   []byte(cfg.JWTSecret),
+		jwtIssuer:   cfg.JWTIssuer,
+		jwtAudience: cfg.JWTAudience,
+		now:         time.Now,
+	}
+	for _, key := range cfg.APIKeys {
+		entry := apiKeyEntry{name: key.Name, tenant: key.Tenant, scopes: scopeSet(key.Scopes)}
+		if entry.tenant == "" {
+			entry.tenant = key.Name
+		}
+		if key.KeySHA256 != "" {
+			entry.sum, _ = hex.DecodeString(key.KeySHA256)
+		} else {
+			sum := sha256.Sum256([]byte(key.Key))
+			entry.sum = sum[:]
+		}
+		a.apiKeys = append(a.apiKeys, entry)
+	}
+	return a
+}
+
+func scopeSet(scopes []string) map[string]bool {
+	set := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		set[scope] = true
+	}
+	return set
+}
+
+var (
+	errMissingCredentials = errors.New("認証情報がありません")
+	errInvalidCredentials = errors.New("認証情報が正しくありません")
+)
+
+// authenticate はリクエストヘッダーの認証情報を検証します。
+// "Authorization: Bearer <JWTまたはAPIキー>" と "X-API-Key: <APIキー>" を受け付けます。
+func (a *authenticator) authenticate(header http.Header) (*principal, error) {
+	if key := header.Get("X-API-Key"); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+	authz := header.Get("Authorization")
+	if authz == "" {
+		return nil, errMissingCredentials
+	}
+	scheme, token, ok := strings.Cut(authz, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, errInvalidCredentials
+	}
+	token = strings.TrimSpace(token)
+	if strings.Count(token, ".") == 2 {
+		return a.authenticateJWT(token)
+	}
+	return a.authenticateAPIKey(token)
+}
+
+func (a *authenticator) authenticateAPIKey(key string) (*principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	for _, entry := range a.apiKeys {
+		if subtle.ConstantTimeCompare(sum[:], entry.sum) == 1 {
+			return &principal{subject: "key:" + entry.name, tenant: entry.tenant, scopes: entry.scopes}, nil
+		}
+	}
+	return nil, errInvalidCredentials
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scopes    []string        `json:"scopes"`
+	Tenant    string          `json:"tenant"`
+}
+
+// authenticateJWT はHS256/HS384/HS512で署名されたJWTを検証します
+func (a *authenticator) authenticateJWT(token string) (*principal, error) {
+	if len(a.jwtSecret) == 0 {
+		return nil, errInvalidCredentials
+	}
+	parts := strings.Split(token, ".")
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, errInvalidCredentials
+	}
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("%w: 署名アルゴリズム%sには対応していません", errInvalidCredentials, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidCredentials
+	}
+	mac := hmac.New(newHash, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errInvalidCredentials
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, errInvalidCredentials
+	}
+	now := a.now()
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: 有効期限(exp)がありません", errInvalidCredentials)
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return nil, fmt.Errorf("%w: トークンの有効期限が切れています", errInvalidCredentials)
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return nil, fmt.Errorf("%w: トークンはまだ有効ではありません", errInvalidCredentials)
+	}
+	if a.jwtIssuer != "" && claims.Issuer != a.jwtIssuer {
+		return nil, fmt.Errorf("%w: 発行者が一致しません", errInvalidCredentials)
+	}
+	if a.jwtAudience != "" && !audienceContains(claims.Audience, a.jwtAudience) {
+		return nil, fmt.Errorf("%w: 対象者が一致しません", errInvalidCredentials)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: subがありません", errInvalidCredentials)
+	}
+
+	scopes := claims.Scopes
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+	tenant := claims.Tenant
+	if tenant == "" {
+		tenant = claims.Subject
+	}
+	return &principal{subject: "user:" + claims.Subject, tenant: tenant, scopes: scopeSet(scopes)}, nil
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audienceContains はaudクレーム（文字列または文字列の配列）に指定の値が含まれるかを返します
+func audienceContains(raw json.RawMessage, audience string) bool {
+	if len(raw) == 0 {
+		return false
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return false
+	}
+	for _, aud := range list {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize はリクエストを認証し、RPCに必要なスコープを持っているかを確認します
+func (a *authenticator) authorize(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+	p, err := a.authenticate(header)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	scope, ok := procedureScopes[procedure]
+	if !ok {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("%sの権限が定義されていません", procedure))
+	}
+	if !p.hasScope(scope) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("%sスコープが必要です", scope))
+	}
+	return withPrincipal(ctx, p), nil
+}
+
+// authInterceptor は全てのRPCで認証とスコープの確認を行うconnectのインターセプターです
+type authInterceptor struct {
+	auth *authenticator
+}
+
+var _ connect.Interceptor = (*authInterceptor)(nil)
+
+func (i *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := i.auth.authorize(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.auth.authorize(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
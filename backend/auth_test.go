@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	score "score-splitter/backend/gen/go"
+	"score-splitter/backend/gen/go/scoreconnect"
+
+	"connectrpc.com/connect"
+)
+
+const testJWTSecret = "test-secret"
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+// signTestJWT はHS256で署名したJWTを返します。algには署名と異なる値を指定できます。
+func signTestJWT(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signing))
+	return signing + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTestAuthenticator() *authenticator {
+	a := newAuthenticator(authConfig{
+		APIKeys: []apiKeyConfig{
+			{Name: "reader", Key: "reader-key", Scopes: []string{scopeRead}},
+		},
+		JWTSecret:   testJWTSecret,
+		JWTIssuer:   "score-splitter-test",
+		JWTAudience: "score-splitter",
+	})
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "alice",
+		"iss":   "score-splitter-test",
+		"aud":   []string{"other", "score-splitter"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"scope": "read trim",
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newTestAuthenticator()
+	withClaims := func(change func(map[string]any)) map[string]any {
+		claims := validClaims()
+		change(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name       string
+		procedure  string
+		header     func(t *testing.T) http.Header
+		wantCode   connect.Code
+		wantTenant string
+	}{
+		{
+			name:      "有効なJWT",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", validClaims()))
+			},
+			wantTenant: "alice",
+		},
+		{
+			name:      "有効なAPIキー",
+			procedure: scoreconnect.ScoreServiceListScoresProcedure,
+			header: func(t *testing.T) http.Header {
+				return http.Header{"X-Api-Key": {"reader-key"}}
+			},
+			wantTenant: "reader",
+		},
+		{
+			name:      "認証情報なし",
+			procedure: scoreconnect.ScoreServiceListScoresProcedure,
+			header:    func(t *testing.T) http.Header { return http.Header{} },
+			wantCode:  connect.CodeUnauthenticated,
+		},
+		{
+			name:      "有効期限切れ",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", withClaims(func(c map[string]any) {
+					c["exp"] = testNow.Add(-jwtLeeway - time.Second).Unix()
+				})))
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "有効期限なし",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", withClaims(func(c map[string]any) { delete(c, "exp") })))
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "対象者が違う",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", withClaims(func(c map[string]any) { c["aud"] = "other" })))
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "発行者が違う",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", withClaims(func(c map[string]any) { c["iss"] = "evil" })))
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "alg none",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				token := signTestJWT(t, "none", validClaims())
+				// 署名を空にした none のトークン
+				return bearer(token[:strings.LastIndex(token, ".")+1])
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "algと署名が一致しない",
+			procedure: scoreconnect.ScoreServiceTrimScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS512", validClaims()))
+			},
+			wantCode: connect.CodeUnauthenticated,
+		},
+		{
+			name:      "スコープが足りない",
+			procedure: scoreconnect.ScoreServiceGenerateScrollVideoProcedure,
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", validClaims()))
+			},
+			wantCode: connect.CodePermissionDenied,
+		},
+		{
+			name:      "APIキーのスコープが足りない",
+			procedure: scoreconnect.ScoreServiceDeleteScoreProcedure,
+			header: func(t *testing.T) http.Header {
+				return http.Header{"X-Api-Key": {"reader-key"}}
+			},
+			wantCode: connect.CodePermissionDenied,
+		},
+		{
+			name:      "権限が定義されていないRPC",
+			procedure: "/score.ScoreService/Unknown",
+			header: func(t *testing.T) http.Header {
+				return bearer(signTestJWT(t, "HS256", withClaims(func(c map[string]any) {
+					c["scope"] = "read trim upload video"
+				})))
+			},
+			wantCode: connect.CodePermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := a.authorize(context.Background(), tt.procedure, tt.header(t))
+			if tt.wantCode != 0 {
+				var connectErr *connect.Error
+				if !errors.As(err, &connectErr) || connectErr.Code() != tt.wantCode {
+					t.Fatalf("err = %v, want code %v", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got := tenantFromContext(ctx); got != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestProcedureScopesCoverService(t *testing.T) {
+	service := score.File_score_proto.Services().ByName("ScoreService")
+	methods := service.Methods()
+	for i := range methods.Len() {
+		procedure := "/" + string(service.FullName()) + "/" + string(methods.Get(i).Name())
+		if _, ok := procedureScopes[procedure]; !ok {
+			t.Errorf("procedureScopes has no scope for %s", procedure)
+		}
+	}
+	if len(procedureScopes) != methods.Len() {
+		t.Errorf("procedureScopes has %d entries for %d methods", len(procedureScopes), methods.Len())
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchTrimRequest は項目の設定とテンプレートを合わせた1件分のトリミングのリクエストを返します。
+// トリミングエリア（areas と page_settings）はまとめて扱い、項目に片方でもあれば項目の設定だけを使います。
+func batchTrimRequest(template, settings *score.TrimScoreRequest, title string, pdf []byte) *score.TrimScoreRequest {
+	req := &score.TrimScoreRequest{}
+	if template != nil {
+		req = proto.Clone(template).(*score.TrimScoreRequest)
+	}
+	if settings != nil {
+		if len(settings.GetAreas()) > 0 || len(settings.GetPageSettings()) > 0 {
+			req.Areas = settings.GetAreas()
+			req.PageSettings = settings.GetPageSettings()
+		}
+		if len(settings.GetIncludePages()) > 0 {
+			req.IncludePages = settings.GetIncludePages()
+		}
+		if settings.GetPassword() != "" {
+			req.Password = settings.GetPassword()
+		}
+		if settings.GetOrientation() != "" {
+			req.Orientation = settings.GetOrientation()
+		}
+		if settings.GetSplit() != nil {
+			req.Split = settings.GetSplit()
+		}
+		if settings.GetStrip() != nil {
+			req.Strip = settings.GetStrip()
+		}
+	}
+	req.Title = title
+	req.PdfFile = pdf
+	return req
+}
+
+// uniqueFilename はZIP内で重複しないようにファイル名に連番を付けます
+func uniqueFilename(name string, used map[string]bool) string {
+	candidate := name
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// BatchTrimScores は複数のスコアを順にトリミングし、項目ごとの進捗と結果をストリームで返します
+func (s *scoreService) BatchTrimScores(
+	ctx context.Context,
+	req *connect.Request[score.BatchTrimScoresRequest],
+	stream *connect.ServerStream[score.BatchTrimScoresResponse],
+) error {
+	items := req.Msg.GetItems()
+	if len(items) == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("処理するスコアがありません"))
+	}
+	if len(items) > s.cfg.MaxBatchItems {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("一度に処理できるスコアは%d件までです（%d件）", s.cfg.MaxBatchItems, len(items)))
+	}
+
+	tenant := tenantFromContext(ctx)
+	template := req.Msg.GetTemplate()
+	if id := req.Msg.GetTemplateId(); id != "" {
+		if template != nil {
+			return connect.NewError(connect.CodeInvalidArgument, errors.New("templateとtemplate_idは同時に指定できません"))
+		}
+		_, saved, err := s.loadTemplate(ctx, tenant, id)
+		if err != nil {
+			return storeError(err)
+		}
+		template = saved
+	}
+	lang := getLanguageFromHeader(req.Header())
+	total := int32(len(items))
+	log.Printf("BatchTrimScores request: items=%d zip=%v lang=%s", total, req.Msg.GetZip(), lang)
+
+	var (
+		completed, failed int32
+		zipChunks         *zipChunkWriter
+		zipWriter         *zip.Writer
+		usedNames         = make(map[string]bool)
+	)
+
+	// overall は項目内の進捗（0-100）をバッチ全体の進捗に換算します
+	overall := func(index int, progress int32) int32 {
+		return int32((int64(index)*100 + int64(progress)) * 100 / (int64(total) * 100))
+	}
+	send := func(res *score.BatchTrimScoresResponse) error {
+		res.CompletedItems = completed
+		res.FailedItems = failed
+		res.TotalItems = total
+		return stream.Send(res)
+	}
+	if req.Msg.GetZip() {
+		// ZIPは全体をメモリに溜めず、チャンクの大きさになるたびに送る
+		zipChunks = &zipChunkWriter{size: int(s.cfg.UploadChunkBytes), send: func(offset int64, chunk []byte) error {
+			return send(&score.BatchTrimScoresResponse{
+				ItemIndex: -1,
+				Stage:     "zip",
+				Progress:  overall(int(completed+failed), 0),
+				ZipChunk:  chunk,
+				ZipOffset: offset,
+			})
+		}}
+		zipWriter = zip.NewWriter(zipChunks)
+	}
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		index := int32(i)
+
+		filename, trimmed, err := s.trimBatchItem(ctx, tenant, template, item, lang, func(p *score.TrimScoreProgressResponse) error {
+			return send(&score.BatchTrimScoresResponse{
+				ItemIndex: index,
+				Stage:     p.GetStage(),
+				Progress:  overall(i, p.GetProgress()),
+				Message:   fmt.Sprintf("[%d/%d] %s", i+1, total, p.GetMessage()),
+			})
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			log.Printf("BatchTrimScores item %d failed: %v", i, err)
+			if err := send(&score.BatchTrimScoresResponse{
+				ItemIndex: index,
+				Stage:     "failed",
+				Progress:  overall(i+1, 0),
+				Message:   fmt.Sprintf("[%d/%d] 処理に失敗しました", i+1, total),
+				Error:     batchItemError(err),
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		completed++
+		filename = uniqueFilename(filename, usedNames)
+		res := &score.BatchTrimScoresResponse{
+			ItemIndex: index,
+			Stage:     "complete",
+			Progress:  overall(i+1, 0),
+			Message:   fmt.Sprintf("[%d/%d] %s", i+1, total, getLocalizedMessage("conversion_complete", lang)),
+			Filename:  filename,
+		}
+		if zipWriter != nil {
+			w, err := zipWriter.Create(filename)
+			if err != nil {
+				return zipChunks.writeError(err)
+			}
+			if _, err := w.Write(trimmed); err != nil {
+				return zipChunks.writeError(err)
+			}
+		} else {
+			res.TrimmedPdf = trimmed
+		}
+		if err := send(res); err != nil {
+			return err
+		}
+	}
+
+	final := &score.BatchTrimScoresResponse{
+		ItemIndex: -1,
+		Stage:     "complete",
+		Progress:  100,
+		Message:   fmt.Sprintf("%d件中%d件のトリミングが完了しました（失敗: %d件）", total, completed, failed),
+	}
+	// 成功した項目が無い場合は空のZIPを返さない
+	if zipWriter != nil && completed > 0 {
+		if err := zipWriter.Close(); err != nil {
+			return zipChunks.writeError(err)
+		}
+		if err := zipChunks.flush(); err != nil {
+			return err
+		}
+		final.ZipFilename = "trimmed-scores.zip"
+		final.ZipSize = zipChunks.offset
+	}
+	return send(final)
+}
+
+// trimBatchItem は1件分のPDFを取得してトリミングし、推奨ファイル名と結果を返します
+func (s *scoreService) trimBatchItem(
+	ctx context.Context,
+	tenant string,
+	template *score.TrimScoreRequest,
+	item *score.BatchTrimItem,
+	lang string,
+	send progressSender,
+) (string, []byte, error) {
+	title := item.GetTitle()
+	pdf := item.GetPdfFile()
+	if id := item.GetScoreId(); id != "" {
+		if len(pdf) > 0 {
+			return "", nil, errors.New("pdf_fileとscore_idは同時に指定できません")
+		}
+		obj, data, err := s.store.get(ctx, tenant, storeKindScores, id)
+		if err != nil {
+			return "", nil, err
+		}
+		if title == "" {
+			title = obj.Title
+		}
+		pdf = data
+	}
+	if len(pdf) == 0 {
+		return "", nil, errors.New("PDFファイルが空です")
+	}
+
+	trimReq := batchTrimRequest(template, item.GetSettings(), title, pdf)
+	defaultAreas, pageOverrides, err := trimAreasFromRequest(trimReq)
+	if err != nil {
+		return "", nil, err
+	}
+	split, err := segmentSplitFromRequest(trimReq.GetSplit())
+	if err != nil {
+		return "", nil, err
+	}
+	strip, err := stripLayoutFromRequest(trimReq)
+	if err != nil {
+		return "", nil, err
+	}
+	trimmed, err := buildTrimmedPDFWithProgress(
+		pdf,
+		defaultAreas,
+		trimReq.GetIncludePages(),
+		trimReq.GetPassword(),
+		pageOverrides,
+		split,
+		strip,
+		trimReq.GetOrientation(),
+		s.cfg.pdfLimits(),
+		send,
+		lang,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	return trimmedFilename(title, trimReq.GetOrientation()), trimmed, nil
+}
+
+// batchItemError は項目のエラーを利用者向けのメッセージに変換します
+func batchItemError(err error) string {
+	switch {
+	case errors.Is(err, errObjectNotFound):
+		return "指定されたスコアが見つかりません"
+	default:
+		var connectErr *connect.Error
+		if errors.As(trimError(err), &connectErr) {
+			return connectErr.Message()
+		}
+		return err.Error()
+	}
+}
+
+// zipChunkWriter はZIPの出力をsizeバイトずつに区切ってsendに渡します
+type zipChunkWriter struct {
+	size   int
+	send   func(offset int64, chunk []byte) error
+	buf    []byte
+	offset int64
+	// err はsendのエラーです。zip.Writerのエラーと区別するために保持します。
+	err error
+}
+
+func (w *zipChunkWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), w.size-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(w.buf) == w.size {
+			// Sendは戻る前にメッセージを書き出すので、送った後はバッファを使い回せる
+			if err := w.sendChunk(w.buf); err != nil {
+				return written, err
+			}
+			w.buf = w.buf[:0]
+		}
+	}
+	return written, nil
+}
+
+// flush は残りのバイト列を送ります
+func (w *zipChunkWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.sendChunk(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *zipChunkWriter) sendChunk(chunk []byte) error {
+	if err := w.send(w.offset, chunk); err != nil {
+		w.err = err
+		return err
+	}
+	w.offset += int64(len(chunk))
+	return nil
+}
+
+// writeError はZIPの書き込みのエラーを返します。チャンクの送信に失敗した場合はそのエラーを返します。
+func (w *zipChunkWriter) writeError(err error) error {
+	if w.err != nil {
+		return w.err
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
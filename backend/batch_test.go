@@ -0,0 +1,76 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestZipChunkWriter(t *testing.T) {
+	var (
+		joined bytes.Buffer
+		chunks int
+	)
+	w := &zipChunkWriter{size: 1000, send: func(offset int64, chunk []byte) error {
+		if offset != int64(joined.Len()) {
+			t.Errorf("chunk %d offset = %d, want %d", chunks, offset, joined.Len())
+		}
+		if len(chunk) > 1000 {
+			t.Errorf("chunk %d has %d bytes, want at most 1000", chunks, len(chunk))
+		}
+		chunks++
+		joined.Write(chunk)
+		return nil
+	}}
+
+	// 圧縮しても小さくならない内容にして、ZIPを複数のチャンクに分けさせる
+	random := make([]byte, 5000)
+	rand.NewChaCha8([32]byte{}).Read(random)
+	files := map[string][]byte{
+		"a.pdf": random[:2000],
+		"b.pdf": random[2000:],
+	}
+	zw := zip.NewWriter(w)
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if chunks < 2 {
+		t.Errorf("sent %d chunk(s), want the ZIP split into several", chunks)
+	}
+	if w.offset != int64(joined.Len()) {
+		t.Errorf("offset = %d, want %d", w.offset, joined.Len())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(joined.Bytes()), int64(joined.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || !bytes.Equal(data, files[f.Name]) {
+			t.Errorf("%s: content mismatch (%v)", f.Name, err)
+		}
+	}
+	if len(zr.File) != len(files) {
+		t.Errorf("ZIP has %d files, want %d", len(zr.File), len(files))
+	}
+}
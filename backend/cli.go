@@ -0,0 +1,752 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"gopkg.in/yaml.v2"
+)
+
+// cliCommand はコマンドラインのサブコマンドです
+type cliCommand struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var cliCommands map[string]cliCommand
+
+func init() {
+	// runHelpがcliCommandsを参照するため、初期化をinitで行う
+	cliCommands = map[string]cliCommand{
+		"serve":   {"HTTPサーバーを起動します（サブコマンド省略時と同じ）", nil},
+		"trim":    {"テンプレートのトリミングエリアで複数のPDFをまとめてトリミングします", runTrim},
+		"inspect": {"PDFのページ数とページサイズを表示します", runInspect},
+		"nup":     {"複数のページを1枚に並べたPDFを作成します", runNUp},
+		"video":   {"PDFから横スクロール動画を生成します", runVideo},
+		"help":    {"使い方を表示します", runHelp},
+	}
+}
+
+// errCLIUsage は使い方の誤りです。メッセージの後に使い方を表示します。
+var errCLIUsage = errors.New("使い方が正しくありません")
+
+// runCLI はサブコマンドを実行し、終了コードを返します
+func runCLI(name string, args []string) int {
+	if name == "serve" {
+		serve(args)
+		return 0
+	}
+	cmd, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "不明なサブコマンドです: %s\n\n", name)
+		runHelp(nil, os.Stderr, os.Stderr)
+		return 2
+	}
+	if err := cmd.run(args, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "score-splitter %s: %v\n", name, err)
+		if errors.Is(err, errCLIUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func runHelp(args []string, stdout, stderr io.Writer) error {
+	fmt.Fprintln(stdout, "使い方: score-splitter <サブコマンド> [フラグ] [引数]")
+	fmt.Fprintln(stdout, "\nサブコマンド:")
+	names := make([]string, 0, len(cliCommands))
+	for name := range cliCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(stdout, "  %-8s %s\n", name, cliCommands[name].summary)
+	}
+	fmt.Fprintln(stdout, "\n各サブコマンドのフラグは score-splitter <サブコマンド> -h で表示します。")
+	return nil
+}
+
+func newCLIFlagSet(name, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("score-splitter "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "使い方: score-splitter %s %s\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// addLimitFlags はPDFの上限を指定するフラグを追加します。既定値はサーバーと同じです。
+func addLimitFlags(fs *flag.FlagSet) func() pdfLimits {
+	defaults := defaultConfig()
+	maxPages := fs.Int("max-pages", defaults.MaxPDFPages, "PDFの最大ページ数")
+	maxStreamBytes := fs.Int64("max-stream-bytes", defaults.MaxPDFStreamBytes, "展開後のストリームの最大バイト数")
+	maxDecodedBytes := fs.Int64("max-decoded-bytes", defaults.MaxPDFDecodedBytes, "展開後の全てのストリームの合計の最大バイト数")
+	return func() pdfLimits {
+		return pdfLimits{maxPages: *maxPages, maxStreamBytes: *maxStreamBytes, maxDecodedBytes: *maxDecodedBytes}
+	}
+}
+
+// addVideoLimitFlags は動画生成の上限を指定するフラグを追加します。既定値はサーバーと同じです。
+func addVideoLimitFlags(fs *flag.FlagSet) func() videoLimits {
+	defaults := defaultConfig()
+	maxStripPixels := fs.Int64("max-strip-pixels", defaults.MaxVideoStripPixels, "ページを横に並べた画像の最大画素数")
+	maxSeconds := fs.Int("max-seconds", defaults.MaxVideoSeconds, "動画の長さの上限（秒）")
+	return func() videoLimits {
+		return videoLimits{maxStripPixels: *maxStripPixels, maxSeconds: *maxSeconds}
+	}
+}
+
+// expandInputs は引数のファイル名、グロブ、ディレクトリ（直下の*.pdf）をPDFのパスの一覧に展開します
+func expandInputs(args []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			matches, err := filepath.Glob(filepath.Join(arg, "*.[pP][dD][fF]"))
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(matches)
+			for _, m := range matches {
+				add(m)
+			}
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("パターン%qが不正です: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%sに一致するファイルがありません", arg)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return paths, nil
+}
+
+// trimTemplate はCLIで使うトリミング設定のファイル（JSONまたはYAML）の内容です。
+// 座標はTrimScoreRequestと同じくページに対する割合（0.0 - 1.0）で指定します。
+type trimTemplate struct {
+	Areas        []templateArea `json:"areas" yaml:"areas"`
+	Pages        []templatePage `json:"pages" yaml:"pages"`
+	IncludePages []int32        `json:"include_pages" yaml:"include_pages"`
+	Orientation  string         `json:"orientation" yaml:"orientation"`
+	Password     string         `json:"password" yaml:"password"`
+	Split        *templateSplit `json:"split" yaml:"split"`
+	Strip        *templateStrip `json:"strip" yaml:"strip"`
+}
+
+type templateArea struct {
+	Top    float64 `json:"top" yaml:"top"`
+	Left   float64 `json:"left" yaml:"left"`
+	Width  float64 `json:"width" yaml:"width"`
+	Height float64 `json:"height" yaml:"height"`
+	// JoinWithNext は次のエリア（次のページの最初のエリアを含む）と縦につなげて1ページにします
+	JoinWithNext bool `json:"join_with_next" yaml:"join_with_next"`
+}
+
+// templateSplit は縦に長いトリミング範囲の分割設定です（SegmentSplitと同じ意味）
+type templateSplit struct {
+	MaxAspectRatio float64  `json:"max_aspect_ratio" yaml:"max_aspect_ratio"`
+	MaxHeight      float64  `json:"max_height" yaml:"max_height"`
+	Mode           string   `json:"mode" yaml:"mode"`
+	Overlap        *float64 `json:"overlap" yaml:"overlap"`
+}
+
+// templateStrip は横一列に並べる出力の設定です（StripLayoutと同じ意味）
+type templateStrip struct {
+	Height       float64 `json:"height" yaml:"height"`
+	MaxPageWidth float64 `json:"max_page_width" yaml:"max_page_width"`
+	Gap          float64 `json:"gap" yaml:"gap"`
+}
+
+// templatePage はページごとのトリミングエリアです
+type templatePage struct {
+	Page  int32          `json:"page" yaml:"page"`
+	Areas []templateArea `json:"areas" yaml:"areas"`
+}
+
+func loadTrimTemplate(path string) (*trimTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("テンプレートを読み込めません: %w", err)
+	}
+	var tmpl trimTemplate
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&tmpl)
+	} else {
+		err = yaml.UnmarshalStrict(data, &tmpl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("テンプレート%sが不正です: %w", path, err)
+	}
+	return &tmpl, nil
+}
+
+func cropAreas(areas []templateArea) []*score.CropArea {
+	out := make([]*score.CropArea, len(areas))
+	for i, a := range areas {
+		out[i] = &score.CropArea{Top: a.Top, Left: a.Left, Width: a.Width, Height: a.Height, JoinWithNext: a.JoinWithNext}
+	}
+	return out
+}
+
+// request はテンプレートをTrimScoreRequestに変換します
+func (t *trimTemplate) request(title string, pdf []byte) *score.TrimScoreRequest {
+	req := &score.TrimScoreRequest{
+		Title:        title,
+		PdfFile:      pdf,
+		Areas:        cropAreas(t.Areas),
+		Password:     t.Password,
+		IncludePages: t.IncludePages,
+		Orientation:  t.Orientation,
+	}
+	if t.Split != nil {
+		req.Split = &score.SegmentSplit{
+			MaxAspectRatio: t.Split.MaxAspectRatio,
+			MaxHeight:      t.Split.MaxHeight,
+			Mode:           t.Split.Mode,
+			Overlap:        t.Split.Overlap,
+		}
+	}
+	if t.Strip != nil {
+		req.Strip = &score.StripLayout{
+			Height:       t.Strip.Height,
+			MaxPageWidth: t.Strip.MaxPageWidth,
+			Gap:          t.Strip.Gap,
+		}
+	}
+	for _, p := range t.Pages {
+		req.PageSettings = append(req.PageSettings, &score.PageTrimSetting{
+			PageNumber: p.Page,
+			Areas:      cropAreas(p.Areas),
+		})
+	}
+	return req
+}
+
+func noProgress(*score.TrimScoreProgressResponse) error { return nil }
+
+func runTrim(args []string, stdout, stderr io.Writer) error {
+	fs := newCLIFlagSet("trim", "-template <ファイル> [-out <ディレクトリ>] <PDF|グロブ|ディレクトリ>...", stderr)
+	templatePath := fs.String("template", "", "トリミング設定のファイル (JSON または YAML)")
+	outDir := fs.String("out", "trimmed", "出力先のディレクトリ")
+	password := fs.String("password", "", "PDFのパスワード（テンプレートの指定より優先）")
+	orientation := fs.String("orientation", "", "出力向き portrait または landscape（テンプレートの指定より優先）")
+	limits := addLimitFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templatePath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: -template と入力ファイルを指定してください", errCLIUsage)
+	}
+
+	tmpl, err := loadTrimTemplate(*templatePath)
+	if err != nil {
+		return err
+	}
+	if *password != "" {
+		tmpl.Password = *password
+	}
+	if *orientation != "" {
+		tmpl.Orientation = *orientation
+	}
+	inputs, err := expandInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+	outputs, err := trimOutputs(inputs, *outDir, tmpl.Orientation)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+
+	failed := 0
+	for i, input := range inputs {
+		out := outputs[i]
+		if err := trimFile(input, out, tmpl, limits()); err != nil {
+			failed++
+			fmt.Fprintf(stderr, "NG %s: %v\n", input, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "OK %s -> %s\n", input, out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d件中%d件が失敗しました", len(inputs), failed)
+	}
+	return nil
+}
+
+// trimTitle は入力のファイル名から拡張子を除いたものを曲名にします
+func trimTitle(input string) string {
+	return strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+}
+
+// trimOutputs は入力ごとの出力先のパスを返します。
+// 別のディレクトリにある同じ名前のPDFなど出力先が重なる場合は、結果を上書きしないようにエラーを返します。
+func trimOutputs(inputs []string, outDir, orientation string) ([]string, error) {
+	outputs := make([]string, len(inputs))
+	// 大文字と小文字を区別しないファイルシステムでも重ならないように、小文字にして比べる
+	seen := make(map[string]string)
+	for i, input := range inputs {
+		name := trimmedFilename(trimTitle(input), orientation)
+		if prev, ok := seen[strings.ToLower(name)]; ok {
+			return nil, fmt.Errorf("%w: %sと%sの出力先がどちらも%sになります。別々に実行して -out を分けてください",
+				errCLIUsage, prev, input, name)
+		}
+		seen[strings.ToLower(name)] = input
+		outputs[i] = filepath.Join(outDir, name)
+	}
+	return outputs, nil
+}
+
+// trimFile は1つのPDFをテンプレートでトリミングし、outに書き出します
+func trimFile(input, out string, tmpl *trimTemplate, limits pdfLimits) error {
+	pdf, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	req := tmpl.request(trimTitle(input), pdf)
+	defaultAreas, pageOverrides, err := trimAreasFromRequest(req)
+	if err != nil {
+		return err
+	}
+	split, err := segmentSplitFromRequest(req.GetSplit())
+	if err != nil {
+		return err
+	}
+	strip, err := stripLayoutFromRequest(req)
+	if err != nil {
+		return err
+	}
+	trimmed, err := buildTrimmedPDFWithProgress(
+		pdf,
+		defaultAreas,
+		req.GetIncludePages(),
+		req.GetPassword(),
+		pageOverrides,
+		split,
+		strip,
+		req.GetOrientation(),
+		limits,
+		noProgress,
+		"ja",
+	)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, trimmed, 0644)
+}
+
+// pdfInfo はinspectで表示するPDFの情報です
+type pdfInfo struct {
+	File      string     `json:"file"`
+	Version   string     `json:"version"`
+	Encrypted bool       `json:"encrypted"`
+	PageCount int        `json:"page_count"`
+	Pages     []pageInfo `json:"pages"`
+}
+
+type pageInfo struct {
+	Page     int     `json:"page"`
+	WidthPt  float64 `json:"width_pt"`
+	HeightPt float64 `json:"height_pt"`
+	Rotate   int     `json:"rotate"`
+}
+
+func runInspect(args []string, stdout, stderr io.Writer) error {
+	fs := newCLIFlagSet("inspect", "[-json] <PDF|グロブ|ディレクトリ>...", stderr)
+	asJSON := fs.Bool("json", false, "JSONで出力します")
+	password := fs.String("password", "", "PDFのパスワード")
+	limits := addLimitFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: 入力ファイルを指定してください", errCLIUsage)
+	}
+	inputs, err := expandInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var infos []pdfInfo
+	failed := 0
+	for _, input := range inputs {
+		info, err := inspectPDF(input, *password, limits())
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "NG %s: %v\n", input, err)
+			continue
+		}
+		infos = append(infos, *info)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(infos); err != nil {
+			return err
+		}
+	} else {
+		for _, info := range infos {
+			fmt.Fprintf(stdout, "%s: PDF %s, %dページ", info.File, info.Version, info.PageCount)
+			if info.Encrypted {
+				fmt.Fprint(stdout, ", 暗号化あり")
+			}
+			fmt.Fprintln(stdout)
+			for _, p := range info.Pages {
+				fmt.Fprintf(stdout, "  %4d: %.1f x %.1f pt (%.0f x %.0f mm)", p.Page, p.WidthPt, p.HeightPt, p.WidthPt*25.4/72, p.HeightPt*25.4/72)
+				if p.Rotate != 0 {
+					fmt.Fprintf(stdout, " 回転%d°", p.Rotate)
+				}
+				fmt.Fprintln(stdout)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d件中%d件を読み込めませんでした", len(inputs), failed)
+	}
+	return nil
+}
+
+func inspectPDF(path, password string, limits pdfLimits) (*pdfInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := readPDFContext(data, password, limits)
+	if err != nil {
+		return nil, err
+	}
+	info := &pdfInfo{
+		File:      path,
+		Version:   ctx.HeaderVersion.String(),
+		Encrypted: ctx.E != nil,
+		PageCount: ctx.PageCount,
+	}
+	for i := 1; i <= ctx.PageCount; i++ {
+		_, _, inh, err := ctx.PageDict(i, false)
+		if err != nil {
+			return nil, err
+		}
+		box := inh.CropBox
+		if box == nil {
+			box = inh.MediaBox
+		}
+		if box == nil {
+			return nil, fmt.Errorf("ページ%vのサイズ情報を取得できません", i)
+		}
+		info.Pages = append(info.Pages, pageInfo{Page: i, WidthPt: box.Width(), HeightPt: box.Height(), Rotate: inh.Rotate})
+	}
+	return info, nil
+}
+
+func runNUp(args []string, stdout, stderr io.Writer) error {
+	fs := newCLIFlagSet("nup", "[-rows 2 -cols 2 -form A4L] -out <出力PDF> <PDF>", stderr)
+	rows := fs.Int("rows", 2, "1枚に並べる行数")
+	cols := fs.Int("cols", 2, "1枚に並べる列数")
+	form := fs.String("form", "A4L", "用紙サイズ（末尾のLで横向き。例: A4L, A3, LetterL）")
+	out := fs.String("out", "", "出力するPDFのパス")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: -out と入力ファイルを1つ指定してください", errCLIUsage)
+	}
+	if *rows < 1 || *cols < 1 {
+		return fmt.Errorf("%w: 行数と列数は1以上にしてください", errCLIUsage)
+	}
+
+	in, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	conf := model.NewDefaultConfiguration()
+	nup, err := pdfapi.PDFGridConfig(*rows, *cols, "formsize:"+*form, conf)
+	if err != nil {
+		return fmt.Errorf("用紙の指定が不正です: %w", err)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := pdfapi.NUp(bytes.NewReader(in), f, nil, nil, nup, conf); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "OK %s -> %s\n", fs.Arg(0), *out)
+	return nil
+}
+
+// templateTempo はテンポマップのファイル（JSONまたはYAML）の1項目です（TempoChangeと同じ意味）
+type templateTempo struct {
+	Segment  int32   `json:"segment" yaml:"segment"`
+	Position float64 `json:"position" yaml:"position"`
+	BPM      int32   `json:"bpm" yaml:"bpm"`
+	Hold     float64 `json:"hold" yaml:"hold"`
+	Ramp     bool    `json:"ramp" yaml:"ramp"`
+}
+
+func loadTempoMap(path string) ([]*score.TempoChange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("テンポマップを読み込めません: %w", err)
+	}
+	var entries []templateTempo
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&entries)
+	} else {
+		err = yaml.UnmarshalStrict(data, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("テンポマップ%sが不正です: %w", path, err)
+	}
+	changes := make([]*score.TempoChange, len(entries))
+	for i, e := range entries {
+		changes[i] = &score.TempoChange{
+			SegmentIndex: e.Segment,
+			Position:     e.Position,
+			Bpm:          e.BPM,
+			HoldSeconds:  e.Hold,
+			Ramp:         e.Ramp,
+		}
+	}
+	return changes, nil
+}
+
+// parseSegmentBeats はカンマ区切りの拍数または小節数をセグメントごとの拍数の指定に変換します
+func parseSegmentBeats(beats, measures string) ([]*score.SegmentBeats, error) {
+	if beats != "" && measures != "" {
+		return nil, errors.New("-beats と -measures は同時に指定できません")
+	}
+	list := beats
+	if list == "" {
+		list = measures
+	}
+	if list == "" {
+		return nil, nil
+	}
+	var out []*score.SegmentBeats
+	for _, field := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%qは数値ではありません", field)
+		}
+		if beats != "" {
+			out = append(out, &score.SegmentBeats{Beats: v})
+		} else {
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("小節数%qは整数で指定してください", field)
+			}
+			out = append(out, &score.SegmentBeats{Measures: int32(v)})
+		}
+	}
+	return out, nil
+}
+
+// parseOverlays は -overlay の一覧を読み取ります。指定がなければnilを返します。
+func parseOverlays(list string, playheadPosition float64) (*score.VideoOverlay, error) {
+	names := splitList(list)
+	if len(names) == 0 && playheadPosition == 0 {
+		return nil, nil
+	}
+	overlay := &score.VideoOverlay{PlayheadPosition: playheadPosition}
+	for _, name := range names {
+		switch name {
+		case "playhead":
+			overlay.Playhead = true
+		case "progress":
+			overlay.ProgressBar = true
+		case "time":
+			overlay.ShowTime = true
+		case "title":
+			overlay.ShowTitle = true
+		default:
+			return nil, fmt.Errorf("重ねて表示するもの%qには対応していません (playhead, progress, time, title)", name)
+		}
+	}
+	return overlay, nil
+}
+
+func runVideo(args []string, stdout, stderr io.Writer) error {
+	fs := newCLIFlagSet("video", "-bpm <BPM> [-out <出力ファイル>] <トリミング済みPDF>", stderr)
+	bpm := fs.Int("bpm", 0, "BPM（スクロール速度の基準）")
+	width := fs.Int("width", 0, "動画の幅 (既定: mp4 / webm は1920、gif / apng は640)")
+	height := fs.Int("height", 0, "動画の高さ (既定: mp4 / webm は1080、gif / apng は360)")
+	fps := fs.Int("fps", 0, "フレームレート (既定: mp4 / webm は30、gif / apng は12)")
+	format := fs.String("format", defaultVideoFormat, "出力形式 (mp4, webm, gif, apng)")
+	crf := fs.Int("crf", 0, "画質 (mp4: 0-51, webm: 0-63、小さいほど高画質)")
+	bitrate := fs.Int("bitrate", 0, "映像のビットレート (kbps、指定すると -crf の代わりに使う)")
+	out := fs.String("out", "", "出力するファイルのパス（省略時はPDFと同じ場所）")
+	tempoPath := fs.String("tempo-map", "", "テンポマップのファイル (JSON または YAML)")
+	beats := fs.String("beats", "", "セグメントごとの拍数をカンマ区切りで指定すると、拍数に合わせてスクロールします (例: 16,16,12)")
+	measures := fs.String("measures", "", "セグメントごとの小節数をカンマ区切りで指定すると、拍子に合わせてスクロールします (例: 4,4,3)")
+	timeSignature := fs.String("time-signature", "", "-measures で使う拍子 (既定: 4/4)")
+	mode := fs.String("mode", videoModeScroll, "表示方法 (scroll: 横スクロール, page: ページめくり)")
+	perPage := fs.Int("segments-per-page", 0, "-mode page で1画面に表示するセグメント数 (1 または 2)")
+	crossfade := fs.Float64("crossfade", 0, "-mode page で画面を切り替えるときのクロスフェードの秒数")
+	previewNext := fs.Bool("preview-next", false, "-mode page で画面下に次のセグメントを小さく表示する")
+	metronome := fs.Bool("metronome", false, "メトロノームのクリック音を入れる")
+	countIn := fs.Int("count-in", 0, "-metronome でスクロールの前に鳴らすカウントインの小節数")
+	audioPath := fs.String("audio", "", "動画に入れる伴奏の音声ファイル (WAV / MP3 / OGG)")
+	audioOffset := fs.Int("audio-offset", 0, "動画の先頭から伴奏を始めるまでのミリ秒（負なら伴奏の先頭を飛ばす）")
+	fitAudio := fs.Bool("duration-from-audio", false, "動画の長さを伴奏の終わりに合わせる")
+	overlays := fs.String("overlay", "", "重ねて表示するものをカンマ区切りで指定 (playhead, progress, time, title)")
+	playheadPosition := fs.Float64("playhead-position", 0, "縦線の画面上の横位置 (画面の幅に対する割合、既定: 0.25)")
+	limits := addLimitFlags(fs)
+	videoMax := addVideoLimitFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: 入力ファイルを1つ指定してください", errCLIUsage)
+	}
+
+	input := fs.Arg(0)
+	pdf, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	var tempo []*score.TempoChange
+	if *tempoPath != "" {
+		if tempo, err = loadTempoMap(*tempoPath); err != nil {
+			return err
+		}
+	}
+	segmentBeats, err := parseSegmentBeats(*beats, *measures)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCLIUsage, err)
+	}
+	if *countIn != 0 && !*metronome {
+		return fmt.Errorf("%w: -count-in は -metronome と一緒に指定してください", errCLIUsage)
+	}
+	var audio []byte
+	if *audioPath != "" {
+		if audio, err = os.ReadFile(*audioPath); err != nil {
+			return err
+		}
+	}
+	var click *score.Metronome
+	if *metronome {
+		click = &score.Metronome{CountInBars: int32(*countIn)}
+	}
+	overlay, err := parseOverlays(*overlays, *playheadPosition)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCLIUsage, err)
+	}
+	timing := videoTimingPixels
+	if len(segmentBeats) > 0 {
+		timing = videoTimingBeats
+	}
+	opts, err := videoOptionsFromRequest(&score.GenerateScrollVideoRequest{
+		Title:             strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)),
+		Bpm:               int32(*bpm),
+		VideoWidth:        int32(*width),
+		VideoHeight:       int32(*height),
+		Fps:               int32(*fps),
+		Format:            *format,
+		Crf:               int32(*crf),
+		BitrateKbps:       int32(*bitrate),
+		TempoMap:          tempo,
+		Timing:            timing,
+		SegmentBeats:      segmentBeats,
+		TimeSignature:     *timeSignature,
+		Mode:              *mode,
+		SegmentsPerPage:   int32(*perPage),
+		CrossfadeSeconds:  *crossfade,
+		PreviewNext:       *previewNext,
+		Metronome:         click,
+		AudioFile:         audio,
+		AudioOffsetMs:     int32(*audioOffset),
+		DurationFromAudio: *fitAudio,
+		Overlay:           overlay,
+	}, videoMax())
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCLIUsage, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	video, err := renderScrollVideo(ctx, pdf, opts, limits(), func(p *score.TrimScoreProgressResponse) error {
+		fmt.Fprintf(stderr, "\r[%3d%%] %s\033[K", p.GetProgress(), p.GetMessage())
+		return nil
+	})
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(video.path)
+
+	path := *out
+	if path == "" {
+		path = filepath.Join(filepath.Dir(input), video.filename)
+	}
+	if err := copyFile(video.path, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "OK %s -> %s (%d秒)\n", input, path, video.durationSeconds)
+	return nil
+}
+
+// copyFile はsrcの内容をdstに書き出します。動画のような大きなファイルもメモリに載せずに写します。
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
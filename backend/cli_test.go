@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile はdir/nameにdataを書き込み、そのパスを返します
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testTemplateYAML = `areas:
+  - {top: 0, left: 0, width: 1, height: 0.5}
+  - {top: 0.5, left: 0, width: 1, height: 0.5}
+`
+
+func TestRunCLIExitCode(t *testing.T) {
+	dir := t.TempDir()
+	pdf := writeTestFile(t, dir, "score.pdf", testPDFBytes(1, 400, 600))
+	tmpl := writeTestFile(t, dir, "template.yaml", []byte(testTemplateYAML))
+	tests := []struct {
+		name string
+		cmd  string
+		args []string
+		want int
+	}{
+		{name: "help", cmd: "help", want: 0},
+		{name: "flag help", cmd: "trim", args: []string{"-h"}, want: 0},
+		{name: "unknown command", cmd: "split", want: 2},
+		{name: "missing template", cmd: "trim", args: []string{pdf}, want: 2},
+		{name: "missing input", cmd: "trim", args: []string{"-template", tmpl}, want: 2},
+		{name: "nup without out", cmd: "nup", args: []string{pdf}, want: 2},
+		{name: "nup zero rows", cmd: "nup", args: []string{"-rows", "0", "-out", filepath.Join(dir, "nup.pdf"), pdf}, want: 2},
+		{name: "video count-in without metronome", cmd: "video", args: []string{"-bpm", "120", "-count-in", "1", pdf}, want: 2},
+		// 使い方は正しいが処理に失敗した場合は1を返す
+		{name: "template not found", cmd: "trim", args: []string{"-template", filepath.Join(dir, "none.yaml"), pdf}, want: 1},
+		{name: "input not found", cmd: "inspect", args: []string{filepath.Join(dir, "none.pdf")}, want: 1},
+		{name: "inspect", cmd: "inspect", args: []string{pdf}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runCLI(tt.cmd, tt.args); got != tt.want {
+				t.Errorf("runCLI(%q, %q) = %d, want %d", tt.cmd, tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTrimTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantAreas int
+		wantPages int
+		wantErr   bool
+	}{
+		{name: "yaml", file: "t.yaml", content: testTemplateYAML, wantAreas: 2},
+		{
+			name:      "json",
+			file:      "t.json",
+			content:   `{"areas": [{"top": 0.1, "left": 0, "width": 1, "height": 0.3, "join_with_next": true}], "pages": [{"page": 2, "areas": []}], "orientation": "landscape"}`,
+			wantAreas: 1,
+			wantPages: 1,
+		},
+		// 拡張子がJSON以外ならYAMLとして読む
+		{name: "yml", file: "t.yml", content: "areas: [{top: 0, left: 0, width: 1, height: 1}]", wantAreas: 1},
+		// 綴りの誤りに気付けるように、知らない項目は拒否する
+		{name: "unknown yaml field", file: "u.yaml", content: "areas: []\nsplitt: {}", wantErr: true},
+		{name: "unknown json field", file: "u.json", content: `{"area": []}`, wantErr: true},
+		{name: "broken json", file: "b.json", content: `{"areas": [`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := loadTrimTemplate(writeTestFile(t, dir, tt.file, []byte(tt.content)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(tmpl.Areas) != tt.wantAreas || len(tmpl.Pages) != tt.wantPages {
+				t.Errorf("areas = %d, pages = %d, want %d, %d", len(tmpl.Areas), len(tmpl.Pages), tt.wantAreas, tt.wantPages)
+			}
+		})
+	}
+
+	if _, err := loadTrimTemplate(filepath.Join(dir, "none.yaml")); err == nil {
+		t.Error("loadTrimTemplate succeeded for a missing file")
+	}
+}
+
+func TestRunTrimDirectory(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	writeTestFile(t, in, "first.pdf", testPDFBytes(1, 400, 600))
+	writeTestFile(t, in, "second.PDF", testPDFBytes(2, 400, 600))
+	// ディレクトリからは直下のPDFだけを読む
+	writeTestFile(t, in, "notes.txt", []byte("not a pdf"))
+	writeTestFile(t, in, "sub/nested.pdf", testPDFBytes(1, 400, 600))
+	tmpl := writeTestFile(t, dir, "template.yaml", []byte(testTemplateYAML))
+	out := filepath.Join(dir, "out")
+
+	var stdout, stderr bytes.Buffer
+	if err := runTrim([]string{"-template", tmpl, "-out", out, in}, &stdout, &stderr); err != nil {
+		t.Fatalf("runTrim: %v\nstderr: %s", err, stderr.String())
+	}
+	if got := strings.Count(stdout.String(), "OK "); got != 2 {
+		t.Errorf("stdout = %q, want 2 OK lines", stdout.String())
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("output has %d files, want 2", len(entries))
+	}
+	// 2つのエリアでトリミングするため、1ページから2ページできる
+	wantPages := map[string]int{"first-trimmed.pdf": 2, "second-trimmed.pdf": 4}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(out, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err := readPDFContext(data, "", pdfLimits{})
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		if want, ok := wantPages[e.Name()]; !ok || ctx.PageCount != want {
+			t.Errorf("%s: %d pages, want %d", e.Name(), ctx.PageCount, want)
+		}
+	}
+}
+
+func TestRunTrimRejectsOutputCollision(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a/x.pdf", testPDFBytes(1, 400, 600))
+	b := writeTestFile(t, dir, "b/X.pdf", testPDFBytes(1, 400, 600))
+	tmpl := writeTestFile(t, dir, "template.yaml", []byte(testTemplateYAML))
+	out := filepath.Join(dir, "out")
+
+	var stdout, stderr bytes.Buffer
+	err := runTrim([]string{"-template", tmpl, "-out", out, a, b}, &stdout, &stderr)
+	if !errors.Is(err, errCLIUsage) {
+		t.Fatalf("err = %v, want errCLIUsage", err)
+	}
+	// 一方の結果をもう一方で上書きしないように、何も書き出さずに止める
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("output directory was created: %v", err)
+	}
+}
+
+func TestTrimOutputs(t *testing.T) {
+	got, err := trimOutputs([]string{"a/x.pdf", "a/y.pdf", "b/z.pdf"}, "out", "landscape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"out/x-trimmed-landscape.pdf", "out/y-trimmed-landscape.pdf", "out/z-trimmed-landscape.pdf"}
+	for i := range want {
+		if got[i] != filepath.FromSlash(want[i]) {
+			t.Errorf("outputs[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if _, err := trimOutputs([]string{"a/x.pdf", "b/x.pdf"}, "out", ""); !errors.Is(err, errCLIUsage) {
+		t.Errorf("err = %v, want errCLIUsage", err)
+	}
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// serverConfig はサーバーの実行時設定です
+type serverConfig struct {
+	Addr            string   `yaml:"addr"`
+	TLSCertFile     string   `yaml:"tls_cert_file"`
+	TLSKeyFile      string   `yaml:"tls_key_file"`
+	UploadDir       string   `yaml:"upload_dir"`
+	MaxRequestBytes int64    `yaml:"max_request_bytes"`
+	AllowedOrigins  []string `yaml:"allowed_origins"`
+	TrimWorkers     int      `yaml:"trim_workers"`
+	VideoWorkers    int      `yaml:"video_workers"`
+	// MaxVideoStripPixels は横スクロールの動画でページを横に並べた画像の最大画素数です
+	MaxVideoStripPixels int64 `yaml:"max_video_strip_pixels"`
+	// MaxVideoSeconds は生成する動画の長さの上限（秒）です
+	MaxVideoSeconds int `yaml:"max_video_seconds"`
+
+	MaxMessageBytes   int64 `yaml:"max_message_bytes"`
+	MaxPDFPages       int   `yaml:"max_pdf_pages"`
+	MaxPDFStreamBytes int64 `yaml:"max_pdf_stream_bytes"`
+	// MaxPDFDecodedBytes は1つのPDFの全てのストリームの展開後のサイズの合計の上限です
+	MaxPDFDecodedBytes int64 `yaml:"max_pdf_decoded_bytes"`
+
+	PerClientTrimJobs     int           `yaml:"per_client_trim_jobs"`
+	PerClientVideoJobs    int           `yaml:"per_client_video_jobs"`
+	AdmissionQueueSize    int           `yaml:"admission_queue_size"`
+	AdmissionQueueTimeout time.Duration `yaml:"admission_queue_timeout"`
+	RetryAfter            time.Duration `yaml:"retry_after"`
+	TrustProxyHeaders     bool          `yaml:"trust_proxy_headers"`
+
+	Auth             authConfig `yaml:"auth"`
+	TenantQuotaBytes int64      `yaml:"tenant_quota_bytes"`
+
+	MaxUploadBytes   int64         `yaml:"max_upload_bytes"`
+	UploadChunkBytes int64         `yaml:"upload_chunk_bytes"`
+	UploadSessionTTL time.Duration `yaml:"upload_session_ttl"`
+	// MaxUploadSessions はテナントごとに同時に開いておける分割アップロードの数です
+	MaxUploadSessions int `yaml:"max_upload_sessions"`
+
+	MaxBatchItems int `yaml:"max_batch_items"`
+
+	MaxQueuedJobs          int `yaml:"max_queued_jobs"`
+	MaxQueuedJobsPerClient int `yaml:"max_queued_jobs_per_client"`
+
+	JobDBPath    string        `yaml:"job_db_path"`
+	JobResultTTL time.Duration `yaml:"job_result_ttl"`
+	// ShutdownTimeout は停止時に処理中のリクエストとジョブの終了を待つ時間です。
+	// 過ぎても終わらないジョブは中断し、次の起動時に再開します。
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Storage storageConfig `yaml:"storage"`
+
+	Rasterizer string `yaml:"rasterizer"`
+}
+
+// storageConfig は保存先の設定です。filesystemの場合はupload_dirに保存します。
+type storageConfig struct {
+	Backend string   `yaml:"backend"`
+	S3      s3Config `yaml:"s3"`
+}
+
+const envPrefix = "SCORE_SPLITTER_"
+
+func defaultConfig() *serverConfig {
+	return &serverConfig{
+		Addr:            ":8085",
+		UploadDir:       "uploads",
+		MaxRequestBytes: 64 << 20,
+		AllowedOrigins:  []string{"*"},
+		TrimWorkers:     4,
+		VideoWorkers:    1,
+
+		MaxVideoStripPixels: 256 << 20,
+		MaxVideoSeconds:     3600,
+
+		MaxMessageBytes:    64 << 20,
+		MaxPDFPages:        500,
+		MaxPDFStreamBytes:  256 << 20,
+		MaxPDFDecodedBytes: 1 << 30,
+
+		PerClientTrimJobs:     2,
+		PerClientVideoJobs:    1,
+		AdmissionQueueSize:    16,
+		AdmissionQueueTimeout: 30 * time.Second,
+		RetryAfter:            10 * time.Second,
+
+		TenantQuotaBytes: 1 << 30,
+
+		MaxUploadBytes:   512 << 20,
+		UploadChunkBytes: 4 << 20,
+		UploadSessionTTL: 24 * time.Hour,
+
+		MaxUploadSessions: 8,
+
+		MaxBatchItems: 50,
+
+		MaxQueuedJobs:          64,
+		MaxQueuedJobsPerClient: 8,
+
+		JobDBPath:    "data/jobs.db",
+		JobResultTTL: 72 * time.Hour,
+
+		ShutdownTimeout: 30 * time.Second,
+
+		Storage: storageConfig{Backend: storageBackendFilesystem},
+
+		Rasterizer: rasterizerAuto,
+	}
+}
+
+// configField はフラグと環境変数の両方から設定できる項目です
+type configField struct {
+	name  string
+	usage string
+	set   func(cfg *serverConfig, value string) error
+}
+
+var configFields = []configField{
+	{"addr", "待ち受けアドレス (例: :8085)", func(cfg *serverConfig, v string) error {
+		cfg.Addr = v
+		return nil
+	}},
+	{"tls-cert", "TLS証明書ファイル", func(cfg *serverConfig, v string) error {
+		cfg.TLSCertFile = v
+		return nil
+	}},
+	{"tls-key", "TLS秘密鍵ファイル", func(cfg *serverConfig, v string) error {
+		cfg.TLSKeyFile = v
+		return nil
+	}},
+	{"upload-dir", "アップロードしたPDFの保存先ディレクトリ", func(cfg *serverConfig, v string) error {
+		cfg.UploadDir = v
+		return nil
+	}},
+	{"max-request-bytes", "リクエストボディの最大バイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxRequestBytes = n
+		return nil
+	}},
+	{"allowed-origins", "CORSで許可するオリジン（カンマ区切り、*で全て許可）", func(cfg *serverConfig, v string) error {
+		cfg.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"trim-workers", "同時に実行するトリミング処理の数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.TrimWorkers = n
+		return nil
+	}},
+	{"video-workers", "同時に実行する動画生成処理の数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.VideoWorkers = n
+		return nil
+	}},
+	{"max-video-strip-pixels", "横スクロールの動画でページを横に並べた画像の最大画素数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxVideoStripPixels = n
+		return nil
+	}},
+	{"max-video-seconds", "生成する動画の長さの上限（秒）", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxVideoSeconds = n
+		return nil
+	}},
+	{"max-message-bytes", "展開後のRPCメッセージの最大バイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxMessageBytes = n
+		return nil
+	}},
+	{"max-pdf-pages", "処理するPDFの最大ページ数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxPDFPages = n
+		return nil
+	}},
+	{"max-pdf-stream-bytes", "PDF内の各ストリームの展開後の最大バイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxPDFStreamBytes = n
+		return nil
+	}},
+	{"max-pdf-decoded-bytes", "PDF内の全てのストリームの展開後の合計の最大バイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxPDFDecodedBytes = n
+		return nil
+	}},
+	{"per-client-trim-jobs", "クライアントごとに同時に実行できるトリミング処理の数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.PerClientTrimJobs = n
+		return nil
+	}},
+	{"per-client-video-jobs", "クライアントごとに同時に実行できる動画生成処理の数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.PerClientVideoJobs = n
+		return nil
+	}},
+	{"admission-queue-size", "処理枠が空くのを待てるリクエストの数（処理の種類ごと）", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.AdmissionQueueSize = n
+		return nil
+	}},
+	{"admission-queue-timeout", "処理枠が空くのを待つ最大時間 (例: 30s)", func(cfg *serverConfig, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.AdmissionQueueTimeout = d
+		return nil
+	}},
+	{"retry-after", "拒否したリクエストに返す再試行までの目安時間 (例: 10s)", func(cfg *serverConfig, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.RetryAfter = d
+		return nil
+	}},
+	{"trust-proxy-headers", "X-Forwarded-For / X-Real-IP を信頼してクライアントIPを判定する", func(cfg *serverConfig, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		cfg.TrustProxyHeaders = b
+		return nil
+	}},
+	{"jwt-secret", "JWTの署名検証に使うHMACの秘密鍵", func(cfg *serverConfig, v string) error {
+		cfg.Auth.JWTSecret = v
+		return nil
+	}},
+	{"jwt-issuer", "JWTの発行者(iss)として受け付ける値", func(cfg *serverConfig, v string) error {
+		cfg.Auth.JWTIssuer = v
+		return nil
+	}},
+	{"jwt-audience", "JWTの対象者(aud)として受け付ける値", func(cfg *serverConfig, v string) error {
+		cfg.Auth.JWTAudience = v
+		return nil
+	}},
+	{"tenant-quota-bytes", "テナントごとの保存容量の上限バイト数（0で無制限）", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.TenantQuotaBytes = n
+		return nil
+	}},
+	{"max-upload-bytes", "分割アップロードで受け付けるファイルの最大バイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.MaxUploadBytes = n
+		return nil
+	}},
+	{"upload-chunk-bytes", "分割アップロード・ダウンロードで推奨するチャンクのバイト数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.UploadChunkBytes = n
+		return nil
+	}},
+	{"upload-session-ttl", "分割アップロードを再開できる期間 (例: 24h)", func(cfg *serverConfig, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.UploadSessionTTL = d
+		return nil
+	}},
+	{"max-upload-sessions", "テナントごとに同時に開いておける分割アップロードの最大数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxUploadSessions = n
+		return nil
+	}},
+	{"max-batch-items", "BatchTrimScoresで一度に処理できるスコアの最大数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxBatchItems = n
+		return nil
+	}},
+	{"max-queued-jobs", "受け付ける未完了の非同期ジョブの最大数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxQueuedJobs = n
+		return nil
+	}},
+	{"max-queued-jobs-per-client", "クライアントごとの未完了の非同期ジョブの最大数", func(cfg *serverConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.MaxQueuedJobsPerClient = n
+		return nil
+	}},
+	{"job-db-path", "非同期ジョブを記録するファイルのパス", func(cfg *serverConfig, v string) error {
+		cfg.JobDBPath = v
+		return nil
+	}},
+	{"job-result-ttl", "終了したジョブと結果を保持する期間 (例: 72h)", func(cfg *serverConfig, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.JobResultTTL = d
+		return nil
+	}},
+	{"shutdown-timeout", "停止時に処理中のリクエストとジョブの終了を待つ時間 (例: 30s)", func(cfg *serverConfig, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.ShutdownTimeout = d
+		return nil
+	}},
+	{"storage-backend", "保存先 (filesystem または s3)", func(cfg *serverConfig, v string) error {
+		cfg.Storage.Backend = v
+		return nil
+	}},
+	{"s3-endpoint", "S3互換ストレージのエンドポイント (例: http://minio:9000)", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.Endpoint = v
+		return nil
+	}},
+	{"s3-region", "S3のリージョン", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.Region = v
+		return nil
+	}},
+	{"s3-bucket", "S3のバケット", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.Bucket = v
+		return nil
+	}},
+	{"s3-prefix", "S3のキーの接頭辞", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.Prefix = v
+		return nil
+	}},
+	{"s3-access-key-id", "S3のアクセスキーID（未指定時はAWS SDKの標準の認証情報）", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.AccessKeyID = v
+		return nil
+	}},
+	{"s3-secret-access-key", "S3のシークレットアクセスキー（未指定時はAWS SDKの標準の認証情報）", func(cfg *serverConfig, v string) error {
+		cfg.Storage.S3.SecretAccessKey = v
+		return nil
+	}},
+	{"rasterizer", "PDFを画像に変換する方法 (auto, pdftoppm, imagemagick, builtin)", func(cfg *serverConfig, v string) error {
+		cfg.Rasterizer = v
+		return nil
+	}},
+	{"s3-use-path-style", "パス形式のURLでS3にアクセスする（MinIOなど）", func(cfg *serverConfig, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		cfg.Storage.S3.UsePathStyle = b
+		return nil
+	}},
+}
+
+// envName はフラグ名に対応する環境変数名を返します (例: upload-dir → SCORE_SPLITTER_UPLOAD_DIR)
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// loadConfig はデフォルト値、設定ファイル、環境変数、フラグの順に設定を読み込みます。
+// 後から読み込んだものが優先されます。
+func loadConfig(args []string, getenv func(string) string) (*serverConfig, error) {
+	fs := flag.NewFlagSet("score-splitter", flag.ContinueOnError)
+	configPath := fs.String("config", getenv(envPrefix+"CONFIG"), "設定ファイル (YAML)")
+	flagValues := make(map[string]*string, len(configFields))
+	for _, field := range configFields {
+		flagValues[field.name] = fs.String(field.name, "", fmt.Sprintf("%s (環境変数 %s)", field.usage, envName(field.name)))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return nil, fmt.Errorf("設定ファイルを読み込めません: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("設定ファイル%sが不正です: %w", *configPath, err)
+		}
+	}
+
+	for _, field := range configFields {
+		value := getenv(envName(field.name))
+		if value == "" {
+			continue
+		}
+		if err := field.set(cfg, value); err != nil {
+			return nil, fmt.Errorf("環境変数%sが不正です: %w", envName(field.name), err)
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, field := range configFields {
+			if field.name != f.Name || flagErr != nil {
+				continue
+			}
+			if err := field.set(cfg, *flagValues[field.name]); err != nil {
+				flagErr = fmt.Errorf("フラグ-%sが不正です: %w", field.name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *serverConfig) validate() error {
+	if c.Addr == "" {
+		return errors.New("待ち受けアドレスが指定されていません")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS証明書と秘密鍵は両方指定してください")
+	}
+	if c.UploadDir == "" {
+		return errors.New("アップロードディレクトリが指定されていません")
+	}
+	if c.MaxRequestBytes <= 0 {
+		return fmt.Errorf("最大リクエストサイズ%dが無効です", c.MaxRequestBytes)
+	}
+	if c.MaxMessageBytes <= 0 || c.MaxMessageBytes > math.MaxInt32 {
+		return fmt.Errorf("最大メッセージサイズ%dが無効です", c.MaxMessageBytes)
+	}
+	if c.MaxPDFPages < 1 {
+		return fmt.Errorf("最大ページ数%dが無効です", c.MaxPDFPages)
+	}
+	if c.MaxPDFStreamBytes <= 0 {
+		return fmt.Errorf("ストリームの最大サイズ%dが無効です", c.MaxPDFStreamBytes)
+	}
+	if c.MaxPDFDecodedBytes < c.MaxPDFStreamBytes {
+		return fmt.Errorf("ストリームの合計の最大サイズ%dはmax_pdf_stream_bytes以上にしてください", c.MaxPDFDecodedBytes)
+	}
+	if c.TrimWorkers < 1 {
+		return fmt.Errorf("トリミングのワーカー数%dが無効です", c.TrimWorkers)
+	}
+	if c.VideoWorkers < 1 {
+		return fmt.Errorf("動画生成のワーカー数%dが無効です", c.VideoWorkers)
+	}
+	if c.MaxVideoStripPixels <= 0 {
+		return fmt.Errorf("動画の結合画像の最大画素数%dが無効です", c.MaxVideoStripPixels)
+	}
+	// メトロノームのクリック音はWAVで作るため、WAVに書ける長さまでにします
+	if c.MaxVideoSeconds < 1 || c.MaxVideoSeconds > maxClickTrackSeconds {
+		return fmt.Errorf("動画の長さの上限%d秒が無効です（1から%d秒）", c.MaxVideoSeconds, maxClickTrackSeconds)
+	}
+	if c.PerClientTrimJobs < 1 || c.PerClientVideoJobs < 1 {
+		return errors.New("クライアントごとの同時実行数は1以上にしてください")
+	}
+	if c.AdmissionQueueSize < 0 {
+		return fmt.Errorf("キューの長さ%dが無効です", c.AdmissionQueueSize)
+	}
+	if c.AdmissionQueueTimeout <= 0 {
+		return fmt.Errorf("キューの待ち時間%vが無効です", c.AdmissionQueueTimeout)
+	}
+	// Retry-After は秒単位で返すため、1秒未満では再試行の目安になりません
+	if c.RetryAfter < time.Second {
+		return fmt.Errorf("再試行までの目安時間%vが無効です（1秒以上）", c.RetryAfter)
+	}
+	if err := c.Auth.validate(); err != nil {
+		return err
+	}
+	if c.TenantQuotaBytes < 0 {
+		return fmt.Errorf("テナントの保存容量%dが無効です", c.TenantQuotaBytes)
+	}
+	if c.MaxUploadBytes <= 0 {
+		return fmt.Errorf("アップロードの最大サイズ%dが無効です", c.MaxUploadBytes)
+	}
+	if c.UploadChunkBytes <= 0 || c.UploadChunkBytes > c.MaxMessageBytes {
+		return fmt.Errorf("チャンクサイズ%dは1以上max_message_bytes以下にしてください", c.UploadChunkBytes)
+	}
+	if c.UploadSessionTTL <= 0 {
+		return fmt.Errorf("アップロードの有効期間%vが無効です", c.UploadSessionTTL)
+	}
+	if c.MaxUploadSessions < 1 {
+		return fmt.Errorf("同時に開いておける分割アップロードの数%dが無効です", c.MaxUploadSessions)
+	}
+	if c.MaxBatchItems < 1 {
+		return fmt.Errorf("一括処理の最大数%dが無効です", c.MaxBatchItems)
+	}
+	if c.MaxQueuedJobs < 1 {
+		return fmt.Errorf("ジョブの最大数%dが無効です", c.MaxQueuedJobs)
+	}
+	if c.MaxQueuedJobsPerClient < 1 {
+		return fmt.Errorf("クライアントごとのジョブの最大数%dが無効です", c.MaxQueuedJobsPerClient)
+	}
+	if c.JobDBPath == "" {
+		return errors.New("ジョブを記録するファイルのパスが指定されていません")
+	}
+	if c.JobResultTTL <= 0 {
+		return fmt.Errorf("ジョブの結果の保持期間%vが無効です", c.JobResultTTL)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("停止時の待ち時間%vが無効です", c.ShutdownTimeout)
+	}
+	switch c.Storage.Backend {
+	case storageBackendFilesystem:
+	case storageBackendS3:
+		if c.Storage.S3.Bucket == "" {
+			return errors.New("S3のバケットが指定されていません")
+		}
+	default:
+		return fmt.Errorf("保存先%sには対応していません", c.Storage.Backend)
+	}
+	if !slices.Contains(rasterizerNames(), c.Rasterizer) {
+		return fmt.Errorf("PDFを画像に変換する方法%sには対応していません（%s）", c.Rasterizer, strings.Join(rasterizerNames(), ", "))
+	}
+	return nil
+}
+
+func (c *serverConfig) tlsEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// originAllowed はCORSで許可されたオリジンかどうかを返します
+func (c *serverConfig) originAllowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfigFile はYAMLの設定ファイルを作り、そのパスを返します
+func writeConfigFile(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func mapEnv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+addr: ":9000"
+trim_workers: 2
+video_workers: 2
+retry_after: 5s
+storage:
+  backend: s3
+  s3:
+    bucket: from-file
+`)
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(t *testing.T, cfg *serverConfig)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *serverConfig) {
+				if cfg.Addr != ":8085" || cfg.TrimWorkers != 4 || cfg.Storage.Backend != storageBackendFilesystem {
+					t.Errorf("addr=%q trim=%d storage=%q, want defaults", cfg.Addr, cfg.TrimWorkers, cfg.Storage.Backend)
+				}
+			},
+		},
+		{
+			name: "file over defaults",
+			args: []string{"-config", path},
+			check: func(t *testing.T, cfg *serverConfig) {
+				if cfg.Addr != ":9000" || cfg.TrimWorkers != 2 || cfg.RetryAfter != 5*time.Second || cfg.Storage.S3.Bucket != "from-file" {
+					t.Errorf("addr=%q trim=%d retry=%v bucket=%q, want values from file", cfg.Addr, cfg.TrimWorkers, cfg.RetryAfter, cfg.Storage.S3.Bucket)
+				}
+				// 設定ファイルに無い項目はデフォルト値のまま
+				if cfg.UploadDir != "uploads" || cfg.PerClientTrimJobs != 2 {
+					t.Errorf("upload_dir=%q per_client_trim_jobs=%d, want defaults", cfg.UploadDir, cfg.PerClientTrimJobs)
+				}
+			},
+		},
+		{
+			name: "env over file",
+			env: map[string]string{
+				envPrefix + "CONFIG":            path,
+				envName("trim-workers"):         "3",
+				envName("video-workers"):        "3",
+				envName("s3-bucket"):            "from-env",
+				envName("admission-queue-size"): "",
+			},
+			check: func(t *testing.T, cfg *serverConfig) {
+				if cfg.Addr != ":9000" || cfg.TrimWorkers != 3 || cfg.VideoWorkers != 3 || cfg.Storage.S3.Bucket != "from-env" {
+					t.Errorf("addr=%q trim=%d video=%d bucket=%q", cfg.Addr, cfg.TrimWorkers, cfg.VideoWorkers, cfg.Storage.S3.Bucket)
+				}
+				// 空の環境変数は指定なしとして扱う
+				if cfg.AdmissionQueueSize != 16 {
+					t.Errorf("admission_queue_size = %d, want default", cfg.AdmissionQueueSize)
+				}
+			},
+		},
+		{
+			name: "flags over env",
+			args: []string{"-config", path, "-video-workers", "5", "-retry-after", "2s"},
+			env:  map[string]string{envName("trim-workers"): "3", envName("video-workers"): "3"},
+			check: func(t *testing.T, cfg *serverConfig) {
+				if cfg.Addr != ":9000" || cfg.TrimWorkers != 3 || cfg.VideoWorkers != 5 || cfg.RetryAfter != 2*time.Second {
+					t.Errorf("addr=%q trim=%d video=%d retry=%v", cfg.Addr, cfg.TrimWorkers, cfg.VideoWorkers, cfg.RetryAfter)
+				}
+			},
+		},
+		{
+			// フラグの-configは環境変数の設定ファイルより優先する
+			name: "config flag over env",
+			args: []string{"-config", path},
+			env:  map[string]string{envPrefix + "CONFIG": filepath.Join(t.TempDir(), "missing.yaml")},
+			check: func(t *testing.T, cfg *serverConfig) {
+				if cfg.Addr != ":9000" {
+					t.Errorf("addr = %q, want value from flag config", cfg.Addr)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(tt.args, mapEnv(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		args []string
+		env  map[string]string
+	}{
+		{name: "unknown key", yaml: "addr: \":9000\"\nmax_workers: 4\n"},
+		{name: "unknown nested key", yaml: "storage:\n  backnd: s3\n"},
+		{name: "wrong type", yaml: "trim_workers: many\n"},
+		{name: "missing file", args: []string{"-config", "/nonexistent/config.yaml"}},
+		{name: "invalid env", env: map[string]string{envName("trim-workers"): "four"}},
+		{name: "invalid flag", args: []string{"-admission-queue-timeout", "30"}},
+		{name: "invalid after merge", args: []string{"-trim-workers", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.yaml != "" {
+				args = append([]string{"-config", writeConfigFile(t, tt.yaml)}, args...)
+			}
+			if _, err := loadConfig(args, mapEnv(tt.env)); err == nil {
+				t.Error("loadConfig succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *serverConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(*serverConfig) {}},
+		{name: "longest click track", modify: func(cfg *serverConfig) { cfg.MaxVideoSeconds = maxClickTrackSeconds }},
+		{name: "longer than click track", modify: func(cfg *serverConfig) { cfg.MaxVideoSeconds = maxClickTrackSeconds + 1 }, wantErr: true},
+		{name: "zero video seconds", modify: func(cfg *serverConfig) { cfg.MaxVideoSeconds = 0 }, wantErr: true},
+		{name: "retry after one second", modify: func(cfg *serverConfig) { cfg.RetryAfter = time.Second }},
+		{name: "retry after under one second", modify: func(cfg *serverConfig) { cfg.RetryAfter = 999 * time.Millisecond }, wantErr: true},
+		{name: "zero retry after", modify: func(cfg *serverConfig) { cfg.RetryAfter = 0 }, wantErr: true},
+		{name: "zero queue size", modify: func(cfg *serverConfig) { cfg.AdmissionQueueSize = 0 }},
+		{name: "negative queue size", modify: func(cfg *serverConfig) { cfg.AdmissionQueueSize = -1 }, wantErr: true},
+		{name: "zero queue timeout", modify: func(cfg *serverConfig) { cfg.AdmissionQueueTimeout = 0 }, wantErr: true},
+		{name: "zero per client jobs", modify: func(cfg *serverConfig) { cfg.PerClientVideoJobs = 0 }, wantErr: true},
+		{name: "tls cert without key", modify: func(cfg *serverConfig) { cfg.TLSCertFile = "tls.crt" }, wantErr: true},
+		{name: "chunk larger than message", modify: func(cfg *serverConfig) { cfg.UploadChunkBytes = cfg.MaxMessageBytes + 1 }, wantErr: true},
+		{name: "s3 without bucket", modify: func(cfg *serverConfig) { cfg.Storage.Backend = storageBackendS3 }, wantErr: true},
+		{name: "unknown storage", modify: func(cfg *serverConfig) { cfg.Storage.Backend = "gcs" }, wantErr: true},
+		{name: "decoded total under stream limit", modify: func(cfg *serverConfig) { cfg.MaxPDFDecodedBytes = cfg.MaxPDFStreamBytes - 1 }, wantErr: true},
+		{name: "unknown rasterizer", modify: func(cfg *serverConfig) { cfg.Rasterizer = "ghostscript" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.modify(cfg)
+			err := cfg.validate()
+			if tt.wantErr && err == nil {
+				t.Error("validate succeeded, want error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validate: %v", err)
+			}
+		})
+	}
+}
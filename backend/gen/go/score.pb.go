@@ -77,6 +77,7 @@ type UploadScoreResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`                // 結果メッセージ
 	ScoreId       string                 `protobuf:"bytes,2,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"` // 保存したスコアのIDなど
+	Duplicate     bool                   `protobuf:"varint,3,opt,name=duplicate,proto3" json:"duplicate,omitempty"`           // 同じ内容のPDFが既にあり、そのIDを返した場合はtrue
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -125,30 +126,38 @@ func (x *UploadScoreResponse) GetScoreId() string {
 	return ""
 }
 
-type CropArea struct {
+func (x *UploadScoreResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+type ScoreInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Top           float64                `protobuf:"fixed64,1,opt,name=top,proto3" json:"top,omitempty"`       // 上端の開始位置 (0.0 - 1.0)
-	Left          float64                `protobuf:"fixed64,2,opt,name=left,proto3" json:"left,omitempty"`     // 左端の開始位置 (0.0 - 1.0)
-	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`   // 幅 (0.0 - 1.0)
-	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"` // 高さ (0.0 - 1.0)
+	ScoreId       string                 `protobuf:"bytes,1,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"`        // スコアのID
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`                           // アップロード時のタイトル
+	SizeBytes     int64                  `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // PDFのサイズ（バイト）
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // アップロード日時（UNIX秒）
+	Titles        []string               `protobuf:"bytes,5,rep,name=titles,proto3" json:"titles,omitempty"`                         // 同じ内容でアップロードされた全てのタイトル（古い順）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CropArea) Reset() {
-	*x = CropArea{}
+func (x *ScoreInfo) Reset() {
+	*x = ScoreInfo{}
 	mi := &file_score_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CropArea) String() string {
+func (x *ScoreInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CropArea) ProtoMessage() {}
+func (*ScoreInfo) ProtoMessage() {}
 
-func (x *CropArea) ProtoReflect() protoreflect.Message {
+func (x *ScoreInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -160,61 +169,66 @@ func (x *CropArea) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CropArea.ProtoReflect.Descriptor instead.
-func (*CropArea) Descriptor() ([]byte, []int) {
+// Deprecated: Use ScoreInfo.ProtoReflect.Descriptor instead.
+func (*ScoreInfo) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *CropArea) GetTop() float64 {
+func (x *ScoreInfo) GetScoreId() string {
 	if x != nil {
-		return x.Top
+		return x.ScoreId
 	}
-	return 0
+	return ""
 }
 
-func (x *CropArea) GetLeft() float64 {
+func (x *ScoreInfo) GetTitle() string {
 	if x != nil {
-		return x.Left
+		return x.Title
 	}
-	return 0
+	return ""
 }
 
-func (x *CropArea) GetWidth() float64 {
+func (x *ScoreInfo) GetSizeBytes() int64 {
 	if x != nil {
-		return x.Width
+		return x.SizeBytes
 	}
 	return 0
 }
 
-func (x *CropArea) GetHeight() float64 {
+func (x *ScoreInfo) GetCreatedAt() int64 {
 	if x != nil {
-		return x.Height
+		return x.CreatedAt
 	}
 	return 0
 }
 
-type PageTrimSetting struct {
+func (x *ScoreInfo) GetTitles() []string {
+	if x != nil {
+		return x.Titles
+	}
+	return nil
+}
+
+type ListScoresRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PageNumber    int32                  `protobuf:"varint,1,opt,name=page_number,json=pageNumber,proto3" json:"page_number,omitempty"` // 対象ページ番号 (1始まり)
-	Areas         []*CropArea            `protobuf:"bytes,2,rep,name=areas,proto3" json:"areas,omitempty"`                              // ページごとのトリミングエリア
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PageTrimSetting) Reset() {
-	*x = PageTrimSetting{}
+func (x *ListScoresRequest) Reset() {
+	*x = ListScoresRequest{}
 	mi := &file_score_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PageTrimSetting) String() string {
+func (x *ListScoresRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PageTrimSetting) ProtoMessage() {}
+func (*ListScoresRequest) ProtoMessage() {}
 
-func (x *PageTrimSetting) ProtoReflect() protoreflect.Message {
+func (x *ListScoresRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -226,52 +240,34 @@ func (x *PageTrimSetting) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PageTrimSetting.ProtoReflect.Descriptor instead.
-func (*PageTrimSetting) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListScoresRequest.ProtoReflect.Descriptor instead.
+func (*ListScoresRequest) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *PageTrimSetting) GetPageNumber() int32 {
-	if x != nil {
-		return x.PageNumber
-	}
-	return 0
-}
-
-func (x *PageTrimSetting) GetAreas() []*CropArea {
-	if x != nil {
-		return x.Areas
-	}
-	return nil
-}
-
-type TrimScoreRequest struct {
+type ListScoresResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                                           // 生成するPDFのベース名
-	PdfFile       []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"`                        // 元のPDF
-	Areas         []*CropArea            `protobuf:"bytes,3,rep,name=areas,proto3" json:"areas,omitempty"`                                           // トリミングエリア
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`                                     // PDFのパスワード（必要な場合）
-	IncludePages  []int32                `protobuf:"varint,5,rep,packed,name=include_pages,json=includePages,proto3" json:"include_pages,omitempty"` // トリミング対象に含めるページ番号（1始まり）
-	PageSettings  []*PageTrimSetting     `protobuf:"bytes,6,rep,name=page_settings,json=pageSettings,proto3" json:"page_settings,omitempty"`         // ページごとのトリミング設定
-	Orientation   string                 `protobuf:"bytes,7,opt,name=orientation,proto3" json:"orientation,omitempty"`                               // 出力向き（"portrait" or "landscape"）
+	Scores        []*ScoreInfo           `protobuf:"bytes,1,rep,name=scores,proto3" json:"scores,omitempty"`                            // 呼び出し元のテナントのスコア一覧（新しい順）
+	UsedBytes     int64                  `protobuf:"varint,2,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`    // テナントの使用量（バイト）
+	QuotaBytes    int64                  `protobuf:"varint,3,opt,name=quota_bytes,json=quotaBytes,proto3" json:"quota_bytes,omitempty"` // テナントの上限（バイト、0は無制限）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TrimScoreRequest) Reset() {
-	*x = TrimScoreRequest{}
+func (x *ListScoresResponse) Reset() {
+	*x = ListScoresResponse{}
 	mi := &file_score_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TrimScoreRequest) String() string {
+func (x *ListScoresResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TrimScoreRequest) ProtoMessage() {}
+func (*ListScoresResponse) ProtoMessage() {}
 
-func (x *TrimScoreRequest) ProtoReflect() protoreflect.Message {
+func (x *ListScoresResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -283,83 +279,53 @@ func (x *TrimScoreRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TrimScoreRequest.ProtoReflect.Descriptor instead.
-func (*TrimScoreRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListScoresResponse.ProtoReflect.Descriptor instead.
+func (*ListScoresResponse) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *TrimScoreRequest) GetTitle() string {
-	if x != nil {
-		return x.Title
-	}
-	return ""
-}
-
-func (x *TrimScoreRequest) GetPdfFile() []byte {
-	if x != nil {
-		return x.PdfFile
-	}
-	return nil
-}
-
-func (x *TrimScoreRequest) GetAreas() []*CropArea {
-	if x != nil {
-		return x.Areas
-	}
-	return nil
-}
-
-func (x *TrimScoreRequest) GetPassword() string {
-	if x != nil {
-		return x.Password
-	}
-	return ""
-}
-
-func (x *TrimScoreRequest) GetIncludePages() []int32 {
+func (x *ListScoresResponse) GetScores() []*ScoreInfo {
 	if x != nil {
-		return x.IncludePages
+		return x.Scores
 	}
 	return nil
 }
 
-func (x *TrimScoreRequest) GetPageSettings() []*PageTrimSetting {
+func (x *ListScoresResponse) GetUsedBytes() int64 {
 	if x != nil {
-		return x.PageSettings
+		return x.UsedBytes
 	}
-	return nil
+	return 0
 }
 
-func (x *TrimScoreRequest) GetOrientation() string {
+func (x *ListScoresResponse) GetQuotaBytes() int64 {
 	if x != nil {
-		return x.Orientation
+		return x.QuotaBytes
 	}
-	return ""
+	return 0
 }
 
-type TrimScoreResponse struct {
+type GetScoreRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`                         // 結果メッセージ
-	TrimmedPdf    []byte                 `protobuf:"bytes,2,opt,name=trimmed_pdf,json=trimmedPdf,proto3" json:"trimmed_pdf,omitempty"` // 生成したPDF
-	Filename      string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`                       // 推奨ファイル名
+	ScoreId       string                 `protobuf:"bytes,1,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TrimScoreResponse) Reset() {
-	*x = TrimScoreResponse{}
+func (x *GetScoreRequest) Reset() {
+	*x = GetScoreRequest{}
 	mi := &file_score_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TrimScoreResponse) String() string {
+func (x *GetScoreRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TrimScoreResponse) ProtoMessage() {}
+func (*GetScoreRequest) ProtoMessage() {}
 
-func (x *TrimScoreResponse) ProtoReflect() protoreflect.Message {
+func (x *GetScoreRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -371,57 +337,40 @@ func (x *TrimScoreResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TrimScoreResponse.ProtoReflect.Descriptor instead.
-func (*TrimScoreResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetScoreRequest.ProtoReflect.Descriptor instead.
+func (*GetScoreRequest) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *TrimScoreResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
-}
-
-func (x *TrimScoreResponse) GetTrimmedPdf() []byte {
-	if x != nil {
-		return x.TrimmedPdf
-	}
-	return nil
-}
-
-func (x *TrimScoreResponse) GetFilename() string {
+func (x *GetScoreRequest) GetScoreId() string {
 	if x != nil {
-		return x.Filename
+		return x.ScoreId
 	}
 	return ""
 }
 
-type TrimScoreProgressResponse struct {
+type GetScoreResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`                             // 処理段階 ("parsing", "processing", "generating", "complete")
-	Progress      int32                  `protobuf:"varint,2,opt,name=progress,proto3" json:"progress,omitempty"`                      // 進捗パーセンテージ (0-100)
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                         // 進捗メッセージ
-	TrimmedPdf    []byte                 `protobuf:"bytes,4,opt,name=trimmed_pdf,json=trimmedPdf,proto3" json:"trimmed_pdf,omitempty"` // 完了時のPDFデータ (stage="complete"時のみ)
-	Filename      string                 `protobuf:"bytes,5,opt,name=filename,proto3" json:"filename,omitempty"`                       // 完了時のファイル名 (stage="complete"時のみ)
+	Score         *ScoreInfo             `protobuf:"bytes,1,opt,name=score,proto3" json:"score,omitempty"`
+	PdfFile       []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"` // PDFファイル本体
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TrimScoreProgressResponse) Reset() {
-	*x = TrimScoreProgressResponse{}
+func (x *GetScoreResponse) Reset() {
+	*x = GetScoreResponse{}
 	mi := &file_score_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TrimScoreProgressResponse) String() string {
+func (x *GetScoreResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TrimScoreProgressResponse) ProtoMessage() {}
+func (*GetScoreResponse) ProtoMessage() {}
 
-func (x *TrimScoreProgressResponse) ProtoReflect() protoreflect.Message {
+func (x *GetScoreResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -433,67 +382,47 @@ func (x *TrimScoreProgressResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TrimScoreProgressResponse.ProtoReflect.Descriptor instead.
-func (*TrimScoreProgressResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetScoreResponse.ProtoReflect.Descriptor instead.
+func (*GetScoreResponse) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *TrimScoreProgressResponse) GetStage() string {
-	if x != nil {
-		return x.Stage
-	}
-	return ""
-}
-
-func (x *TrimScoreProgressResponse) GetProgress() int32 {
-	if x != nil {
-		return x.Progress
-	}
-	return 0
-}
-
-func (x *TrimScoreProgressResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
-}
-
-func (x *TrimScoreProgressResponse) GetTrimmedPdf() []byte {
+func (x *GetScoreResponse) GetScore() *ScoreInfo {
 	if x != nil {
-		return x.TrimmedPdf
+		return x.Score
 	}
 	return nil
 }
 
-func (x *TrimScoreProgressResponse) GetFilename() string {
+func (x *GetScoreResponse) GetPdfFile() []byte {
 	if x != nil {
-		return x.Filename
+		return x.PdfFile
 	}
-	return ""
+	return nil
 }
 
-type SearchYoutubeVideosRequest struct {
+type DeleteScoreRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"` // 検索キーワード
+	ScoreId       string                 `protobuf:"bytes,1,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"` // 削除するアップロードのタイトル（同じ内容を複数回アップロードした場合。省略時は最後のアップロード）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchYoutubeVideosRequest) Reset() {
-	*x = SearchYoutubeVideosRequest{}
+func (x *DeleteScoreRequest) Reset() {
+	*x = DeleteScoreRequest{}
 	mi := &file_score_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchYoutubeVideosRequest) String() string {
+func (x *DeleteScoreRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchYoutubeVideosRequest) ProtoMessage() {}
+func (*DeleteScoreRequest) ProtoMessage() {}
 
-func (x *SearchYoutubeVideosRequest) ProtoReflect() protoreflect.Message {
+func (x *DeleteScoreRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -505,41 +434,46 @@ func (x *SearchYoutubeVideosRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchYoutubeVideosRequest.ProtoReflect.Descriptor instead.
-func (*SearchYoutubeVideosRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteScoreRequest.ProtoReflect.Descriptor instead.
+func (*DeleteScoreRequest) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SearchYoutubeVideosRequest) GetQuery() string {
+func (x *DeleteScoreRequest) GetScoreId() string {
 	if x != nil {
-		return x.Query
+		return x.ScoreId
 	}
 	return ""
 }
 
-type YoutubeVideo struct {
+func (x *DeleteScoreRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type DeleteScoreResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	ThumbnailUrl  string                 `protobuf:"bytes,3,opt,name=thumbnail_url,json=thumbnailUrl,proto3" json:"thumbnail_url,omitempty"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *YoutubeVideo) Reset() {
-	*x = YoutubeVideo{}
+func (x *DeleteScoreResponse) Reset() {
+	*x = DeleteScoreResponse{}
 	mi := &file_score_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *YoutubeVideo) String() string {
+func (x *DeleteScoreResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*YoutubeVideo) ProtoMessage() {}
+func (*DeleteScoreResponse) ProtoMessage() {}
 
-func (x *YoutubeVideo) ProtoReflect() protoreflect.Message {
+func (x *DeleteScoreResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -551,53 +485,41 @@ func (x *YoutubeVideo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use YoutubeVideo.ProtoReflect.Descriptor instead.
-func (*YoutubeVideo) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteScoreResponse.ProtoReflect.Descriptor instead.
+func (*DeleteScoreResponse) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *YoutubeVideo) GetVideoId() string {
-	if x != nil {
-		return x.VideoId
-	}
-	return ""
-}
-
-func (x *YoutubeVideo) GetTitle() string {
-	if x != nil {
-		return x.Title
-	}
-	return ""
-}
-
-func (x *YoutubeVideo) GetThumbnailUrl() string {
+func (x *DeleteScoreResponse) GetMessage() string {
 	if x != nil {
-		return x.ThumbnailUrl
+		return x.Message
 	}
 	return ""
 }
 
-type SearchYoutubeVideosResponse struct {
+// トリミング設定のテンプレート。スコアと同じく呼び出し元のテナントごとに保存します。
+type SaveTemplateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Videos        []*YoutubeVideo        `protobuf:"bytes,1,rep,name=videos,proto3" json:"videos,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`         // テンプレートの名前
+	Settings      *TrimScoreRequest      `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"` // トリミング設定（title / pdf_file / password は保存しません）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchYoutubeVideosResponse) Reset() {
-	*x = SearchYoutubeVideosResponse{}
+func (x *SaveTemplateRequest) Reset() {
+	*x = SaveTemplateRequest{}
 	mi := &file_score_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchYoutubeVideosResponse) String() string {
+func (x *SaveTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchYoutubeVideosResponse) ProtoMessage() {}
+func (*SaveTemplateRequest) ProtoMessage() {}
 
-func (x *SearchYoutubeVideosResponse) ProtoReflect() protoreflect.Message {
+func (x *SaveTemplateRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -609,45 +531,48 @@ func (x *SearchYoutubeVideosResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchYoutubeVideosResponse.ProtoReflect.Descriptor instead.
-func (*SearchYoutubeVideosResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SaveTemplateRequest.ProtoReflect.Descriptor instead.
+func (*SaveTemplateRequest) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *SearchYoutubeVideosResponse) GetVideos() []*YoutubeVideo {
+func (x *SaveTemplateRequest) GetName() string {
 	if x != nil {
-		return x.Videos
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SaveTemplateRequest) GetSettings() *TrimScoreRequest {
+	if x != nil {
+		return x.Settings
 	}
 	return nil
 }
 
-type GenerateScrollVideoRequest struct {
+type TemplateInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                                 // 動画のタイトル
-	PdfFile       []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"`              // トリミング済みPDFファイル
-	Bpm           int32                  `protobuf:"varint,3,opt,name=bpm,proto3" json:"bpm,omitempty"`                                    // BPM（スクロール速度の基準）
-	VideoWidth    int32                  `protobuf:"varint,4,opt,name=video_width,json=videoWidth,proto3" json:"video_width,omitempty"`    // 動画の幅（デフォルト: 1920）
-	VideoHeight   int32                  `protobuf:"varint,5,opt,name=video_height,json=videoHeight,proto3" json:"video_height,omitempty"` // 動画の高さ（デフォルト: 1080）
-	Fps           int32                  `protobuf:"varint,6,opt,name=fps,proto3" json:"fps,omitempty"`                                    // フレームレート（デフォルト: 30）
-	Format        string                 `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`                               // 出力フォーマット（"mp4", "webm"等、デフォルト: "mp4"）
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // 保存日時（UNIX秒）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenerateScrollVideoRequest) Reset() {
-	*x = GenerateScrollVideoRequest{}
+func (x *TemplateInfo) Reset() {
+	*x = TemplateInfo{}
 	mi := &file_score_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateScrollVideoRequest) String() string {
+func (x *TemplateInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateScrollVideoRequest) ProtoMessage() {}
+func (*TemplateInfo) ProtoMessage() {}
 
-func (x *GenerateScrollVideoRequest) ProtoReflect() protoreflect.Message {
+func (x *TemplateInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_score_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -659,85 +584,2367 @@ func (x *GenerateScrollVideoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateScrollVideoRequest.ProtoReflect.Descriptor instead.
-func (*GenerateScrollVideoRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use TemplateInfo.ProtoReflect.Descriptor instead.
+func (*TemplateInfo) Descriptor() ([]byte, []int) {
 	return file_score_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GenerateScrollVideoRequest) GetTitle() string {
+func (x *TemplateInfo) GetTemplateId() string {
 	if x != nil {
-		return x.Title
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *GenerateScrollVideoRequest) GetPdfFile() []byte {
+func (x *TemplateInfo) GetName() string {
 	if x != nil {
-		return x.PdfFile
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-func (x *GenerateScrollVideoRequest) GetBpm() int32 {
+func (x *TemplateInfo) GetCreatedAt() int64 {
 	if x != nil {
-		return x.Bpm
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListTemplatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesRequest) Reset() {
+	*x = ListTemplatesRequest{}
+	mi := &file_score_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesRequest) ProtoMessage() {}
+
+func (x *ListTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{11}
+}
+
+type ListTemplatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Templates     []*TemplateInfo        `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"` // 呼び出し元のテナントのテンプレート一覧（新しい順）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesResponse) Reset() {
+	*x = ListTemplatesResponse{}
+	mi := &file_score_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesResponse) ProtoMessage() {}
+
+func (x *ListTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListTemplatesResponse) GetTemplates() []*TemplateInfo {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+type GetTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTemplateRequest) Reset() {
+	*x = GetTemplateRequest{}
+	mi := &file_score_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTemplateRequest) ProtoMessage() {}
+
+func (x *GetTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTemplateRequest.ProtoReflect.Descriptor instead.
+func (*GetTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetTemplateRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+type GetTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Template      *TemplateInfo          `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	Settings      *TrimScoreRequest      `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTemplateResponse) Reset() {
+	*x = GetTemplateResponse{}
+	mi := &file_score_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTemplateResponse) ProtoMessage() {}
+
+func (x *GetTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTemplateResponse.ProtoReflect.Descriptor instead.
+func (*GetTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetTemplateResponse) GetTemplate() *TemplateInfo {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+func (x *GetTemplateResponse) GetSettings() *TrimScoreRequest {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type DeleteTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTemplateRequest) Reset() {
+	*x = DeleteTemplateRequest{}
+	mi := &file_score_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTemplateRequest) ProtoMessage() {}
+
+func (x *DeleteTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTemplateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteTemplateRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+type DeleteTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTemplateResponse) Reset() {
+	*x = DeleteTemplateResponse{}
+	mi := &file_score_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTemplateResponse) ProtoMessage() {}
+
+func (x *DeleteTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTemplateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteTemplateResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// 分割アップロード: BeginUpload → AppendUploadChunk（繰り返し）→ CommitUpload
+// 中断した場合は GetUploadStatus で受信済みのバイト数を確認し、その位置から再開します。
+type BeginUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                           // スコアのタイトル
+	TotalSize     int64                  `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"` // PDF全体のサイズ（バイト）
+	Sha256        string                 `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`                         // PDF全体のSHA-256（16進数）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BeginUploadRequest) Reset() {
+	*x = BeginUploadRequest{}
+	mi := &file_score_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BeginUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeginUploadRequest) ProtoMessage() {}
+
+func (x *BeginUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeginUploadRequest.ProtoReflect.Descriptor instead.
+func (*BeginUploadRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BeginUploadRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BeginUploadRequest) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *BeginUploadRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type BeginUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`     // アップロードのID
+	ChunkSize     int64                  `protobuf:"varint,2,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"` // 推奨するチャンクのサイズ（バイト）
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // 有効期限（UNIX秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BeginUploadResponse) Reset() {
+	*x = BeginUploadResponse{}
+	mi := &file_score_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BeginUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BeginUploadResponse) ProtoMessage() {}
+
+func (x *BeginUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BeginUploadResponse.ProtoReflect.Descriptor instead.
+func (*BeginUploadResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BeginUploadResponse) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *BeginUploadResponse) GetChunkSize() int64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *BeginUploadResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type AppendUploadChunkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                             // チャンクの開始位置（受信済みのバイト数と一致する必要があります）
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                                  // チャンクのデータ
+	ChunkSha256   string                 `protobuf:"bytes,4,opt,name=chunk_sha256,json=chunkSha256,proto3" json:"chunk_sha256,omitempty"` // チャンクのSHA-256（16進数、省略可）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendUploadChunkRequest) Reset() {
+	*x = AppendUploadChunkRequest{}
+	mi := &file_score_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendUploadChunkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendUploadChunkRequest) ProtoMessage() {}
+
+func (x *AppendUploadChunkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendUploadChunkRequest.ProtoReflect.Descriptor instead.
+func (*AppendUploadChunkRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AppendUploadChunkRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *AppendUploadChunkRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AppendUploadChunkRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *AppendUploadChunkRequest) GetChunkSha256() string {
+	if x != nil {
+		return x.ChunkSha256
+	}
+	return ""
+}
+
+type AppendUploadChunkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReceivedBytes int64                  `protobuf:"varint,1,opt,name=received_bytes,json=receivedBytes,proto3" json:"received_bytes,omitempty"` // 受信済みのバイト数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendUploadChunkResponse) Reset() {
+	*x = AppendUploadChunkResponse{}
+	mi := &file_score_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendUploadChunkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendUploadChunkResponse) ProtoMessage() {}
+
+func (x *AppendUploadChunkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendUploadChunkResponse.ProtoReflect.Descriptor instead.
+func (*AppendUploadChunkResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AppendUploadChunkResponse) GetReceivedBytes() int64 {
+	if x != nil {
+		return x.ReceivedBytes
+	}
+	return 0
+}
+
+type GetUploadStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUploadStatusRequest) Reset() {
+	*x = GetUploadStatusRequest{}
+	mi := &file_score_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUploadStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUploadStatusRequest) ProtoMessage() {}
+
+func (x *GetUploadStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUploadStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetUploadStatusRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetUploadStatusRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type GetUploadStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	TotalSize     int64                  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	ReceivedBytes int64                  `protobuf:"varint,4,opt,name=received_bytes,json=receivedBytes,proto3" json:"received_bytes,omitempty"` // 受信済みのバイト数（次のチャンクのoffset）
+	ExpiresAt     int64                  `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`             // 有効期限（UNIX秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUploadStatusResponse) Reset() {
+	*x = GetUploadStatusResponse{}
+	mi := &file_score_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUploadStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUploadStatusResponse) ProtoMessage() {}
+
+func (x *GetUploadStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUploadStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetUploadStatusResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetUploadStatusResponse) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *GetUploadStatusResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *GetUploadStatusResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *GetUploadStatusResponse) GetReceivedBytes() int64 {
+	if x != nil {
+		return x.ReceivedBytes
+	}
+	return 0
+}
+
+func (x *GetUploadStatusResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type CommitUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitUploadRequest) Reset() {
+	*x = CommitUploadRequest{}
+	mi := &file_score_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitUploadRequest) ProtoMessage() {}
+
+func (x *CommitUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitUploadRequest.ProtoReflect.Descriptor instead.
+func (*CommitUploadRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CommitUploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type CropArea struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Top           float64                `protobuf:"fixed64,1,opt,name=top,proto3" json:"top,omitempty"`                                        // 上端の開始位置 (0.0 - 1.0)
+	Left          float64                `protobuf:"fixed64,2,opt,name=left,proto3" json:"left,omitempty"`                                      // 左端の開始位置 (0.0 - 1.0)
+	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`                                    // 幅 (0.0 - 1.0)
+	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"`                                  // 高さ (0.0 - 1.0)
+	JoinWithNext  bool                   `protobuf:"varint,5,opt,name=join_with_next,json=joinWithNext,proto3" json:"join_with_next,omitempty"` // 次のトリミングエリア（次のページの最初のエリアを含む）と縦につなげて1ページにする
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropArea) Reset() {
+	*x = CropArea{}
+	mi := &file_score_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropArea) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropArea) ProtoMessage() {}
+
+func (x *CropArea) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropArea.ProtoReflect.Descriptor instead.
+func (*CropArea) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CropArea) GetTop() float64 {
+	if x != nil {
+		return x.Top
+	}
+	return 0
+}
+
+func (x *CropArea) GetLeft() float64 {
+	if x != nil {
+		return x.Left
+	}
+	return 0
+}
+
+func (x *CropArea) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CropArea) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *CropArea) GetJoinWithNext() bool {
+	if x != nil {
+		return x.JoinWithNext
+	}
+	return false
+}
+
+type PageTrimSetting struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageNumber    int32                  `protobuf:"varint,1,opt,name=page_number,json=pageNumber,proto3" json:"page_number,omitempty"` // 対象ページ番号 (1始まり)
+	Areas         []*CropArea            `protobuf:"bytes,2,rep,name=areas,proto3" json:"areas,omitempty"`                              // ページごとのトリミングエリア
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PageTrimSetting) Reset() {
+	*x = PageTrimSetting{}
+	mi := &file_score_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PageTrimSetting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageTrimSetting) ProtoMessage() {}
+
+func (x *PageTrimSetting) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageTrimSetting.ProtoReflect.Descriptor instead.
+func (*PageTrimSetting) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *PageTrimSetting) GetPageNumber() int32 {
+	if x != nil {
+		return x.PageNumber
+	}
+	return 0
+}
+
+func (x *PageTrimSetting) GetAreas() []*CropArea {
+	if x != nil {
+		return x.Areas
+	}
+	return nil
+}
+
+type TrimScoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                                           // 生成するPDFのベース名
+	PdfFile       []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"`                        // 元のPDF
+	Areas         []*CropArea            `protobuf:"bytes,3,rep,name=areas,proto3" json:"areas,omitempty"`                                           // トリミングエリア
+	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`                                     // PDFのパスワード（必要な場合）
+	IncludePages  []int32                `protobuf:"varint,5,rep,packed,name=include_pages,json=includePages,proto3" json:"include_pages,omitempty"` // トリミング対象に含めるページ番号（1始まり）
+	PageSettings  []*PageTrimSetting     `protobuf:"bytes,6,rep,name=page_settings,json=pageSettings,proto3" json:"page_settings,omitempty"`         // ページごとのトリミング設定
+	Orientation   string                 `protobuf:"bytes,7,opt,name=orientation,proto3" json:"orientation,omitempty"`                               // 出力向き（"portrait" or "landscape"）
+	Split         *SegmentSplit          `protobuf:"bytes,8,opt,name=split,proto3" json:"split,omitempty"`                                           // 縦に長いトリミング範囲を複数ページに分割する設定
+	Strip         *StripLayout           `protobuf:"bytes,9,opt,name=strip,proto3" json:"strip,omitempty"`                                           // 指定すると全てのトリミング範囲を横一列につなげて出力する
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrimScoreRequest) Reset() {
+	*x = TrimScoreRequest{}
+	mi := &file_score_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrimScoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrimScoreRequest) ProtoMessage() {}
+
+func (x *TrimScoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrimScoreRequest.ProtoReflect.Descriptor instead.
+func (*TrimScoreRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TrimScoreRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *TrimScoreRequest) GetPdfFile() []byte {
+	if x != nil {
+		return x.PdfFile
+	}
+	return nil
+}
+
+func (x *TrimScoreRequest) GetAreas() []*CropArea {
+	if x != nil {
+		return x.Areas
+	}
+	return nil
+}
+
+func (x *TrimScoreRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *TrimScoreRequest) GetIncludePages() []int32 {
+	if x != nil {
+		return x.IncludePages
+	}
+	return nil
+}
+
+func (x *TrimScoreRequest) GetPageSettings() []*PageTrimSetting {
+	if x != nil {
+		return x.PageSettings
+	}
+	return nil
+}
+
+func (x *TrimScoreRequest) GetOrientation() string {
+	if x != nil {
+		return x.Orientation
+	}
+	return ""
+}
+
+func (x *TrimScoreRequest) GetSplit() *SegmentSplit {
+	if x != nil {
+		return x.Split
+	}
+	return nil
+}
+
+func (x *TrimScoreRequest) GetStrip() *StripLayout {
+	if x != nil {
+		return x.Strip
+	}
+	return nil
+}
+
+// StripLayout はスクロールして読むための横長の出力設定です。
+// 各範囲を同じ高さにそろえて左から順に並べます。landscape とは同時に指定できません。
+type StripLayout struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        float64                `protobuf:"fixed64,1,opt,name=height,proto3" json:"height,omitempty"`                                   // そろえる高さ（ポイント）。0なら最も高い範囲に合わせる
+	MaxPageWidth  float64                `protobuf:"fixed64,2,opt,name=max_page_width,json=maxPageWidth,proto3" json:"max_page_width,omitempty"` // 1ページの幅の上限（ポイント）。超える場合は次のページに続ける。0ならPDFの上限（14400）
+	Gap           float64                `protobuf:"fixed64,3,opt,name=gap,proto3" json:"gap,omitempty"`                                         // 範囲の間の余白（ポイント）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StripLayout) Reset() {
+	*x = StripLayout{}
+	mi := &file_score_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StripLayout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StripLayout) ProtoMessage() {}
+
+func (x *StripLayout) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StripLayout.ProtoReflect.Descriptor instead.
+func (*StripLayout) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StripLayout) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *StripLayout) GetMaxPageWidth() float64 {
+	if x != nil {
+		return x.MaxPageWidth
+	}
+	return 0
+}
+
+func (x *StripLayout) GetGap() float64 {
+	if x != nil {
+		return x.Gap
+	}
+	return 0
+}
+
+// SegmentSplit はトリミング範囲が長すぎる場合の分割方法です。
+// max_aspect_ratio と max_height のどちらも0なら分割しません。join_with_next でつなげた範囲は、つなげた後の1ページを分割します。
+type SegmentSplit struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MaxAspectRatio float64                `protobuf:"fixed64,1,opt,name=max_aspect_ratio,json=maxAspectRatio,proto3" json:"max_aspect_ratio,omitempty"` // 1ページの高さ/幅の上限（例: 1.414 でA4縦の比率）
+	MaxHeight      float64                `protobuf:"fixed64,2,opt,name=max_height,json=maxHeight,proto3" json:"max_height,omitempty"`                  // 1ページの高さの上限（ポイント）
+	Mode           string                 `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`                                               // "whitespace"（既定: 五線の間の余白で分割）または "overlap"（固定位置で分割）
+	Overlap        *float64               `protobuf:"fixed64,4,opt,name=overlap,proto3,oneof" json:"overlap,omitempty"`                                 // 固定位置で分割する場合の重なり（1ページの高さに対する割合 0.0 - 0.5、既定 0.05）
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SegmentSplit) Reset() {
+	*x = SegmentSplit{}
+	mi := &file_score_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentSplit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentSplit) ProtoMessage() {}
+
+func (x *SegmentSplit) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentSplit.ProtoReflect.Descriptor instead.
+func (*SegmentSplit) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SegmentSplit) GetMaxAspectRatio() float64 {
+	if x != nil {
+		return x.MaxAspectRatio
+	}
+	return 0
+}
+
+func (x *SegmentSplit) GetMaxHeight() float64 {
+	if x != nil {
+		return x.MaxHeight
+	}
+	return 0
+}
+
+func (x *SegmentSplit) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *SegmentSplit) GetOverlap() float64 {
+	if x != nil && x.Overlap != nil {
+		return *x.Overlap
+	}
+	return 0
+}
+
+type TrimScoreResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`                         // 結果メッセージ
+	TrimmedPdf    []byte                 `protobuf:"bytes,2,opt,name=trimmed_pdf,json=trimmedPdf,proto3" json:"trimmed_pdf,omitempty"` // 生成したPDF
+	Filename      string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`                       // 推奨ファイル名
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrimScoreResponse) Reset() {
+	*x = TrimScoreResponse{}
+	mi := &file_score_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrimScoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrimScoreResponse) ProtoMessage() {}
+
+func (x *TrimScoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrimScoreResponse.ProtoReflect.Descriptor instead.
+func (*TrimScoreResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TrimScoreResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TrimScoreResponse) GetTrimmedPdf() []byte {
+	if x != nil {
+		return x.TrimmedPdf
+	}
+	return nil
+}
+
+func (x *TrimScoreResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+type TrimScoreProgressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`                             // 処理段階 ("parsing", "processing", "generating", "complete")
+	Progress      int32                  `protobuf:"varint,2,opt,name=progress,proto3" json:"progress,omitempty"`                      // 進捗パーセンテージ (0-100)
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                         // 進捗メッセージ
+	TrimmedPdf    []byte                 `protobuf:"bytes,4,opt,name=trimmed_pdf,json=trimmedPdf,proto3" json:"trimmed_pdf,omitempty"` // 完了時のPDFデータ (stage="complete"時のみ)
+	Filename      string                 `protobuf:"bytes,5,opt,name=filename,proto3" json:"filename,omitempty"`                       // 完了時のファイル名 (stage="complete"時のみ)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrimScoreProgressResponse) Reset() {
+	*x = TrimScoreProgressResponse{}
+	mi := &file_score_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrimScoreProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrimScoreProgressResponse) ProtoMessage() {}
+
+func (x *TrimScoreProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrimScoreProgressResponse.ProtoReflect.Descriptor instead.
+func (*TrimScoreProgressResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TrimScoreProgressResponse) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *TrimScoreProgressResponse) GetProgress() int32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *TrimScoreProgressResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TrimScoreProgressResponse) GetTrimmedPdf() []byte {
+	if x != nil {
+		return x.TrimmedPdf
+	}
+	return nil
+}
+
+func (x *TrimScoreProgressResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+type SearchYoutubeVideosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"` // 検索キーワード
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchYoutubeVideosRequest) Reset() {
+	*x = SearchYoutubeVideosRequest{}
+	mi := &file_score_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchYoutubeVideosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchYoutubeVideosRequest) ProtoMessage() {}
+
+func (x *SearchYoutubeVideosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchYoutubeVideosRequest.ProtoReflect.Descriptor instead.
+func (*SearchYoutubeVideosRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *SearchYoutubeVideosRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type YoutubeVideo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	ThumbnailUrl  string                 `protobuf:"bytes,3,opt,name=thumbnail_url,json=thumbnailUrl,proto3" json:"thumbnail_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *YoutubeVideo) Reset() {
+	*x = YoutubeVideo{}
+	mi := &file_score_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *YoutubeVideo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*YoutubeVideo) ProtoMessage() {}
+
+func (x *YoutubeVideo) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use YoutubeVideo.ProtoReflect.Descriptor instead.
+func (*YoutubeVideo) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *YoutubeVideo) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *YoutubeVideo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *YoutubeVideo) GetThumbnailUrl() string {
+	if x != nil {
+		return x.ThumbnailUrl
+	}
+	return ""
+}
+
+type SearchYoutubeVideosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Videos        []*YoutubeVideo        `protobuf:"bytes,1,rep,name=videos,proto3" json:"videos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchYoutubeVideosResponse) Reset() {
+	*x = SearchYoutubeVideosResponse{}
+	mi := &file_score_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchYoutubeVideosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchYoutubeVideosResponse) ProtoMessage() {}
+
+func (x *SearchYoutubeVideosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchYoutubeVideosResponse.ProtoReflect.Descriptor instead.
+func (*SearchYoutubeVideosResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SearchYoutubeVideosResponse) GetVideos() []*YoutubeVideo {
+	if x != nil {
+		return x.Videos
+	}
+	return nil
+}
+
+type GenerateScrollVideoRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Title             string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                                                      // 動画のタイトル
+	PdfFile           []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"`                                   // トリミング済みPDFファイル
+	Bpm               int32                  `protobuf:"varint,3,opt,name=bpm,proto3" json:"bpm,omitempty"`                                                         // BPM（スクロール速度の基準）
+	VideoWidth        int32                  `protobuf:"varint,4,opt,name=video_width,json=videoWidth,proto3" json:"video_width,omitempty"`                         // 動画の幅（デフォルト: 1920）
+	VideoHeight       int32                  `protobuf:"varint,5,opt,name=video_height,json=videoHeight,proto3" json:"video_height,omitempty"`                      // 動画の高さ（デフォルト: 1080）
+	Fps               int32                  `protobuf:"varint,6,opt,name=fps,proto3" json:"fps,omitempty"`                                                         // フレームレート（デフォルト: 30）
+	Format            string                 `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`                                                    // 出力フォーマット（"mp4": H.264、"webm": VP9、"gif" / "apng": プレビュー用のアニメーション画像、デフォルト: "mp4"）
+	TempoMap          []*TempoChange         `protobuf:"bytes,8,rep,name=tempo_map,json=tempoMap,proto3" json:"tempo_map,omitempty"`                                // テンポの変化（指定した位置からスクロール速度を変える）
+	Timing            string                 `protobuf:"bytes,9,opt,name=timing,proto3" json:"timing,omitempty"`                                                    // スクロールの速さの決め方（"pixels": (BPM÷60)×120 ピクセル/秒（デフォルト）、"beats": セグメントごとの拍数）
+	SegmentBeats      []*SegmentBeats        `protobuf:"bytes,10,rep,name=segment_beats,json=segmentBeats,proto3" json:"segment_beats,omitempty"`                   // timing が "beats" のときのセグメントごとの拍数（セグメントの順）
+	TimeSignature     string                 `protobuf:"bytes,11,opt,name=time_signature,json=timeSignature,proto3" json:"time_signature,omitempty"`                // 小節数で指定するときとメトロノームの拍子（デフォルト: "4/4"）
+	Mode              string                 `protobuf:"bytes,12,opt,name=mode,proto3" json:"mode,omitempty"`                                                       // 表示方法（"scroll": 横スクロール（デフォルト）、"page": ページめくり）
+	SegmentsPerPage   int32                  `protobuf:"varint,13,opt,name=segments_per_page,json=segmentsPerPage,proto3" json:"segments_per_page,omitempty"`       // mode が "page" のときに1画面に表示するセグメント数（1 または 2、デフォルト: 1）
+	CrossfadeSeconds  float64                `protobuf:"fixed64,14,opt,name=crossfade_seconds,json=crossfadeSeconds,proto3" json:"crossfade_seconds,omitempty"`     // mode が "page" のときのクロスフェードの秒数（0なら切り替え）
+	PreviewNext       bool                   `protobuf:"varint,15,opt,name=preview_next,json=previewNext,proto3" json:"preview_next,omitempty"`                     // mode が "page" のときに画面下に次のセグメントを小さく表示する
+	Metronome         *Metronome             `protobuf:"bytes,16,opt,name=metronome,proto3" json:"metronome,omitempty"`                                             // 指定するとメトロノームのクリック音を動画に入れる
+	AudioFile         []byte                 `protobuf:"bytes,17,opt,name=audio_file,json=audioFile,proto3" json:"audio_file,omitempty"`                            // 伴奏などの音声ファイル（WAV / MP3 / OGG）
+	AudioOffsetMs     int32                  `protobuf:"varint,18,opt,name=audio_offset_ms,json=audioOffsetMs,proto3" json:"audio_offset_ms,omitempty"`             // 動画の先頭から音声を始めるまでのミリ秒（負なら音声の先頭を飛ばす。カウントインも動画に含む）
+	DurationFromAudio bool                   `protobuf:"varint,19,opt,name=duration_from_audio,json=durationFromAudio,proto3" json:"duration_from_audio,omitempty"` // 動画の長さを音声の終わりに合わせる
+	Overlay           *VideoOverlay          `protobuf:"bytes,20,opt,name=overlay,proto3" json:"overlay,omitempty"`                                                 // 動画に重ねて表示するもの
+	Crf               int32                  `protobuf:"varint,21,opt,name=crf,proto3" json:"crf,omitempty"`                                                        // 画質（mp4: 0 - 51、webm: 0 - 63、小さいほど高画質。0なら形式ごとのデフォルト）
+	BitrateKbps       int32                  `protobuf:"varint,22,opt,name=bitrate_kbps,json=bitrateKbps,proto3" json:"bitrate_kbps,omitempty"`                     // 映像のビットレート（kbps）。指定すると crf の代わりにビットレートで画質を決める（mp4 / webm のみ）
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GenerateScrollVideoRequest) Reset() {
+	*x = GenerateScrollVideoRequest{}
+	mi := &file_score_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateScrollVideoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateScrollVideoRequest) ProtoMessage() {}
+
+func (x *GenerateScrollVideoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateScrollVideoRequest.ProtoReflect.Descriptor instead.
+func (*GenerateScrollVideoRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GenerateScrollVideoRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoRequest) GetPdfFile() []byte {
+	if x != nil {
+		return x.PdfFile
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetBpm() int32 {
+	if x != nil {
+		return x.Bpm
 	}
 	return 0
 }
 
 func (x *GenerateScrollVideoRequest) GetVideoWidth() int32 {
 	if x != nil {
-		return x.VideoWidth
+		return x.VideoWidth
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetVideoHeight() int32 {
+	if x != nil {
+		return x.VideoHeight
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetFps() int32 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoRequest) GetTempoMap() []*TempoChange {
+	if x != nil {
+		return x.TempoMap
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetTiming() string {
+	if x != nil {
+		return x.Timing
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoRequest) GetSegmentBeats() []*SegmentBeats {
+	if x != nil {
+		return x.SegmentBeats
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetTimeSignature() string {
+	if x != nil {
+		return x.TimeSignature
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoRequest) GetSegmentsPerPage() int32 {
+	if x != nil {
+		return x.SegmentsPerPage
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetCrossfadeSeconds() float64 {
+	if x != nil {
+		return x.CrossfadeSeconds
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetPreviewNext() bool {
+	if x != nil {
+		return x.PreviewNext
+	}
+	return false
+}
+
+func (x *GenerateScrollVideoRequest) GetMetronome() *Metronome {
+	if x != nil {
+		return x.Metronome
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetAudioFile() []byte {
+	if x != nil {
+		return x.AudioFile
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetAudioOffsetMs() int32 {
+	if x != nil {
+		return x.AudioOffsetMs
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetDurationFromAudio() bool {
+	if x != nil {
+		return x.DurationFromAudio
+	}
+	return false
+}
+
+func (x *GenerateScrollVideoRequest) GetOverlay() *VideoOverlay {
+	if x != nil {
+		return x.Overlay
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoRequest) GetCrf() int32 {
+	if x != nil {
+		return x.Crf
+	}
+	return 0
+}
+
+func (x *GenerateScrollVideoRequest) GetBitrateKbps() int32 {
+	if x != nil {
+		return x.BitrateKbps
+	}
+	return 0
+}
+
+// VideoOverlay は動画に重ねて表示するものの設定です
+type VideoOverlay struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Playhead         bool                   `protobuf:"varint,1,opt,name=playhead,proto3" json:"playhead,omitempty"`                                          // 演奏中の位置を示す縦線を表示する（mode が "scroll" のときのみ）
+	PlayheadPosition float64                `protobuf:"fixed64,2,opt,name=playhead_position,json=playheadPosition,proto3" json:"playhead_position,omitempty"` // 縦線の画面上の横位置（画面の幅に対する割合 0.05 - 0.95、0ならデフォルト: 0.25）
+	ProgressBar      bool                   `protobuf:"varint,3,opt,name=progress_bar,json=progressBar,proto3" json:"progress_bar,omitempty"`                 // 画面下に動画の進み具合のバーを表示する
+	ShowTime         bool                   `protobuf:"varint,4,opt,name=show_time,json=showTime,proto3" json:"show_time,omitempty"`                          // 経過時間と動画の長さを表示する
+	ShowTitle        bool                   `protobuf:"varint,5,opt,name=show_title,json=showTitle,proto3" json:"show_title,omitempty"`                       // タイトルを表示する
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *VideoOverlay) Reset() {
+	*x = VideoOverlay{}
+	mi := &file_score_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoOverlay) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoOverlay) ProtoMessage() {}
+
+func (x *VideoOverlay) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoOverlay.ProtoReflect.Descriptor instead.
+func (*VideoOverlay) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *VideoOverlay) GetPlayhead() bool {
+	if x != nil {
+		return x.Playhead
+	}
+	return false
+}
+
+func (x *VideoOverlay) GetPlayheadPosition() float64 {
+	if x != nil {
+		return x.PlayheadPosition
+	}
+	return 0
+}
+
+func (x *VideoOverlay) GetProgressBar() bool {
+	if x != nil {
+		return x.ProgressBar
+	}
+	return false
+}
+
+func (x *VideoOverlay) GetShowTime() bool {
+	if x != nil {
+		return x.ShowTime
+	}
+	return false
+}
+
+func (x *VideoOverlay) GetShowTitle() bool {
+	if x != nil {
+		return x.ShowTitle
+	}
+	return false
+}
+
+// Metronome は動画に入れるクリック音の設定です。
+// 拍はスクロールのタイミングに合わせ、小節の頭（拍子は time_signature、timing が "beats" のときはセグメントごとの拍子）を強く鳴らします。
+type Metronome struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CountInBars   int32                  `protobuf:"varint,1,opt,name=count_in_bars,json=countInBars,proto3" json:"count_in_bars,omitempty"` // スクロールを始める前に鳴らす小節数（0なら鳴らさない）
+	Volume        float64                `protobuf:"fixed64,2,opt,name=volume,proto3" json:"volume,omitempty"`                               // 音量（0.0 - 1.0、0ならデフォルト: 1.0）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metronome) Reset() {
+	*x = Metronome{}
+	mi := &file_score_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metronome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metronome) ProtoMessage() {}
+
+func (x *Metronome) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metronome.ProtoReflect.Descriptor instead.
+func (*Metronome) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Metronome) GetCountInBars() int32 {
+	if x != nil {
+		return x.CountInBars
+	}
+	return 0
+}
+
+func (x *Metronome) GetVolume() float64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+// SegmentBeats はセグメント（トリミング済みPDFの1ページ）の長さを拍で表します。
+// BPMは拍子の分母の音符を1拍として数えます（6/8 なら8分音符）。
+type SegmentBeats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Beats         float64                `protobuf:"fixed64,1,opt,name=beats,proto3" json:"beats,omitempty"`                                    // 拍数
+	Measures      int32                  `protobuf:"varint,2,opt,name=measures,proto3" json:"measures,omitempty"`                               // 小節数（beats が0のとき、拍子から拍数を計算する）
+	TimeSignature string                 `protobuf:"bytes,3,opt,name=time_signature,json=timeSignature,proto3" json:"time_signature,omitempty"` // このセグメントの拍子（省略時はリクエストの time_signature）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentBeats) Reset() {
+	*x = SegmentBeats{}
+	mi := &file_score_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentBeats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentBeats) ProtoMessage() {}
+
+func (x *SegmentBeats) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentBeats.ProtoReflect.Descriptor instead.
+func (*SegmentBeats) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SegmentBeats) GetBeats() float64 {
+	if x != nil {
+		return x.Beats
+	}
+	return 0
+}
+
+func (x *SegmentBeats) GetMeasures() int32 {
+	if x != nil {
+		return x.Measures
+	}
+	return 0
+}
+
+func (x *SegmentBeats) GetTimeSignature() string {
+	if x != nil {
+		return x.TimeSignature
+	}
+	return ""
+}
+
+// TempoChange は楽譜上の位置でのテンポの変化です。
+// 位置はトリミング済みPDFのページ（セグメント）と、その中の横方向の割合で指定します。
+type TempoChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SegmentIndex  int32                  `protobuf:"varint,1,opt,name=segment_index,json=segmentIndex,proto3" json:"segment_index,omitempty"` // セグメントの番号（0始まり）
+	Position      float64                `protobuf:"fixed64,2,opt,name=position,proto3" json:"position,omitempty"`                            // セグメント内の位置（0.0 - 1.0、小節の位置など）
+	Bpm           int32                  `protobuf:"varint,3,opt,name=bpm,proto3" json:"bpm,omitempty"`                                       // この位置からのBPM（0なら直前のBPMのまま）
+	HoldSeconds   float64                `protobuf:"fixed64,4,opt,name=hold_seconds,json=holdSeconds,proto3" json:"hold_seconds,omitempty"`   // この位置でスクロールを止める秒数（フェルマータなど）
+	Ramp          bool                   `protobuf:"varint,5,opt,name=ramp,proto3" json:"ramp,omitempty"`                                     // 次の変化の位置まで徐々にBPMを変える（リタルダンド・アッチェレランド）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TempoChange) Reset() {
+	*x = TempoChange{}
+	mi := &file_score_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TempoChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TempoChange) ProtoMessage() {}
+
+func (x *TempoChange) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TempoChange.ProtoReflect.Descriptor instead.
+func (*TempoChange) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *TempoChange) GetSegmentIndex() int32 {
+	if x != nil {
+		return x.SegmentIndex
+	}
+	return 0
+}
+
+func (x *TempoChange) GetPosition() float64 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *TempoChange) GetBpm() int32 {
+	if x != nil {
+		return x.Bpm
+	}
+	return 0
+}
+
+func (x *TempoChange) GetHoldSeconds() float64 {
+	if x != nil {
+		return x.HoldSeconds
+	}
+	return 0
+}
+
+func (x *TempoChange) GetRamp() bool {
+	if x != nil {
+		return x.Ramp
+	}
+	return false
+}
+
+type GenerateScrollVideoResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Message         string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`                                         // 結果メッセージ
+	VideoData       []byte                 `protobuf:"bytes,2,opt,name=video_data,json=videoData,proto3" json:"video_data,omitempty"`                    // 生成された動画データ（大きな動画は SubmitVideoJob と DownloadJobResult で受け取ってください）
+	Filename        string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`                                       // 推奨ファイル名
+	DurationSeconds int32                  `protobuf:"varint,4,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"` // 動画の長さ（秒）
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GenerateScrollVideoResponse) Reset() {
+	*x = GenerateScrollVideoResponse{}
+	mi := &file_score_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateScrollVideoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateScrollVideoResponse) ProtoMessage() {}
+
+func (x *GenerateScrollVideoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateScrollVideoResponse.ProtoReflect.Descriptor instead.
+func (*GenerateScrollVideoResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *GenerateScrollVideoResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoResponse) GetVideoData() []byte {
+	if x != nil {
+		return x.VideoData
+	}
+	return nil
+}
+
+func (x *GenerateScrollVideoResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *GenerateScrollVideoResponse) GetDurationSeconds() int32 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+// 非同期ジョブ: SubmitTrimJob / SubmitVideoJob でジョブIDを受け取り、
+// GetJob または WatchJob で状態を確認して、完了後に GetJobResult で結果を取得します。
+type SubmitJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Status        *JobStatus             `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobResponse) Reset() {
+	*x = SubmitJobResponse{}
+	mi := &file_score_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobResponse) ProtoMessage() {}
+
+func (x *SubmitJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobResponse.ProtoReflect.Descriptor instead.
+func (*SubmitJobResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *SubmitJobResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *SubmitJobResponse) GetStatus() *JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type JobStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`        // 処理段階（TrimScoreProgressResponse と同じ。待機中は "queued"）
+	Progress      int32                  `protobuf:"varint,2,opt,name=progress,proto3" json:"progress,omitempty"` // 進捗パーセンテージ (0-100)
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`    // 進捗メッセージ
+	JobId         string                 `protobuf:"bytes,4,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Kind          string                 `protobuf:"bytes,5,opt,name=kind,proto3" json:"kind,omitempty"`                              // ジョブの種類 ("trim", "video")
+	State         string                 `protobuf:"bytes,6,opt,name=state,proto3" json:"state,omitempty"`                            // 状態 ("queued", "running", "succeeded", "failed")
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`                            // 失敗時のエラーメッセージ
+	CreatedAt     int64                  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`  // 受付日時（UNIX秒）
+	UpdatedAt     int64                  `protobuf:"varint,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`  // 最終更新日時（UNIX秒）
+	Filename      string                 `protobuf:"bytes,10,opt,name=filename,proto3" json:"filename,omitempty"`                     // 完了時の推奨ファイル名
+	ExpiresAt     int64                  `protobuf:"varint,11,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // 終了したジョブと結果が削除される日時（UNIX秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobStatus) Reset() {
+	*x = JobStatus{}
+	mi := &file_score_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatus) ProtoMessage() {}
+
+func (x *JobStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatus.ProtoReflect.Descriptor instead.
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *JobStatus) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *JobStatus) GetProgress() int32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *JobStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *JobStatus) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobStatus) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *JobStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *JobStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *JobStatus) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *JobStatus) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *JobStatus) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *JobStatus) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_score_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *GetJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type GetJobResultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`                          // 推奨ファイル名
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // 結果のMIMEタイプ
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`                                  // 結果のPDFまたは動画
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobResultResponse) Reset() {
+	*x = GetJobResultResponse{}
+	mi := &file_score_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobResultResponse) ProtoMessage() {}
+
+func (x *GetJobResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobResultResponse.ProtoReflect.Descriptor instead.
+func (*GetJobResultResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *GetJobResultResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *GetJobResultResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *GetJobResultResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GetJobResultResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// 結果の分割ダウンロード: 1つのメッセージに収まらない大きな動画などは DownloadJobResult でチャンクに分けて受け取ります。
+// 中断した場合は受信済みのバイト数を offset に指定して再開します。
+type DownloadJobResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                        // 開始位置（受信済みのバイト数）
+	ChunkSize     int64                  `protobuf:"varint,3,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"` // チャンクのサイズ（バイト、0ならサーバーの推奨値。上限はupload_chunk_bytes）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadJobResultRequest) Reset() {
+	*x = DownloadJobResultRequest{}
+	mi := &file_score_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadJobResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadJobResultRequest) ProtoMessage() {}
+
+func (x *DownloadJobResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadJobResultRequest.ProtoReflect.Descriptor instead.
+func (*DownloadJobResultRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DownloadJobResultRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DownloadJobResultRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
 	}
 	return 0
 }
 
-func (x *GenerateScrollVideoRequest) GetVideoHeight() int32 {
+func (x *DownloadJobResultRequest) GetChunkSize() int64 {
 	if x != nil {
-		return x.VideoHeight
+		return x.ChunkSize
 	}
 	return 0
 }
 
-func (x *GenerateScrollVideoRequest) GetFps() int32 {
+type JobResultChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`                          // 推奨ファイル名（最初のチャンクのみ）
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // 結果のMIMEタイプ（最初のチャンクのみ）
+	TotalSize     int64                  `protobuf:"varint,4,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`      // 結果全体のサイズ（最初のチャンクのみ）
+	Sha256        string                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"`                              // 結果全体のSHA-256（16進数、最初のチャンクのみ）
+	Offset        int64                  `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`                             // このチャンクの開始位置
+	Data          []byte                 `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"`                                  // チャンクのデータ
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobResultChunk) Reset() {
+	*x = JobResultChunk{}
+	mi := &file_score_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobResultChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobResultChunk) ProtoMessage() {}
+
+func (x *JobResultChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[45]
 	if x != nil {
-		return x.Fps
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobResultChunk.ProtoReflect.Descriptor instead.
+func (*JobResultChunk) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *JobResultChunk) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobResultChunk) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *JobResultChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *JobResultChunk) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
 	}
 	return 0
 }
 
-func (x *GenerateScrollVideoRequest) GetFormat() string {
+func (x *JobResultChunk) GetSha256() string {
 	if x != nil {
-		return x.Format
+		return x.Sha256
 	}
 	return ""
 }
 
-type GenerateScrollVideoResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Message         string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`                                         // 結果メッセージ
-	VideoData       []byte                 `protobuf:"bytes,2,opt,name=video_data,json=videoData,proto3" json:"video_data,omitempty"`                    // 生成された動画データ
-	Filename        string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`                                       // 推奨ファイル名
-	DurationSeconds int32                  `protobuf:"varint,4,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"` // 動画の長さ（秒）
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *JobResultChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
 }
 
-func (x *GenerateScrollVideoResponse) Reset() {
-	*x = GenerateScrollVideoResponse{}
-	mi := &file_score_proto_msgTypes[11]
+func (x *JobResultChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// 複数のスコアを一括でトリミングします。項目ごとの失敗はバッチ全体を中断せず、その項目のエラーとして返します。
+type BatchTrimItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`                    // 生成するPDFのベース名（score_id指定時は省略すると保存時のタイトル）
+	PdfFile       []byte                 `protobuf:"bytes,2,opt,name=pdf_file,json=pdfFile,proto3" json:"pdf_file,omitempty"` // 元のPDF
+	ScoreId       string                 `protobuf:"bytes,3,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"` // 保存済みスコアのID（pdf_fileの代わりに指定）
+	Settings      *TrimScoreRequest      `protobuf:"bytes,4,opt,name=settings,proto3" json:"settings,omitempty"`              // この項目のトリミング設定（省略した項目はtemplateを使用。title と pdf_file は無視）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTrimItem) Reset() {
+	*x = BatchTrimItem{}
+	mi := &file_score_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateScrollVideoResponse) String() string {
+func (x *BatchTrimItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateScrollVideoResponse) ProtoMessage() {}
+func (*BatchTrimItem) ProtoMessage() {}
 
-func (x *GenerateScrollVideoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_score_proto_msgTypes[11]
+func (x *BatchTrimItem) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -748,35 +2955,251 @@ func (x *GenerateScrollVideoResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateScrollVideoResponse.ProtoReflect.Descriptor instead.
-func (*GenerateScrollVideoResponse) Descriptor() ([]byte, []int) {
-	return file_score_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use BatchTrimItem.ProtoReflect.Descriptor instead.
+func (*BatchTrimItem) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *GenerateScrollVideoResponse) GetMessage() string {
+func (x *BatchTrimItem) GetTitle() string {
 	if x != nil {
-		return x.Message
+		return x.Title
 	}
 	return ""
 }
 
-func (x *GenerateScrollVideoResponse) GetVideoData() []byte {
+func (x *BatchTrimItem) GetPdfFile() []byte {
 	if x != nil {
-		return x.VideoData
+		return x.PdfFile
 	}
 	return nil
 }
 
-func (x *GenerateScrollVideoResponse) GetFilename() string {
+func (x *BatchTrimItem) GetScoreId() string {
+	if x != nil {
+		return x.ScoreId
+	}
+	return ""
+}
+
+func (x *BatchTrimItem) GetSettings() *TrimScoreRequest {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type BatchTrimScoresRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*BatchTrimItem       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Template      *TrimScoreRequest      `protobuf:"bytes,2,opt,name=template,proto3" json:"template,omitempty"`                       // 全項目で共通のトリミング設定（title と pdf_file は無視）
+	Zip           bool                   `protobuf:"varint,3,opt,name=zip,proto3" json:"zip,omitempty"`                                // trueの場合は結果を1つのZIPにまとめ、少しずつ zip_chunk で返す
+	TemplateId    string                 `protobuf:"bytes,4,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"` // 保存したテンプレートのID（templateの代わりに指定）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTrimScoresRequest) Reset() {
+	*x = BatchTrimScoresRequest{}
+	mi := &file_score_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTrimScoresRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTrimScoresRequest) ProtoMessage() {}
+
+func (x *BatchTrimScoresRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTrimScoresRequest.ProtoReflect.Descriptor instead.
+func (*BatchTrimScoresRequest) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *BatchTrimScoresRequest) GetItems() []*BatchTrimItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BatchTrimScoresRequest) GetTemplate() *TrimScoreRequest {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+func (x *BatchTrimScoresRequest) GetZip() bool {
+	if x != nil {
+		return x.Zip
+	}
+	return false
+}
+
+func (x *BatchTrimScoresRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+type BatchTrimScoresResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ItemIndex      int32                  `protobuf:"varint,1,opt,name=item_index,json=itemIndex,proto3" json:"item_index,omitempty"`                // 対象の項目（0始まり）。バッチ全体の通知は -1
+	Stage          string                 `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`                                          // 処理段階（項目の "failed" とバッチ全体の "complete" を含む）
+	Progress       int32                  `protobuf:"varint,3,opt,name=progress,proto3" json:"progress,omitempty"`                                   // バッチ全体の進捗パーセンテージ (0-100)
+	Message        string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                                      // 進捗メッセージ
+	Filename       string                 `protobuf:"bytes,5,opt,name=filename,proto3" json:"filename,omitempty"`                                    // 項目の完了時のファイル名（ZIP内のファイル名と同じ）
+	TrimmedPdf     []byte                 `protobuf:"bytes,6,opt,name=trimmed_pdf,json=trimmedPdf,proto3" json:"trimmed_pdf,omitempty"`              // 項目の完了時のPDF（zip=false の場合のみ）
+	Error          string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`                                          // 項目が失敗した場合のエラーメッセージ
+	CompletedItems int32                  `protobuf:"varint,8,opt,name=completed_items,json=completedItems,proto3" json:"completed_items,omitempty"` // 成功した項目数
+	FailedItems    int32                  `protobuf:"varint,9,opt,name=failed_items,json=failedItems,proto3" json:"failed_items,omitempty"`          // 失敗した項目数
+	TotalItems     int32                  `protobuf:"varint,10,opt,name=total_items,json=totalItems,proto3" json:"total_items,omitempty"`            // 全項目数
+	ZipFilename    string                 `protobuf:"bytes,12,opt,name=zip_filename,json=zipFilename,proto3" json:"zip_filename,omitempty"`          // ZIPの推奨ファイル名（zip=true の場合、最後の通知のみ）
+	ZipChunk       []byte                 `protobuf:"bytes,13,opt,name=zip_chunk,json=zipChunk,proto3" json:"zip_chunk,omitempty"`                   // ZIPの続きのバイト列（zip=true の場合、stage が "zip" の通知）。受け取った順に連結します
+	ZipOffset      int64                  `protobuf:"varint,14,opt,name=zip_offset,json=zipOffset,proto3" json:"zip_offset,omitempty"`               // zip_chunk のZIP内での開始位置
+	ZipSize        int64                  `protobuf:"varint,15,opt,name=zip_size,json=zipSize,proto3" json:"zip_size,omitempty"`                     // ZIP全体のバイト数（最後の通知のみ）
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchTrimScoresResponse) Reset() {
+	*x = BatchTrimScoresResponse{}
+	mi := &file_score_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTrimScoresResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTrimScoresResponse) ProtoMessage() {}
+
+func (x *BatchTrimScoresResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_score_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTrimScoresResponse.ProtoReflect.Descriptor instead.
+func (*BatchTrimScoresResponse) Descriptor() ([]byte, []int) {
+	return file_score_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *BatchTrimScoresResponse) GetItemIndex() int32 {
+	if x != nil {
+		return x.ItemIndex
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *BatchTrimScoresResponse) GetProgress() int32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *BatchTrimScoresResponse) GetFilename() string {
 	if x != nil {
 		return x.Filename
 	}
 	return ""
 }
 
-func (x *GenerateScrollVideoResponse) GetDurationSeconds() int32 {
+func (x *BatchTrimScoresResponse) GetTrimmedPdf() []byte {
 	if x != nil {
-		return x.DurationSeconds
+		return x.TrimmedPdf
+	}
+	return nil
+}
+
+func (x *BatchTrimScoresResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BatchTrimScoresResponse) GetCompletedItems() int32 {
+	if x != nil {
+		return x.CompletedItems
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetFailedItems() int32 {
+	if x != nil {
+		return x.FailedItems
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetTotalItems() int32 {
+	if x != nil {
+		return x.TotalItems
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetZipFilename() string {
+	if x != nil {
+		return x.ZipFilename
+	}
+	return ""
+}
+
+func (x *BatchTrimScoresResponse) GetZipChunk() []byte {
+	if x != nil {
+		return x.ZipChunk
+	}
+	return nil
+}
+
+func (x *BatchTrimScoresResponse) GetZipOffset() int64 {
+	if x != nil {
+		return x.ZipOffset
+	}
+	return 0
+}
+
+func (x *BatchTrimScoresResponse) GetZipSize() int64 {
+	if x != nil {
+		return x.ZipSize
 	}
 	return 0
 }
@@ -788,19 +3211,99 @@ const file_score_proto_rawDesc = "" +
 	"\vscore.proto\x12\x05score\"E\n" +
 	"\x12UploadScoreRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x19\n" +
-	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\"J\n" +
+	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\"h\n" +
 	"\x13UploadScoreResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x19\n" +
-	"\bscore_id\x18\x02 \x01(\tR\ascoreId\"^\n" +
+	"\bscore_id\x18\x02 \x01(\tR\ascoreId\x12\x1c\n" +
+	"\tduplicate\x18\x03 \x01(\bR\tduplicate\"\x92\x01\n" +
+	"\tScoreInfo\x12\x19\n" +
+	"\bscore_id\x18\x01 \x01(\tR\ascoreId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x03R\tsizeBytes\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x16\n" +
+	"\x06titles\x18\x05 \x03(\tR\x06titles\"\x13\n" +
+	"\x11ListScoresRequest\"~\n" +
+	"\x12ListScoresResponse\x12(\n" +
+	"\x06scores\x18\x01 \x03(\v2\x10.score.ScoreInfoR\x06scores\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x02 \x01(\x03R\tusedBytes\x12\x1f\n" +
+	"\vquota_bytes\x18\x03 \x01(\x03R\n" +
+	"quotaBytes\",\n" +
+	"\x0fGetScoreRequest\x12\x19\n" +
+	"\bscore_id\x18\x01 \x01(\tR\ascoreId\"U\n" +
+	"\x10GetScoreResponse\x12&\n" +
+	"\x05score\x18\x01 \x01(\v2\x10.score.ScoreInfoR\x05score\x12\x19\n" +
+	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\"E\n" +
+	"\x12DeleteScoreRequest\x12\x19\n" +
+	"\bscore_id\x18\x01 \x01(\tR\ascoreId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\"/\n" +
+	"\x13DeleteScoreResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"^\n" +
+	"\x13SaveTemplateRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x123\n" +
+	"\bsettings\x18\x02 \x01(\v2\x17.score.TrimScoreRequestR\bsettings\"b\n" +
+	"\fTemplateInfo\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\"\x16\n" +
+	"\x14ListTemplatesRequest\"J\n" +
+	"\x15ListTemplatesResponse\x121\n" +
+	"\ttemplates\x18\x01 \x03(\v2\x13.score.TemplateInfoR\ttemplates\"5\n" +
+	"\x12GetTemplateRequest\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\"{\n" +
+	"\x13GetTemplateResponse\x12/\n" +
+	"\btemplate\x18\x01 \x01(\v2\x13.score.TemplateInfoR\btemplate\x123\n" +
+	"\bsettings\x18\x02 \x01(\v2\x17.score.TrimScoreRequestR\bsettings\"8\n" +
+	"\x15DeleteTemplateRequest\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\"2\n" +
+	"\x16DeleteTemplateResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"a\n" +
+	"\x12BeginUploadRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x03R\ttotalSize\x12\x16\n" +
+	"\x06sha256\x18\x03 \x01(\tR\x06sha256\"p\n" +
+	"\x13BeginUploadResponse\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x02 \x01(\x03R\tchunkSize\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"\x86\x01\n" +
+	"\x18AppendUploadChunkRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12!\n" +
+	"\fchunk_sha256\x18\x04 \x01(\tR\vchunkSha256\"B\n" +
+	"\x19AppendUploadChunkResponse\x12%\n" +
+	"\x0ereceived_bytes\x18\x01 \x01(\x03R\rreceivedBytes\"5\n" +
+	"\x16GetUploadStatusRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"\xb1\x01\n" +
+	"\x17GetUploadStatusResponse\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\x12%\n" +
+	"\x0ereceived_bytes\x18\x04 \x01(\x03R\rreceivedBytes\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\x03R\texpiresAt\"2\n" +
+	"\x13CommitUploadRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"\x84\x01\n" +
 	"\bCropArea\x12\x10\n" +
 	"\x03top\x18\x01 \x01(\x01R\x03top\x12\x12\n" +
 	"\x04left\x18\x02 \x01(\x01R\x04left\x12\x14\n" +
 	"\x05width\x18\x03 \x01(\x01R\x05width\x12\x16\n" +
-	"\x06height\x18\x04 \x01(\x01R\x06height\"Y\n" +
+	"\x06height\x18\x04 \x01(\x01R\x06height\x12$\n" +
+	"\x0ejoin_with_next\x18\x05 \x01(\bR\fjoinWithNext\"Y\n" +
 	"\x0fPageTrimSetting\x12\x1f\n" +
 	"\vpage_number\x18\x01 \x01(\x05R\n" +
 	"pageNumber\x12%\n" +
-	"\x05areas\x18\x02 \x03(\v2\x0f.score.CropAreaR\x05areas\"\x8a\x02\n" +
+	"\x05areas\x18\x02 \x03(\v2\x0f.score.CropAreaR\x05areas\"\xdf\x02\n" +
 	"\x10TrimScoreRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x19\n" +
 	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\x12%\n" +
@@ -808,7 +3311,21 @@ const file_score_proto_rawDesc = "" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x12#\n" +
 	"\rinclude_pages\x18\x05 \x03(\x05R\fincludePages\x12;\n" +
 	"\rpage_settings\x18\x06 \x03(\v2\x16.score.PageTrimSettingR\fpageSettings\x12 \n" +
-	"\vorientation\x18\a \x01(\tR\vorientation\"j\n" +
+	"\vorientation\x18\a \x01(\tR\vorientation\x12)\n" +
+	"\x05split\x18\b \x01(\v2\x13.score.SegmentSplitR\x05split\x12(\n" +
+	"\x05strip\x18\t \x01(\v2\x12.score.StripLayoutR\x05strip\"]\n" +
+	"\vStripLayout\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x01R\x06height\x12$\n" +
+	"\x0emax_page_width\x18\x02 \x01(\x01R\fmaxPageWidth\x12\x10\n" +
+	"\x03gap\x18\x03 \x01(\x01R\x03gap\"\x96\x01\n" +
+	"\fSegmentSplit\x12(\n" +
+	"\x10max_aspect_ratio\x18\x01 \x01(\x01R\x0emaxAspectRatio\x12\x1d\n" +
+	"\n" +
+	"max_height\x18\x02 \x01(\x01R\tmaxHeight\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12\x1d\n" +
+	"\aoverlap\x18\x04 \x01(\x01H\x00R\aoverlap\x88\x01\x01B\n" +
+	"\n" +
+	"\b_overlap\"j\n" +
 	"\x11TrimScoreResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1f\n" +
 	"\vtrimmed_pdf\x18\x02 \x01(\fR\n" +
@@ -828,7 +3345,7 @@ const file_score_proto_rawDesc = "" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12#\n" +
 	"\rthumbnail_url\x18\x03 \x01(\tR\fthumbnailUrl\"J\n" +
 	"\x1bSearchYoutubeVideosResponse\x12+\n" +
-	"\x06videos\x18\x01 \x03(\v2\x13.score.YoutubeVideoR\x06videos\"\xcd\x01\n" +
+	"\x06videos\x18\x01 \x03(\v2\x13.score.YoutubeVideoR\x06videos\"\x92\x06\n" +
 	"\x1aGenerateScrollVideoRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x19\n" +
 	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\x12\x10\n" +
@@ -837,19 +3354,146 @@ const file_score_proto_rawDesc = "" +
 	"videoWidth\x12!\n" +
 	"\fvideo_height\x18\x05 \x01(\x05R\vvideoHeight\x12\x10\n" +
 	"\x03fps\x18\x06 \x01(\x05R\x03fps\x12\x16\n" +
-	"\x06format\x18\a \x01(\tR\x06format\"\x9d\x01\n" +
+	"\x06format\x18\a \x01(\tR\x06format\x12/\n" +
+	"\ttempo_map\x18\b \x03(\v2\x12.score.TempoChangeR\btempoMap\x12\x16\n" +
+	"\x06timing\x18\t \x01(\tR\x06timing\x128\n" +
+	"\rsegment_beats\x18\n" +
+	" \x03(\v2\x13.score.SegmentBeatsR\fsegmentBeats\x12%\n" +
+	"\x0etime_signature\x18\v \x01(\tR\rtimeSignature\x12\x12\n" +
+	"\x04mode\x18\f \x01(\tR\x04mode\x12*\n" +
+	"\x11segments_per_page\x18\r \x01(\x05R\x0fsegmentsPerPage\x12+\n" +
+	"\x11crossfade_seconds\x18\x0e \x01(\x01R\x10crossfadeSeconds\x12!\n" +
+	"\fpreview_next\x18\x0f \x01(\bR\vpreviewNext\x12.\n" +
+	"\tmetronome\x18\x10 \x01(\v2\x10.score.MetronomeR\tmetronome\x12\x1d\n" +
+	"\n" +
+	"audio_file\x18\x11 \x01(\fR\taudioFile\x12&\n" +
+	"\x0faudio_offset_ms\x18\x12 \x01(\x05R\raudioOffsetMs\x12.\n" +
+	"\x13duration_from_audio\x18\x13 \x01(\bR\x11durationFromAudio\x12-\n" +
+	"\aoverlay\x18\x14 \x01(\v2\x13.score.VideoOverlayR\aoverlay\x12\x10\n" +
+	"\x03crf\x18\x15 \x01(\x05R\x03crf\x12!\n" +
+	"\fbitrate_kbps\x18\x16 \x01(\x05R\vbitrateKbps\"\xb6\x01\n" +
+	"\fVideoOverlay\x12\x1a\n" +
+	"\bplayhead\x18\x01 \x01(\bR\bplayhead\x12+\n" +
+	"\x11playhead_position\x18\x02 \x01(\x01R\x10playheadPosition\x12!\n" +
+	"\fprogress_bar\x18\x03 \x01(\bR\vprogressBar\x12\x1b\n" +
+	"\tshow_time\x18\x04 \x01(\bR\bshowTime\x12\x1d\n" +
+	"\n" +
+	"show_title\x18\x05 \x01(\bR\tshowTitle\"G\n" +
+	"\tMetronome\x12\"\n" +
+	"\rcount_in_bars\x18\x01 \x01(\x05R\vcountInBars\x12\x16\n" +
+	"\x06volume\x18\x02 \x01(\x01R\x06volume\"g\n" +
+	"\fSegmentBeats\x12\x14\n" +
+	"\x05beats\x18\x01 \x01(\x01R\x05beats\x12\x1a\n" +
+	"\bmeasures\x18\x02 \x01(\x05R\bmeasures\x12%\n" +
+	"\x0etime_signature\x18\x03 \x01(\tR\rtimeSignature\"\x97\x01\n" +
+	"\vTempoChange\x12#\n" +
+	"\rsegment_index\x18\x01 \x01(\x05R\fsegmentIndex\x12\x1a\n" +
+	"\bposition\x18\x02 \x01(\x01R\bposition\x12\x10\n" +
+	"\x03bpm\x18\x03 \x01(\x05R\x03bpm\x12!\n" +
+	"\fhold_seconds\x18\x04 \x01(\x01R\vholdSeconds\x12\x12\n" +
+	"\x04ramp\x18\x05 \x01(\bR\x04ramp\"\x9d\x01\n" +
 	"\x1bGenerateScrollVideoResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
 	"\n" +
 	"video_data\x18\x02 \x01(\fR\tvideoData\x12\x1a\n" +
 	"\bfilename\x18\x03 \x01(\tR\bfilename\x12)\n" +
-	"\x10duration_seconds\x18\x04 \x01(\x05R\x0fdurationSeconds2\xa6\x03\n" +
+	"\x10duration_seconds\x18\x04 \x01(\x05R\x0fdurationSeconds\"T\n" +
+	"\x11SubmitJobResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12(\n" +
+	"\x06status\x18\x02 \x01(\v2\x10.score.JobStatusR\x06status\"\xa7\x02\n" +
+	"\tJobStatus\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x1a\n" +
+	"\bprogress\x18\x02 \x01(\x05R\bprogress\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x15\n" +
+	"\x06job_id\x18\x04 \x01(\tR\x05jobId\x12\x12\n" +
+	"\x04kind\x18\x05 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05state\x18\x06 \x01(\tR\x05state\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\x03R\tupdatedAt\x12\x1a\n" +
+	"\bfilename\x18\n" +
+	" \x01(\tR\bfilename\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\v \x01(\x03R\texpiresAt\"&\n" +
+	"\rGetJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\x80\x01\n" +
+	"\x14GetJobResultResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\"h\n" +
+	"\x18DownloadJobResultRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x03 \x01(\x03R\tchunkSize\"\xc9\x01\n" +
+	"\x0eJobResultChunk\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x04 \x01(\x03R\ttotalSize\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha256\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\a \x01(\fR\x04data\"\x90\x01\n" +
+	"\rBatchTrimItem\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x19\n" +
+	"\bpdf_file\x18\x02 \x01(\fR\apdfFile\x12\x19\n" +
+	"\bscore_id\x18\x03 \x01(\tR\ascoreId\x123\n" +
+	"\bsettings\x18\x04 \x01(\v2\x17.score.TrimScoreRequestR\bsettings\"\xac\x01\n" +
+	"\x16BatchTrimScoresRequest\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.score.BatchTrimItemR\x05items\x123\n" +
+	"\btemplate\x18\x02 \x01(\v2\x17.score.TrimScoreRequestR\btemplate\x12\x10\n" +
+	"\x03zip\x18\x03 \x01(\bR\x03zip\x12\x1f\n" +
+	"\vtemplate_id\x18\x04 \x01(\tR\n" +
+	"templateId\"\xc4\x03\n" +
+	"\x17BatchTrimScoresResponse\x12\x1d\n" +
+	"\n" +
+	"item_index\x18\x01 \x01(\x05R\titemIndex\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\tR\x05stage\x12\x1a\n" +
+	"\bprogress\x18\x03 \x01(\x05R\bprogress\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1a\n" +
+	"\bfilename\x18\x05 \x01(\tR\bfilename\x12\x1f\n" +
+	"\vtrimmed_pdf\x18\x06 \x01(\fR\n" +
+	"trimmedPdf\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12'\n" +
+	"\x0fcompleted_items\x18\b \x01(\x05R\x0ecompletedItems\x12!\n" +
+	"\ffailed_items\x18\t \x01(\x05R\vfailedItems\x12\x1f\n" +
+	"\vtotal_items\x18\n" +
+	" \x01(\x05R\n" +
+	"totalItems\x12!\n" +
+	"\fzip_filename\x18\f \x01(\tR\vzipFilename\x12\x1b\n" +
+	"\tzip_chunk\x18\r \x01(\fR\bzipChunk\x12\x1d\n" +
+	"\n" +
+	"zip_offset\x18\x0e \x01(\x03R\tzipOffset\x12\x19\n" +
+	"\bzip_size\x18\x0f \x01(\x03R\azipSizeJ\x04\b\v\x10\f2\xa7\r\n" +
 	"\fScoreService\x12D\n" +
 	"\vUploadScore\x12\x19.score.UploadScoreRequest\x1a\x1a.score.UploadScoreResponse\x12>\n" +
 	"\tTrimScore\x12\x17.score.TrimScoreRequest\x1a\x18.score.TrimScoreResponse\x12T\n" +
 	"\x15TrimScoreWithProgress\x12\x17.score.TrimScoreRequest\x1a .score.TrimScoreProgressResponse0\x01\x12\\\n" +
 	"\x13SearchYoutubeVideos\x12!.score.SearchYoutubeVideosRequest\x1a\".score.SearchYoutubeVideosResponse\x12\\\n" +
-	"\x13GenerateScrollVideo\x12!.score.GenerateScrollVideoRequest\x1a\".score.GenerateScrollVideoResponseB+Z)score-splitter/backend/gen/go/score;scoreb\x06proto3"
+	"\x13GenerateScrollVideo\x12!.score.GenerateScrollVideoRequest\x1a\".score.GenerateScrollVideoResponse\x12A\n" +
+	"\n" +
+	"ListScores\x12\x18.score.ListScoresRequest\x1a\x19.score.ListScoresResponse\x12;\n" +
+	"\bGetScore\x12\x16.score.GetScoreRequest\x1a\x17.score.GetScoreResponse\x12D\n" +
+	"\vDeleteScore\x12\x19.score.DeleteScoreRequest\x1a\x1a.score.DeleteScoreResponse\x12D\n" +
+	"\vBeginUpload\x12\x19.score.BeginUploadRequest\x1a\x1a.score.BeginUploadResponse\x12V\n" +
+	"\x11AppendUploadChunk\x12\x1f.score.AppendUploadChunkRequest\x1a .score.AppendUploadChunkResponse\x12P\n" +
+	"\x0fGetUploadStatus\x12\x1d.score.GetUploadStatusRequest\x1a\x1e.score.GetUploadStatusResponse\x12F\n" +
+	"\fCommitUpload\x12\x1a.score.CommitUploadRequest\x1a\x1a.score.UploadScoreResponse\x12B\n" +
+	"\rSubmitTrimJob\x12\x17.score.TrimScoreRequest\x1a\x18.score.SubmitJobResponse\x12M\n" +
+	"\x0eSubmitVideoJob\x12!.score.GenerateScrollVideoRequest\x1a\x18.score.SubmitJobResponse\x120\n" +
+	"\x06GetJob\x12\x14.score.GetJobRequest\x1a\x10.score.JobStatus\x124\n" +
+	"\bWatchJob\x12\x14.score.GetJobRequest\x1a\x10.score.JobStatus0\x01\x12A\n" +
+	"\fGetJobResult\x12\x14.score.GetJobRequest\x1a\x1b.score.GetJobResultResponse\x12M\n" +
+	"\x11DownloadJobResult\x12\x1f.score.DownloadJobResultRequest\x1a\x15.score.JobResultChunk0\x01\x12R\n" +
+	"\x0fBatchTrimScores\x12\x1d.score.BatchTrimScoresRequest\x1a\x1e.score.BatchTrimScoresResponse0\x01\x12?\n" +
+	"\fSaveTemplate\x12\x1a.score.SaveTemplateRequest\x1a\x13.score.TemplateInfo\x12J\n" +
+	"\rListTemplates\x12\x1b.score.ListTemplatesRequest\x1a\x1c.score.ListTemplatesResponse\x12D\n" +
+	"\vGetTemplate\x12\x19.score.GetTemplateRequest\x1a\x1a.score.GetTemplateResponse\x12M\n" +
+	"\x0eDeleteTemplate\x12\x1c.score.DeleteTemplateRequest\x1a\x1d.score.DeleteTemplateResponseB+Z)score-splitter/backend/gen/go/score;scoreb\x06proto3"
 
 var (
 	file_score_proto_rawDescOnce sync.Once
@@ -863,41 +3507,130 @@ func file_score_proto_rawDescGZIP() []byte {
 	return file_score_proto_rawDescData
 }
 
-var file_score_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_score_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
 var file_score_proto_goTypes = []any{
 	(*UploadScoreRequest)(nil),          // 0: score.UploadScoreRequest
 	(*UploadScoreResponse)(nil),         // 1: score.UploadScoreResponse
-	(*CropArea)(nil),                    // 2: score.CropArea
-	(*PageTrimSetting)(nil),             // 3: score.PageTrimSetting
-	(*TrimScoreRequest)(nil),            // 4: score.TrimScoreRequest
-	(*TrimScoreResponse)(nil),           // 5: score.TrimScoreResponse
-	(*TrimScoreProgressResponse)(nil),   // 6: score.TrimScoreProgressResponse
-	(*SearchYoutubeVideosRequest)(nil),  // 7: score.SearchYoutubeVideosRequest
-	(*YoutubeVideo)(nil),                // 8: score.YoutubeVideo
-	(*SearchYoutubeVideosResponse)(nil), // 9: score.SearchYoutubeVideosResponse
-	(*GenerateScrollVideoRequest)(nil),  // 10: score.GenerateScrollVideoRequest
-	(*GenerateScrollVideoResponse)(nil), // 11: score.GenerateScrollVideoResponse
+	(*ScoreInfo)(nil),                   // 2: score.ScoreInfo
+	(*ListScoresRequest)(nil),           // 3: score.ListScoresRequest
+	(*ListScoresResponse)(nil),          // 4: score.ListScoresResponse
+	(*GetScoreRequest)(nil),             // 5: score.GetScoreRequest
+	(*GetScoreResponse)(nil),            // 6: score.GetScoreResponse
+	(*DeleteScoreRequest)(nil),          // 7: score.DeleteScoreRequest
+	(*DeleteScoreResponse)(nil),         // 8: score.DeleteScoreResponse
+	(*SaveTemplateRequest)(nil),         // 9: score.SaveTemplateRequest
+	(*TemplateInfo)(nil),                // 10: score.TemplateInfo
+	(*ListTemplatesRequest)(nil),        // 11: score.ListTemplatesRequest
+	(*ListTemplatesResponse)(nil),       // 12: score.ListTemplatesResponse
+	(*GetTemplateRequest)(nil),          // 13: score.GetTemplateRequest
+	(*GetTemplateResponse)(nil),         // 14: score.GetTemplateResponse
+	(*DeleteTemplateRequest)(nil),       // 15: score.DeleteTemplateRequest
+	(*DeleteTemplateResponse)(nil),      // 16: score.DeleteTemplateResponse
+	(*BeginUploadRequest)(nil),          // 17: score.BeginUploadRequest
+	(*BeginUploadResponse)(nil),         // 18: score.BeginUploadResponse
+	(*AppendUploadChunkRequest)(nil),    // 19: score.AppendUploadChunkRequest
+	(*AppendUploadChunkResponse)(nil),   // 20: score.AppendUploadChunkResponse
+	(*GetUploadStatusRequest)(nil),      // 21: score.GetUploadStatusRequest
+	(*GetUploadStatusResponse)(nil),     // 22: score.GetUploadStatusResponse
+	(*CommitUploadRequest)(nil),         // 23: score.CommitUploadRequest
+	(*CropArea)(nil),                    // 24: score.CropArea
+	(*PageTrimSetting)(nil),             // 25: score.PageTrimSetting
+	(*TrimScoreRequest)(nil),            // 26: score.TrimScoreRequest
+	(*StripLayout)(nil),                 // 27: score.StripLayout
+	(*SegmentSplit)(nil),                // 28: score.SegmentSplit
+	(*TrimScoreResponse)(nil),           // 29: score.TrimScoreResponse
+	(*TrimScoreProgressResponse)(nil),   // 30: score.TrimScoreProgressResponse
+	(*SearchYoutubeVideosRequest)(nil),  // 31: score.SearchYoutubeVideosRequest
+	(*YoutubeVideo)(nil),                // 32: score.YoutubeVideo
+	(*SearchYoutubeVideosResponse)(nil), // 33: score.SearchYoutubeVideosResponse
+	(*GenerateScrollVideoRequest)(nil),  // 34: score.GenerateScrollVideoRequest
+	(*VideoOverlay)(nil),                // 35: score.VideoOverlay
+	(*Metronome)(nil),                   // 36: score.Metronome
+	(*SegmentBeats)(nil),                // 37: score.SegmentBeats
+	(*TempoChange)(nil),                 // 38: score.TempoChange
+	(*GenerateScrollVideoResponse)(nil), // 39: score.GenerateScrollVideoResponse
+	(*SubmitJobResponse)(nil),           // 40: score.SubmitJobResponse
+	(*JobStatus)(nil),                   // 41: score.JobStatus
+	(*GetJobRequest)(nil),               // 42: score.GetJobRequest
+	(*GetJobResultResponse)(nil),        // 43: score.GetJobResultResponse
+	(*DownloadJobResultRequest)(nil),    // 44: score.DownloadJobResultRequest
+	(*JobResultChunk)(nil),              // 45: score.JobResultChunk
+	(*BatchTrimItem)(nil),               // 46: score.BatchTrimItem
+	(*BatchTrimScoresRequest)(nil),      // 47: score.BatchTrimScoresRequest
+	(*BatchTrimScoresResponse)(nil),     // 48: score.BatchTrimScoresResponse
 }
 var file_score_proto_depIdxs = []int32{
-	2,  // 0: score.PageTrimSetting.areas:type_name -> score.CropArea
-	2,  // 1: score.TrimScoreRequest.areas:type_name -> score.CropArea
-	3,  // 2: score.TrimScoreRequest.page_settings:type_name -> score.PageTrimSetting
-	8,  // 3: score.SearchYoutubeVideosResponse.videos:type_name -> score.YoutubeVideo
-	0,  // 4: score.ScoreService.UploadScore:input_type -> score.UploadScoreRequest
-	4,  // 5: score.ScoreService.TrimScore:input_type -> score.TrimScoreRequest
-	4,  // 6: score.ScoreService.TrimScoreWithProgress:input_type -> score.TrimScoreRequest
-	7,  // 7: score.ScoreService.SearchYoutubeVideos:input_type -> score.SearchYoutubeVideosRequest
-	10, // 8: score.ScoreService.GenerateScrollVideo:input_type -> score.GenerateScrollVideoRequest
-	1,  // 9: score.ScoreService.UploadScore:output_type -> score.UploadScoreResponse
-	5,  // 10: score.ScoreService.TrimScore:output_type -> score.TrimScoreResponse
-	6,  // 11: score.ScoreService.TrimScoreWithProgress:output_type -> score.TrimScoreProgressResponse
-	9,  // 12: score.ScoreService.SearchYoutubeVideos:output_type -> score.SearchYoutubeVideosResponse
-	11, // 13: score.ScoreService.GenerateScrollVideo:output_type -> score.GenerateScrollVideoResponse
-	9,  // [9:14] is the sub-list for method output_type
-	4,  // [4:9] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	2,  // 0: score.ListScoresResponse.scores:type_name -> score.ScoreInfo
+	2,  // 1: score.GetScoreResponse.score:type_name -> score.ScoreInfo
+	26, // 2: score.SaveTemplateRequest.settings:type_name -> score.TrimScoreRequest
+	10, // 3: score.ListTemplatesResponse.templates:type_name -> score.TemplateInfo
+	10, // 4: score.GetTemplateResponse.template:type_name -> score.TemplateInfo
+	26, // 5: score.GetTemplateResponse.settings:type_name -> score.TrimScoreRequest
+	24, // 6: score.PageTrimSetting.areas:type_name -> score.CropArea
+	24, // 7: score.TrimScoreRequest.areas:type_name -> score.CropArea
+	25, // 8: score.TrimScoreRequest.page_settings:type_name -> score.PageTrimSetting
+	28, // 9: score.TrimScoreRequest.split:type_name -> score.SegmentSplit
+	27, // 10: score.TrimScoreRequest.strip:type_name -> score.StripLayout
+	32, // 11: score.SearchYoutubeVideosResponse.videos:type_name -> score.YoutubeVideo
+	38, // 12: score.GenerateScrollVideoRequest.tempo_map:type_name -> score.TempoChange
+	37, // 13: score.GenerateScrollVideoRequest.segment_beats:type_name -> score.SegmentBeats
+	36, // 14: score.GenerateScrollVideoRequest.metronome:type_name -> score.Metronome
+	35, // 15: score.GenerateScrollVideoRequest.overlay:type_name -> score.VideoOverlay
+	41, // 16: score.SubmitJobResponse.status:type_name -> score.JobStatus
+	26, // 17: score.BatchTrimItem.settings:type_name -> score.TrimScoreRequest
+	46, // 18: score.BatchTrimScoresRequest.items:type_name -> score.BatchTrimItem
+	26, // 19: score.BatchTrimScoresRequest.template:type_name -> score.TrimScoreRequest
+	0,  // 20: score.ScoreService.UploadScore:input_type -> score.UploadScoreRequest
+	26, // 21: score.ScoreService.TrimScore:input_type -> score.TrimScoreRequest
+	26, // 22: score.ScoreService.TrimScoreWithProgress:input_type -> score.TrimScoreRequest
+	31, // 23: score.ScoreService.SearchYoutubeVideos:input_type -> score.SearchYoutubeVideosRequest
+	34, // 24: score.ScoreService.GenerateScrollVideo:input_type -> score.GenerateScrollVideoRequest
+	3,  // 25: score.ScoreService.ListScores:input_type -> score.ListScoresRequest
+	5,  // 26: score.ScoreService.GetScore:input_type -> score.GetScoreRequest
+	7,  // 27: score.ScoreService.DeleteScore:input_type -> score.DeleteScoreRequest
+	17, // 28: score.ScoreService.BeginUpload:input_type -> score.BeginUploadRequest
+	19, // 29: score.ScoreService.AppendUploadChunk:input_type -> score.AppendUploadChunkRequest
+	21, // 30: score.ScoreService.GetUploadStatus:input_type -> score.GetUploadStatusRequest
+	23, // 31: score.ScoreService.CommitUpload:input_type -> score.CommitUploadRequest
+	26, // 32: score.ScoreService.SubmitTrimJob:input_type -> score.TrimScoreRequest
+	34, // 33: score.ScoreService.SubmitVideoJob:input_type -> score.GenerateScrollVideoRequest
+	42, // 34: score.ScoreService.GetJob:input_type -> score.GetJobRequest
+	42, // 35: score.ScoreService.WatchJob:input_type -> score.GetJobRequest
+	42, // 36: score.ScoreService.GetJobResult:input_type -> score.GetJobRequest
+	44, // 37: score.ScoreService.DownloadJobResult:input_type -> score.DownloadJobResultRequest
+	47, // 38: score.ScoreService.BatchTrimScores:input_type -> score.BatchTrimScoresRequest
+	9,  // 39: score.ScoreService.SaveTemplate:input_type -> score.SaveTemplateRequest
+	11, // 40: score.ScoreService.ListTemplates:input_type -> score.ListTemplatesRequest
+	13, // 41: score.ScoreService.GetTemplate:input_type -> score.GetTemplateRequest
+	15, // 42: score.ScoreService.DeleteTemplate:input_type -> score.DeleteTemplateRequest
+	1,  // 43: score.ScoreService.UploadScore:output_type -> score.UploadScoreResponse
+	29, // 44: score.ScoreService.TrimScore:output_type -> score.TrimScoreResponse
+	30, // 45: score.ScoreService.TrimScoreWithProgress:output_type -> score.TrimScoreProgressResponse
+	33, // 46: score.ScoreService.SearchYoutubeVideos:output_type -> score.SearchYoutubeVideosResponse
+	39, // 47: score.ScoreService.GenerateScrollVideo:output_type -> score.GenerateScrollVideoResponse
+	4,  // 48: score.ScoreService.ListScores:output_type -> score.ListScoresResponse
+	6,  // 49: score.ScoreService.GetScore:output_type -> score.GetScoreResponse
+	8,  // 50: score.ScoreService.DeleteScore:output_type -> score.DeleteScoreResponse
+	18, // 51: score.ScoreService.BeginUpload:output_type -> score.BeginUploadResponse
+	20, // 52: score.ScoreService.AppendUploadChunk:output_type -> score.AppendUploadChunkResponse
+	22, // 53: score.ScoreService.GetUploadStatus:output_type -> score.GetUploadStatusResponse
+	1,  // 54: score.ScoreService.CommitUpload:output_type -> score.UploadScoreResponse
+	40, // 55: score.ScoreService.SubmitTrimJob:output_type -> score.SubmitJobResponse
+	40, // 56: score.ScoreService.SubmitVideoJob:output_type -> score.SubmitJobResponse
+	41, // 57: score.ScoreService.GetJob:output_type -> score.JobStatus
+	41, // 58: score.ScoreService.WatchJob:output_type -> score.JobStatus
+	43, // 59: score.ScoreService.GetJobResult:output_type -> score.GetJobResultResponse
+	45, // 60: score.ScoreService.DownloadJobResult:output_type -> score.JobResultChunk
+	48, // 61: score.ScoreService.BatchTrimScores:output_type -> score.BatchTrimScoresResponse
+	10, // 62: score.ScoreService.SaveTemplate:output_type -> score.TemplateInfo
+	12, // 63: score.ScoreService.ListTemplates:output_type -> score.ListTemplatesResponse
+	14, // 64: score.ScoreService.GetTemplate:output_type -> score.GetTemplateResponse
+	16, // 65: score.ScoreService.DeleteTemplate:output_type -> score.DeleteTemplateResponse
+	43, // [43:66] is the sub-list for method output_type
+	20, // [20:43] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_score_proto_init() }
@@ -905,13 +3638,14 @@ func file_score_proto_init() {
 	if File_score_proto != nil {
 		return
 	}
+	file_score_proto_msgTypes[28].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_score_proto_rawDesc), len(file_score_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   49,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
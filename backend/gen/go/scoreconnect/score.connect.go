@@ -47,6 +47,56 @@ const (
 	// ScoreServiceGenerateScrollVideoProcedure is the fully-qualified name of the ScoreService's
 	// GenerateScrollVideo RPC.
 	ScoreServiceGenerateScrollVideoProcedure = "/score.ScoreService/GenerateScrollVideo"
+	// ScoreServiceListScoresProcedure is the fully-qualified name of the ScoreService's ListScores RPC.
+	ScoreServiceListScoresProcedure = "/score.ScoreService/ListScores"
+	// ScoreServiceGetScoreProcedure is the fully-qualified name of the ScoreService's GetScore RPC.
+	ScoreServiceGetScoreProcedure = "/score.ScoreService/GetScore"
+	// ScoreServiceDeleteScoreProcedure is the fully-qualified name of the ScoreService's DeleteScore
+	// RPC.
+	ScoreServiceDeleteScoreProcedure = "/score.ScoreService/DeleteScore"
+	// ScoreServiceBeginUploadProcedure is the fully-qualified name of the ScoreService's BeginUpload
+	// RPC.
+	ScoreServiceBeginUploadProcedure = "/score.ScoreService/BeginUpload"
+	// ScoreServiceAppendUploadChunkProcedure is the fully-qualified name of the ScoreService's
+	// AppendUploadChunk RPC.
+	ScoreServiceAppendUploadChunkProcedure = "/score.ScoreService/AppendUploadChunk"
+	// ScoreServiceGetUploadStatusProcedure is the fully-qualified name of the ScoreService's
+	// GetUploadStatus RPC.
+	ScoreServiceGetUploadStatusProcedure = "/score.ScoreService/GetUploadStatus"
+	// ScoreServiceCommitUploadProcedure is the fully-qualified name of the ScoreService's CommitUpload
+	// RPC.
+	ScoreServiceCommitUploadProcedure = "/score.ScoreService/CommitUpload"
+	// ScoreServiceSubmitTrimJobProcedure is the fully-qualified name of the ScoreService's
+	// SubmitTrimJob RPC.
+	ScoreServiceSubmitTrimJobProcedure = "/score.ScoreService/SubmitTrimJob"
+	// ScoreServiceSubmitVideoJobProcedure is the fully-qualified name of the ScoreService's
+	// SubmitVideoJob RPC.
+	ScoreServiceSubmitVideoJobProcedure = "/score.ScoreService/SubmitVideoJob"
+	// ScoreServiceGetJobProcedure is the fully-qualified name of the ScoreService's GetJob RPC.
+	ScoreServiceGetJobProcedure = "/score.ScoreService/GetJob"
+	// ScoreServiceWatchJobProcedure is the fully-qualified name of the ScoreService's WatchJob RPC.
+	ScoreServiceWatchJobProcedure = "/score.ScoreService/WatchJob"
+	// ScoreServiceGetJobResultProcedure is the fully-qualified name of the ScoreService's GetJobResult
+	// RPC.
+	ScoreServiceGetJobResultProcedure = "/score.ScoreService/GetJobResult"
+	// ScoreServiceDownloadJobResultProcedure is the fully-qualified name of the ScoreService's
+	// DownloadJobResult RPC.
+	ScoreServiceDownloadJobResultProcedure = "/score.ScoreService/DownloadJobResult"
+	// ScoreServiceBatchTrimScoresProcedure is the fully-qualified name of the ScoreService's
+	// BatchTrimScores RPC.
+	ScoreServiceBatchTrimScoresProcedure = "/score.ScoreService/BatchTrimScores"
+	// ScoreServiceSaveTemplateProcedure is the fully-qualified name of the ScoreService's SaveTemplate
+	// RPC.
+	ScoreServiceSaveTemplateProcedure = "/score.ScoreService/SaveTemplate"
+	// ScoreServiceListTemplatesProcedure is the fully-qualified name of the ScoreService's
+	// ListTemplates RPC.
+	ScoreServiceListTemplatesProcedure = "/score.ScoreService/ListTemplates"
+	// ScoreServiceGetTemplateProcedure is the fully-qualified name of the ScoreService's GetTemplate
+	// RPC.
+	ScoreServiceGetTemplateProcedure = "/score.ScoreService/GetTemplate"
+	// ScoreServiceDeleteTemplateProcedure is the fully-qualified name of the ScoreService's
+	// DeleteTemplate RPC.
+	ScoreServiceDeleteTemplateProcedure = "/score.ScoreService/DeleteTemplate"
 )
 
 // ScoreServiceClient is a client for the score.ScoreService service.
@@ -56,6 +106,24 @@ type ScoreServiceClient interface {
 	TrimScoreWithProgress(context.Context, *connect.Request[score.TrimScoreRequest]) (*connect.ServerStreamForClient[score.TrimScoreProgressResponse], error)
 	SearchYoutubeVideos(context.Context, *connect.Request[score.SearchYoutubeVideosRequest]) (*connect.Response[score.SearchYoutubeVideosResponse], error)
 	GenerateScrollVideo(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.GenerateScrollVideoResponse], error)
+	ListScores(context.Context, *connect.Request[score.ListScoresRequest]) (*connect.Response[score.ListScoresResponse], error)
+	GetScore(context.Context, *connect.Request[score.GetScoreRequest]) (*connect.Response[score.GetScoreResponse], error)
+	DeleteScore(context.Context, *connect.Request[score.DeleteScoreRequest]) (*connect.Response[score.DeleteScoreResponse], error)
+	BeginUpload(context.Context, *connect.Request[score.BeginUploadRequest]) (*connect.Response[score.BeginUploadResponse], error)
+	AppendUploadChunk(context.Context, *connect.Request[score.AppendUploadChunkRequest]) (*connect.Response[score.AppendUploadChunkResponse], error)
+	GetUploadStatus(context.Context, *connect.Request[score.GetUploadStatusRequest]) (*connect.Response[score.GetUploadStatusResponse], error)
+	CommitUpload(context.Context, *connect.Request[score.CommitUploadRequest]) (*connect.Response[score.UploadScoreResponse], error)
+	SubmitTrimJob(context.Context, *connect.Request[score.TrimScoreRequest]) (*connect.Response[score.SubmitJobResponse], error)
+	SubmitVideoJob(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.SubmitJobResponse], error)
+	GetJob(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.JobStatus], error)
+	WatchJob(context.Context, *connect.Request[score.GetJobRequest]) (*connect.ServerStreamForClient[score.JobStatus], error)
+	GetJobResult(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.GetJobResultResponse], error)
+	DownloadJobResult(context.Context, *connect.Request[score.DownloadJobResultRequest]) (*connect.ServerStreamForClient[score.JobResultChunk], error)
+	BatchTrimScores(context.Context, *connect.Request[score.BatchTrimScoresRequest]) (*connect.ServerStreamForClient[score.BatchTrimScoresResponse], error)
+	SaveTemplate(context.Context, *connect.Request[score.SaveTemplateRequest]) (*connect.Response[score.TemplateInfo], error)
+	ListTemplates(context.Context, *connect.Request[score.ListTemplatesRequest]) (*connect.Response[score.ListTemplatesResponse], error)
+	GetTemplate(context.Context, *connect.Request[score.GetTemplateRequest]) (*connect.Response[score.GetTemplateResponse], error)
+	DeleteTemplate(context.Context, *connect.Request[score.DeleteTemplateRequest]) (*connect.Response[score.DeleteTemplateResponse], error)
 }
 
 // NewScoreServiceClient constructs a client for the score.ScoreService service. By default, it uses
@@ -99,6 +167,114 @@ func NewScoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts .
 			connect.WithSchema(scoreServiceMethods.ByName("GenerateScrollVideo")),
 			connect.WithClientOptions(opts...),
 		),
+		listScores: connect.NewClient[score.ListScoresRequest, score.ListScoresResponse](
+			httpClient,
+			baseURL+ScoreServiceListScoresProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("ListScores")),
+			connect.WithClientOptions(opts...),
+		),
+		getScore: connect.NewClient[score.GetScoreRequest, score.GetScoreResponse](
+			httpClient,
+			baseURL+ScoreServiceGetScoreProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("GetScore")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteScore: connect.NewClient[score.DeleteScoreRequest, score.DeleteScoreResponse](
+			httpClient,
+			baseURL+ScoreServiceDeleteScoreProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("DeleteScore")),
+			connect.WithClientOptions(opts...),
+		),
+		beginUpload: connect.NewClient[score.BeginUploadRequest, score.BeginUploadResponse](
+			httpClient,
+			baseURL+ScoreServiceBeginUploadProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("BeginUpload")),
+			connect.WithClientOptions(opts...),
+		),
+		appendUploadChunk: connect.NewClient[score.AppendUploadChunkRequest, score.AppendUploadChunkResponse](
+			httpClient,
+			baseURL+ScoreServiceAppendUploadChunkProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("AppendUploadChunk")),
+			connect.WithClientOptions(opts...),
+		),
+		getUploadStatus: connect.NewClient[score.GetUploadStatusRequest, score.GetUploadStatusResponse](
+			httpClient,
+			baseURL+ScoreServiceGetUploadStatusProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("GetUploadStatus")),
+			connect.WithClientOptions(opts...),
+		),
+		commitUpload: connect.NewClient[score.CommitUploadRequest, score.UploadScoreResponse](
+			httpClient,
+			baseURL+ScoreServiceCommitUploadProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("CommitUpload")),
+			connect.WithClientOptions(opts...),
+		),
+		submitTrimJob: connect.NewClient[score.TrimScoreRequest, score.SubmitJobResponse](
+			httpClient,
+			baseURL+ScoreServiceSubmitTrimJobProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("SubmitTrimJob")),
+			connect.WithClientOptions(opts...),
+		),
+		submitVideoJob: connect.NewClient[score.GenerateScrollVideoRequest, score.SubmitJobResponse](
+			httpClient,
+			baseURL+ScoreServiceSubmitVideoJobProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("SubmitVideoJob")),
+			connect.WithClientOptions(opts...),
+		),
+		getJob: connect.NewClient[score.GetJobRequest, score.JobStatus](
+			httpClient,
+			baseURL+ScoreServiceGetJobProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("GetJob")),
+			connect.WithClientOptions(opts...),
+		),
+		watchJob: connect.NewClient[score.GetJobRequest, score.JobStatus](
+			httpClient,
+			baseURL+ScoreServiceWatchJobProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("WatchJob")),
+			connect.WithClientOptions(opts...),
+		),
+		getJobResult: connect.NewClient[score.GetJobRequest, score.GetJobResultResponse](
+			httpClient,
+			baseURL+ScoreServiceGetJobResultProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("GetJobResult")),
+			connect.WithClientOptions(opts...),
+		),
+		downloadJobResult: connect.NewClient[score.DownloadJobResultRequest, score.JobResultChunk](
+			httpClient,
+			baseURL+ScoreServiceDownloadJobResultProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("DownloadJobResult")),
+			connect.WithClientOptions(opts...),
+		),
+		batchTrimScores: connect.NewClient[score.BatchTrimScoresRequest, score.BatchTrimScoresResponse](
+			httpClient,
+			baseURL+ScoreServiceBatchTrimScoresProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("BatchTrimScores")),
+			connect.WithClientOptions(opts...),
+		),
+		saveTemplate: connect.NewClient[score.SaveTemplateRequest, score.TemplateInfo](
+			httpClient,
+			baseURL+ScoreServiceSaveTemplateProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("SaveTemplate")),
+			connect.WithClientOptions(opts...),
+		),
+		listTemplates: connect.NewClient[score.ListTemplatesRequest, score.ListTemplatesResponse](
+			httpClient,
+			baseURL+ScoreServiceListTemplatesProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("ListTemplates")),
+			connect.WithClientOptions(opts...),
+		),
+		getTemplate: connect.NewClient[score.GetTemplateRequest, score.GetTemplateResponse](
+			httpClient,
+			baseURL+ScoreServiceGetTemplateProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("GetTemplate")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteTemplate: connect.NewClient[score.DeleteTemplateRequest, score.DeleteTemplateResponse](
+			httpClient,
+			baseURL+ScoreServiceDeleteTemplateProcedure,
+			connect.WithSchema(scoreServiceMethods.ByName("DeleteTemplate")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -109,6 +285,24 @@ type scoreServiceClient struct {
 	trimScoreWithProgress *connect.Client[score.TrimScoreRequest, score.TrimScoreProgressResponse]
 	searchYoutubeVideos   *connect.Client[score.SearchYoutubeVideosRequest, score.SearchYoutubeVideosResponse]
 	generateScrollVideo   *connect.Client[score.GenerateScrollVideoRequest, score.GenerateScrollVideoResponse]
+	listScores            *connect.Client[score.ListScoresRequest, score.ListScoresResponse]
+	getScore              *connect.Client[score.GetScoreRequest, score.GetScoreResponse]
+	deleteScore           *connect.Client[score.DeleteScoreRequest, score.DeleteScoreResponse]
+	beginUpload           *connect.Client[score.BeginUploadRequest, score.BeginUploadResponse]
+	appendUploadChunk     *connect.Client[score.AppendUploadChunkRequest, score.AppendUploadChunkResponse]
+	getUploadStatus       *connect.Client[score.GetUploadStatusRequest, score.GetUploadStatusResponse]
+	commitUpload          *connect.Client[score.CommitUploadRequest, score.UploadScoreResponse]
+	submitTrimJob         *connect.Client[score.TrimScoreRequest, score.SubmitJobResponse]
+	submitVideoJob        *connect.Client[score.GenerateScrollVideoRequest, score.SubmitJobResponse]
+	getJob                *connect.Client[score.GetJobRequest, score.JobStatus]
+	watchJob              *connect.Client[score.GetJobRequest, score.JobStatus]
+	getJobResult          *connect.Client[score.GetJobRequest, score.GetJobResultResponse]
+	downloadJobResult     *connect.Client[score.DownloadJobResultRequest, score.JobResultChunk]
+	batchTrimScores       *connect.Client[score.BatchTrimScoresRequest, score.BatchTrimScoresResponse]
+	saveTemplate          *connect.Client[score.SaveTemplateRequest, score.TemplateInfo]
+	listTemplates         *connect.Client[score.ListTemplatesRequest, score.ListTemplatesResponse]
+	getTemplate           *connect.Client[score.GetTemplateRequest, score.GetTemplateResponse]
+	deleteTemplate        *connect.Client[score.DeleteTemplateRequest, score.DeleteTemplateResponse]
 }
 
 // UploadScore calls score.ScoreService.UploadScore.
@@ -136,6 +330,96 @@ func (c *scoreServiceClient) GenerateScrollVideo(ctx context.Context, req *conne
 	return c.generateScrollVideo.CallUnary(ctx, req)
 }
 
+// ListScores calls score.ScoreService.ListScores.
+func (c *scoreServiceClient) ListScores(ctx context.Context, req *connect.Request[score.ListScoresRequest]) (*connect.Response[score.ListScoresResponse], error) {
+	return c.listScores.CallUnary(ctx, req)
+}
+
+// GetScore calls score.ScoreService.GetScore.
+func (c *scoreServiceClient) GetScore(ctx context.Context, req *connect.Request[score.GetScoreRequest]) (*connect.Response[score.GetScoreResponse], error) {
+	return c.getScore.CallUnary(ctx, req)
+}
+
+// DeleteScore calls score.ScoreService.DeleteScore.
+func (c *scoreServiceClient) DeleteScore(ctx context.Context, req *connect.Request[score.DeleteScoreRequest]) (*connect.Response[score.DeleteScoreResponse], error) {
+	return c.deleteScore.CallUnary(ctx, req)
+}
+
+// BeginUpload calls score.ScoreService.BeginUpload.
+func (c *scoreServiceClient) BeginUpload(ctx context.Context, req *connect.Request[score.BeginUploadRequest]) (*connect.Response[score.BeginUploadResponse], error) {
+	return c.beginUpload.CallUnary(ctx, req)
+}
+
+// AppendUploadChunk calls score.ScoreService.AppendUploadChunk.
+func (c *scoreServiceClient) AppendUploadChunk(ctx context.Context, req *connect.Request[score.AppendUploadChunkRequest]) (*connect.Response[score.AppendUploadChunkResponse], error) {
+	return c.appendUploadChunk.CallUnary(ctx, req)
+}
+
+// GetUploadStatus calls score.ScoreService.GetUploadStatus.
+func (c *scoreServiceClient) GetUploadStatus(ctx context.Context, req *connect.Request[score.GetUploadStatusRequest]) (*connect.Response[score.GetUploadStatusResponse], error) {
+	return c.getUploadStatus.CallUnary(ctx, req)
+}
+
+// CommitUpload calls score.ScoreService.CommitUpload.
+func (c *scoreServiceClient) CommitUpload(ctx context.Context, req *connect.Request[score.CommitUploadRequest]) (*connect.Response[score.UploadScoreResponse], error) {
+	return c.commitUpload.CallUnary(ctx, req)
+}
+
+// SubmitTrimJob calls score.ScoreService.SubmitTrimJob.
+func (c *scoreServiceClient) SubmitTrimJob(ctx context.Context, req *connect.Request[score.TrimScoreRequest]) (*connect.Response[score.SubmitJobResponse], error) {
+	return c.submitTrimJob.CallUnary(ctx, req)
+}
+
+// SubmitVideoJob calls score.ScoreService.SubmitVideoJob.
+func (c *scoreServiceClient) SubmitVideoJob(ctx context.Context, req *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.SubmitJobResponse], error) {
+	return c.submitVideoJob.CallUnary(ctx, req)
+}
+
+// GetJob calls score.ScoreService.GetJob.
+func (c *scoreServiceClient) GetJob(ctx context.Context, req *connect.Request[score.GetJobRequest]) (*connect.Response[score.JobStatus], error) {
+	return c.getJob.CallUnary(ctx, req)
+}
+
+// WatchJob calls score.ScoreService.WatchJob.
+func (c *scoreServiceClient) WatchJob(ctx context.Context, req *connect.Request[score.GetJobRequest]) (*connect.ServerStreamForClient[score.JobStatus], error) {
+	return c.watchJob.CallServerStream(ctx, req)
+}
+
+// GetJobResult calls score.ScoreService.GetJobResult.
+func (c *scoreServiceClient) GetJobResult(ctx context.Context, req *connect.Request[score.GetJobRequest]) (*connect.Response[score.GetJobResultResponse], error) {
+	return c.getJobResult.CallUnary(ctx, req)
+}
+
+// DownloadJobResult calls score.ScoreService.DownloadJobResult.
+func (c *scoreServiceClient) DownloadJobResult(ctx context.Context, req *connect.Request[score.DownloadJobResultRequest]) (*connect.ServerStreamForClient[score.JobResultChunk], error) {
+	return c.downloadJobResult.CallServerStream(ctx, req)
+}
+
+// BatchTrimScores calls score.ScoreService.BatchTrimScores.
+func (c *scoreServiceClient) BatchTrimScores(ctx context.Context, req *connect.Request[score.BatchTrimScoresRequest]) (*connect.ServerStreamForClient[score.BatchTrimScoresResponse], error) {
+	return c.batchTrimScores.CallServerStream(ctx, req)
+}
+
+// SaveTemplate calls score.ScoreService.SaveTemplate.
+func (c *scoreServiceClient) SaveTemplate(ctx context.Context, req *connect.Request[score.SaveTemplateRequest]) (*connect.Response[score.TemplateInfo], error) {
+	return c.saveTemplate.CallUnary(ctx, req)
+}
+
+// ListTemplates calls score.ScoreService.ListTemplates.
+func (c *scoreServiceClient) ListTemplates(ctx context.Context, req *connect.Request[score.ListTemplatesRequest]) (*connect.Response[score.ListTemplatesResponse], error) {
+	return c.listTemplates.CallUnary(ctx, req)
+}
+
+// GetTemplate calls score.ScoreService.GetTemplate.
+func (c *scoreServiceClient) GetTemplate(ctx context.Context, req *connect.Request[score.GetTemplateRequest]) (*connect.Response[score.GetTemplateResponse], error) {
+	return c.getTemplate.CallUnary(ctx, req)
+}
+
+// DeleteTemplate calls score.ScoreService.DeleteTemplate.
+func (c *scoreServiceClient) DeleteTemplate(ctx context.Context, req *connect.Request[score.DeleteTemplateRequest]) (*connect.Response[score.DeleteTemplateResponse], error) {
+	return c.deleteTemplate.CallUnary(ctx, req)
+}
+
 // ScoreServiceHandler is an implementation of the score.ScoreService service.
 type ScoreServiceHandler interface {
 	UploadScore(context.Context, *connect.Request[score.UploadScoreRequest]) (*connect.Response[score.UploadScoreResponse], error)
@@ -143,6 +427,24 @@ type ScoreServiceHandler interface {
 	TrimScoreWithProgress(context.Context, *connect.Request[score.TrimScoreRequest], *connect.ServerStream[score.TrimScoreProgressResponse]) error
 	SearchYoutubeVideos(context.Context, *connect.Request[score.SearchYoutubeVideosRequest]) (*connect.Response[score.SearchYoutubeVideosResponse], error)
 	GenerateScrollVideo(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.GenerateScrollVideoResponse], error)
+	ListScores(context.Context, *connect.Request[score.ListScoresRequest]) (*connect.Response[score.ListScoresResponse], error)
+	GetScore(context.Context, *connect.Request[score.GetScoreRequest]) (*connect.Response[score.GetScoreResponse], error)
+	DeleteScore(context.Context, *connect.Request[score.DeleteScoreRequest]) (*connect.Response[score.DeleteScoreResponse], error)
+	BeginUpload(context.Context, *connect.Request[score.BeginUploadRequest]) (*connect.Response[score.BeginUploadResponse], error)
+	AppendUploadChunk(context.Context, *connect.Request[score.AppendUploadChunkRequest]) (*connect.Response[score.AppendUploadChunkResponse], error)
+	GetUploadStatus(context.Context, *connect.Request[score.GetUploadStatusRequest]) (*connect.Response[score.GetUploadStatusResponse], error)
+	CommitUpload(context.Context, *connect.Request[score.CommitUploadRequest]) (*connect.Response[score.UploadScoreResponse], error)
+	SubmitTrimJob(context.Context, *connect.Request[score.TrimScoreRequest]) (*connect.Response[score.SubmitJobResponse], error)
+	SubmitVideoJob(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.SubmitJobResponse], error)
+	GetJob(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.JobStatus], error)
+	WatchJob(context.Context, *connect.Request[score.GetJobRequest], *connect.ServerStream[score.JobStatus]) error
+	GetJobResult(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.GetJobResultResponse], error)
+	DownloadJobResult(context.Context, *connect.Request[score.DownloadJobResultRequest], *connect.ServerStream[score.JobResultChunk]) error
+	BatchTrimScores(context.Context, *connect.Request[score.BatchTrimScoresRequest], *connect.ServerStream[score.BatchTrimScoresResponse]) error
+	SaveTemplate(context.Context, *connect.Request[score.SaveTemplateRequest]) (*connect.Response[score.TemplateInfo], error)
+	ListTemplates(context.Context, *connect.Request[score.ListTemplatesRequest]) (*connect.Response[score.ListTemplatesResponse], error)
+	GetTemplate(context.Context, *connect.Request[score.GetTemplateRequest]) (*connect.Response[score.GetTemplateResponse], error)
+	DeleteTemplate(context.Context, *connect.Request[score.DeleteTemplateRequest]) (*connect.Response[score.DeleteTemplateResponse], error)
 }
 
 // NewScoreServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -182,6 +484,114 @@ func NewScoreServiceHandler(svc ScoreServiceHandler, opts ...connect.HandlerOpti
 		connect.WithSchema(scoreServiceMethods.ByName("GenerateScrollVideo")),
 		connect.WithHandlerOptions(opts...),
 	)
+	scoreServiceListScoresHandler := connect.NewUnaryHandler(
+		ScoreServiceListScoresProcedure,
+		svc.ListScores,
+		connect.WithSchema(scoreServiceMethods.ByName("ListScores")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceGetScoreHandler := connect.NewUnaryHandler(
+		ScoreServiceGetScoreProcedure,
+		svc.GetScore,
+		connect.WithSchema(scoreServiceMethods.ByName("GetScore")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceDeleteScoreHandler := connect.NewUnaryHandler(
+		ScoreServiceDeleteScoreProcedure,
+		svc.DeleteScore,
+		connect.WithSchema(scoreServiceMethods.ByName("DeleteScore")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceBeginUploadHandler := connect.NewUnaryHandler(
+		ScoreServiceBeginUploadProcedure,
+		svc.BeginUpload,
+		connect.WithSchema(scoreServiceMethods.ByName("BeginUpload")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceAppendUploadChunkHandler := connect.NewUnaryHandler(
+		ScoreServiceAppendUploadChunkProcedure,
+		svc.AppendUploadChunk,
+		connect.WithSchema(scoreServiceMethods.ByName("AppendUploadChunk")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceGetUploadStatusHandler := connect.NewUnaryHandler(
+		ScoreServiceGetUploadStatusProcedure,
+		svc.GetUploadStatus,
+		connect.WithSchema(scoreServiceMethods.ByName("GetUploadStatus")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceCommitUploadHandler := connect.NewUnaryHandler(
+		ScoreServiceCommitUploadProcedure,
+		svc.CommitUpload,
+		connect.WithSchema(scoreServiceMethods.ByName("CommitUpload")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceSubmitTrimJobHandler := connect.NewUnaryHandler(
+		ScoreServiceSubmitTrimJobProcedure,
+		svc.SubmitTrimJob,
+		connect.WithSchema(scoreServiceMethods.ByName("SubmitTrimJob")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceSubmitVideoJobHandler := connect.NewUnaryHandler(
+		ScoreServiceSubmitVideoJobProcedure,
+		svc.SubmitVideoJob,
+		connect.WithSchema(scoreServiceMethods.ByName("SubmitVideoJob")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceGetJobHandler := connect.NewUnaryHandler(
+		ScoreServiceGetJobProcedure,
+		svc.GetJob,
+		connect.WithSchema(scoreServiceMethods.ByName("GetJob")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceWatchJobHandler := connect.NewServerStreamHandler(
+		ScoreServiceWatchJobProcedure,
+		svc.WatchJob,
+		connect.WithSchema(scoreServiceMethods.ByName("WatchJob")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceGetJobResultHandler := connect.NewUnaryHandler(
+		ScoreServiceGetJobResultProcedure,
+		svc.GetJobResult,
+		connect.WithSchema(scoreServiceMethods.ByName("GetJobResult")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceDownloadJobResultHandler := connect.NewServerStreamHandler(
+		ScoreServiceDownloadJobResultProcedure,
+		svc.DownloadJobResult,
+		connect.WithSchema(scoreServiceMethods.ByName("DownloadJobResult")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceBatchTrimScoresHandler := connect.NewServerStreamHandler(
+		ScoreServiceBatchTrimScoresProcedure,
+		svc.BatchTrimScores,
+		connect.WithSchema(scoreServiceMethods.ByName("BatchTrimScores")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceSaveTemplateHandler := connect.NewUnaryHandler(
+		ScoreServiceSaveTemplateProcedure,
+		svc.SaveTemplate,
+		connect.WithSchema(scoreServiceMethods.ByName("SaveTemplate")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceListTemplatesHandler := connect.NewUnaryHandler(
+		ScoreServiceListTemplatesProcedure,
+		svc.ListTemplates,
+		connect.WithSchema(scoreServiceMethods.ByName("ListTemplates")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceGetTemplateHandler := connect.NewUnaryHandler(
+		ScoreServiceGetTemplateProcedure,
+		svc.GetTemplate,
+		connect.WithSchema(scoreServiceMethods.ByName("GetTemplate")),
+		connect.WithHandlerOptions(opts...),
+	)
+	scoreServiceDeleteTemplateHandler := connect.NewUnaryHandler(
+		ScoreServiceDeleteTemplateProcedure,
+		svc.DeleteTemplate,
+		connect.WithSchema(scoreServiceMethods.ByName("DeleteTemplate")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/score.ScoreService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ScoreServiceUploadScoreProcedure:
@@ -194,6 +604,42 @@ func NewScoreServiceHandler(svc ScoreServiceHandler, opts ...connect.HandlerOpti
 			scoreServiceSearchYoutubeVideosHandler.ServeHTTP(w, r)
 		case ScoreServiceGenerateScrollVideoProcedure:
 			scoreServiceGenerateScrollVideoHandler.ServeHTTP(w, r)
+		case ScoreServiceListScoresProcedure:
+			scoreServiceListScoresHandler.ServeHTTP(w, r)
+		case ScoreServiceGetScoreProcedure:
+			scoreServiceGetScoreHandler.ServeHTTP(w, r)
+		case ScoreServiceDeleteScoreProcedure:
+			scoreServiceDeleteScoreHandler.ServeHTTP(w, r)
+		case ScoreServiceBeginUploadProcedure:
+			scoreServiceBeginUploadHandler.ServeHTTP(w, r)
+		case ScoreServiceAppendUploadChunkProcedure:
+			scoreServiceAppendUploadChunkHandler.ServeHTTP(w, r)
+		case ScoreServiceGetUploadStatusProcedure:
+			scoreServiceGetUploadStatusHandler.ServeHTTP(w, r)
+		case ScoreServiceCommitUploadProcedure:
+			scoreServiceCommitUploadHandler.ServeHTTP(w, r)
+		case ScoreServiceSubmitTrimJobProcedure:
+			scoreServiceSubmitTrimJobHandler.ServeHTTP(w, r)
+		case ScoreServiceSubmitVideoJobProcedure:
+			scoreServiceSubmitVideoJobHandler.ServeHTTP(w, r)
+		case ScoreServiceGetJobProcedure:
+			scoreServiceGetJobHandler.ServeHTTP(w, r)
+		case ScoreServiceWatchJobProcedure:
+			scoreServiceWatchJobHandler.ServeHTTP(w, r)
+		case ScoreServiceGetJobResultProcedure:
+			scoreServiceGetJobResultHandler.ServeHTTP(w, r)
+		case ScoreServiceDownloadJobResultProcedure:
+			scoreServiceDownloadJobResultHandler.ServeHTTP(w, r)
+		case ScoreServiceBatchTrimScoresProcedure:
+			scoreServiceBatchTrimScoresHandler.ServeHTTP(w, r)
+		case ScoreServiceSaveTemplateProcedure:
+			scoreServiceSaveTemplateHandler.ServeHTTP(w, r)
+		case ScoreServiceListTemplatesProcedure:
+			scoreServiceListTemplatesHandler.ServeHTTP(w, r)
+		case ScoreServiceGetTemplateProcedure:
+			scoreServiceGetTemplateHandler.ServeHTTP(w, r)
+		case ScoreServiceDeleteTemplateProcedure:
+			scoreServiceDeleteTemplateHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -222,3 +668,75 @@ func (UnimplementedScoreServiceHandler) SearchYoutubeVideos(context.Context, *co
 func (UnimplementedScoreServiceHandler) GenerateScrollVideo(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.GenerateScrollVideoResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GenerateScrollVideo is not implemented"))
 }
+
+func (UnimplementedScoreServiceHandler) ListScores(context.Context, *connect.Request[score.ListScoresRequest]) (*connect.Response[score.ListScoresResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.ListScores is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) GetScore(context.Context, *connect.Request[score.GetScoreRequest]) (*connect.Response[score.GetScoreResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GetScore is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) DeleteScore(context.Context, *connect.Request[score.DeleteScoreRequest]) (*connect.Response[score.DeleteScoreResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.DeleteScore is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) BeginUpload(context.Context, *connect.Request[score.BeginUploadRequest]) (*connect.Response[score.BeginUploadResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.BeginUpload is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) AppendUploadChunk(context.Context, *connect.Request[score.AppendUploadChunkRequest]) (*connect.Response[score.AppendUploadChunkResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.AppendUploadChunk is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) GetUploadStatus(context.Context, *connect.Request[score.GetUploadStatusRequest]) (*connect.Response[score.GetUploadStatusResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GetUploadStatus is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) CommitUpload(context.Context, *connect.Request[score.CommitUploadRequest]) (*connect.Response[score.UploadScoreResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.CommitUpload is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) SubmitTrimJob(context.Context, *connect.Request[score.TrimScoreRequest]) (*connect.Response[score.SubmitJobResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.SubmitTrimJob is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) SubmitVideoJob(context.Context, *connect.Request[score.GenerateScrollVideoRequest]) (*connect.Response[score.SubmitJobResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.SubmitVideoJob is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) GetJob(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.JobStatus], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GetJob is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) WatchJob(context.Context, *connect.Request[score.GetJobRequest], *connect.ServerStream[score.JobStatus]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.WatchJob is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) GetJobResult(context.Context, *connect.Request[score.GetJobRequest]) (*connect.Response[score.GetJobResultResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GetJobResult is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) DownloadJobResult(context.Context, *connect.Request[score.DownloadJobResultRequest], *connect.ServerStream[score.JobResultChunk]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.DownloadJobResult is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) BatchTrimScores(context.Context, *connect.Request[score.BatchTrimScoresRequest], *connect.ServerStream[score.BatchTrimScoresResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.BatchTrimScores is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) SaveTemplate(context.Context, *connect.Request[score.SaveTemplateRequest]) (*connect.Response[score.TemplateInfo], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.SaveTemplate is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) ListTemplates(context.Context, *connect.Request[score.ListTemplatesRequest]) (*connect.Response[score.ListTemplatesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.ListTemplates is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) GetTemplate(context.Context, *connect.Request[score.GetTemplateRequest]) (*connect.Response[score.GetTemplateResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.GetTemplate is not implemented"))
+}
+
+func (UnimplementedScoreServiceHandler) DeleteTemplate(context.Context, *connect.Request[score.DeleteTemplateRequest]) (*connect.Response[score.DeleteTemplateResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("score.ScoreService.DeleteTemplate is not implemented"))
+}
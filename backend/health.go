@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// healthCheckTTL は外部ツールと保存先を調べた結果を使い回す時間です。
+	// オーケストレーターは数秒おきに問い合わせるため、毎回コマンドを実行したり書き込んだりしないようにします。
+	healthCheckTTL = 10 * time.Second
+	// healthCommandTimeout はバージョンを調べるコマンドの実行時間の上限です
+	healthCommandTimeout = 5 * time.Second
+	// healthRefreshTimeout は外部ツールと保存先を調べ直す時間の上限です。
+	// 保存先が応答しなくても確認を終わらせ、書き込めない状態として報告します。
+	healthRefreshTimeout = healthCommandTimeout
+	// healthProbePrefix は保存先に書き込めるかを調べるためのキーの接頭辞です。
+	// テナント名は英数字で始まるため、テナントのデータと重なりません。
+	healthProbePrefix = "_health/"
+)
+
+// healthTool はヘルスチェックで調べる外部ツールです
+type healthTool struct {
+	name string
+	// commands は探すコマンド名です。最初に見つかったものを使います。
+	commands []string
+	// versionArgs はバージョンを表示させる引数です
+	versionArgs []string
+	// required はこのツールが無いとサーバーが処理を受け付けられないかどうかです
+	required bool
+}
+
+// healthTools は調べる外部ツールです。PDFの変換に使うツールは rasterizer と同じ名前にし、
+// 使っている変換方法のツールだけを必須とします。
+var healthTools = []healthTool{
+	{name: "ffmpeg", commands: []string{"ffmpeg"}, versionArgs: []string{"-version"}, required: true},
+	// 伴奏の長さに動画を合わせる場合だけ使うため、必須にはしません
+	{name: "ffprobe", commands: []string{"ffprobe"}, versionArgs: []string{"-version"}},
+	{name: rasterizerPdftoppm, commands: []string{"pdftoppm"}, versionArgs: []string{"-v"}},
+	{name: rasterizerImageMagick, commands: []string{"magick", "convert"}, versionArgs: []string{"-version"}},
+}
+
+// dependencyStatus は外部ツールの状態です
+type dependencyStatus struct {
+	Available bool   `json:"available"`
+	Required  bool   `json:"required"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// storageStatus は保存先の状態です
+type storageStatus struct {
+	Backend  string `json:"backend"`
+	Writable bool   `json:"writable"`
+	Error    string `json:"error,omitempty"`
+}
+
+// queueStatus は処理の種類ごとの実行数と待ち数です
+type queueStatus struct {
+	Running int `json:"running"`
+	Waiting int `json:"waiting"`
+	Limit   int `json:"limit"`
+	// Jobs は未完了の非同期ジョブの数です（実行中を含む）
+	Jobs int `json:"jobs"`
+}
+
+// healthReport は /health と /ready が返す内容です
+type healthReport struct {
+	// Status は必須の依存が揃っていれば ok、そうでなければ degraded です
+	Status       string                      `json:"status"`
+	Service      string                      `json:"service"`
+	Rasterizer   string                      `json:"rasterizer"`
+	Rasterizers  []string                    `json:"rasterizers"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+	Storage      storageStatus               `json:"storage"`
+	Queues       map[jobKind]queueStatus     `json:"queues"`
+	// Problems は処理を受け付けられない理由です
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ready は必須の依存が全て揃っているかどうかを返します
+func (r *healthReport) ready() bool {
+	return len(r.Problems) == 0
+}
+
+// healthChecker は外部ツール・保存先・キューの状態を調べます
+type healthChecker struct {
+	storage    blobStorage
+	admission  *admissionController
+	jobs       *jobManager
+	rasterizer pageRasterizer
+	// refreshTimeout は1回の確認にかけられる時間です
+	refreshTimeout time.Duration
+
+	mu           sync.Mutex
+	checkedAt    time.Time
+	dependencies map[string]dependencyStatus
+	storageState storageStatus
+	// refreshing は実行中の確認が終わると閉じられます。確認していない間はnilです。
+	refreshing chan struct{}
+}
+
+func newHealthChecker(storage blobStorage, admission *admissionController, jobs *jobManager, rasterizer pageRasterizer) *healthChecker {
+	return &healthChecker{
+		storage:        storage,
+		admission:      admission,
+		jobs:           jobs,
+		rasterizer:     rasterizer,
+		refreshTimeout: healthRefreshTimeout,
+	}
+}
+
+// report は現在の状態を返します。外部ツールと保存先は healthCheckTTL の間は前回の結果を使います。
+func (h *healthChecker) report(ctx context.Context) *healthReport {
+	dependencies, storage := h.cachedChecks(ctx)
+
+	report := &healthReport{
+		Status:       "ok",
+		Service:      "score-splitter-backend",
+		Rasterizer:   h.rasterizer.Name(),
+		Rasterizers:  availableRasterizers(),
+		Dependencies: dependencies,
+		Storage:      storage,
+		Queues:       make(map[jobKind]queueStatus),
+	}
+	pending := h.jobs.pending()
+	for _, kind := range []jobKind{jobKindTrim, jobKindVideo} {
+		running, waiting := h.admission.stats(kind)
+		report.Queues[kind] = queueStatus{
+			Running: running,
+			Waiting: waiting,
+			Limit:   h.admission.limits[kind].global,
+			Jobs:    pending[kind],
+		}
+	}
+
+	for _, tool := range healthTools {
+		if d := dependencies[tool.name]; d.Required && !d.Available {
+			report.Problems = append(report.Problems, tool.name+"が見つかりません")
+		}
+	}
+	if !h.rasterizer.Available() {
+		report.Problems = append(report.Problems, "PDFを画像に変換する方法"+h.rasterizer.Name()+"が使えません")
+	}
+	if !storage.Writable {
+		report.Problems = append(report.Problems, "保存先に書き込めません")
+	}
+	if !report.ready() {
+		report.Status = "degraded"
+	}
+	return report
+}
+
+// cachedChecks は外部ツールと保存先を調べた結果を返します。
+// 結果が古ければ裏で調べ直し、終わるまでは前回の結果を返します。保存先が応答しなくても問い合わせを待たせません。
+// まだ一度も調べていない場合だけ、最初の結果が出るかctxが終わるまで待ちます。
+func (h *healthChecker) cachedChecks(ctx context.Context) (map[string]dependencyStatus, storageStatus) {
+	h.mu.Lock()
+	if time.Since(h.checkedAt) > healthCheckTTL && h.refreshing == nil {
+		h.refreshing = make(chan struct{})
+		// 問い合わせが途中で切れても失敗として使い回さないよう、リクエストのキャンセルは引き継ぎません
+		go h.refresh(context.WithoutCancel(ctx), h.refreshing)
+	}
+	refreshing, checked := h.refreshing, !h.checkedAt.IsZero()
+	h.mu.Unlock()
+
+	if !checked {
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return map[string]dependencyStatus{}, storageStatus{Backend: h.storage.Describe(), Error: "確認中です"}
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dependencies, h.storageState
+}
+
+// refresh は外部ツールと保存先を調べて結果を置き換え、doneを閉じます。
+// 調べ終わるまで次の確認を始めないため、refreshTimeoutで必ず終わらせます。
+func (h *healthChecker) refresh(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, h.refreshTimeout)
+	defer cancel()
+	dependencies := h.checkDependencies(ctx)
+	storage := h.checkStorage(ctx)
+
+	h.mu.Lock()
+	h.dependencies, h.storageState = dependencies, storage
+	h.checkedAt = time.Now()
+	h.refreshing = nil
+	h.mu.Unlock()
+	close(done)
+}
+
+// checkDependencies は外部ツールの有無とバージョンを調べます
+func (h *healthChecker) checkDependencies(ctx context.Context) map[string]dependencyStatus {
+	statuses := make(map[string]dependencyStatus, len(healthTools))
+	for _, tool := range healthTools {
+		status := dependencyStatus{Required: tool.required || tool.name == h.rasterizer.Name()}
+		path, err := lookPathAny(tool.commands...)
+		if err != nil {
+			status.Error = strings.Join(tool.commands, "/") + "が見つかりません"
+			statuses[tool.name] = status
+			continue
+		}
+		status.Available = true
+		status.Path = path
+		version, err := commandVersion(ctx, path, tool.versionArgs...)
+		if err != nil {
+			status.Error = err.Error()
+		}
+		status.Version = version
+		statuses[tool.name] = status
+	}
+	return statuses
+}
+
+// checkStorage は保存先に書き込んで削除できるかを調べます
+func (h *healthChecker) checkStorage(ctx context.Context) storageStatus {
+	status := storageStatus{Backend: h.storage.Describe()}
+	id, err := newObjectID()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	key := healthProbePrefix + id
+	if err := h.storage.Put(ctx, key, []byte("ok")); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if err := h.storage.Delete(ctx, key); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Writable = true
+	return status
+}
+
+// commandVersion はコマンドのバージョン表示の最初の空でない行を返します
+func commandVersion(ctx context.Context, path string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCommandTimeout)
+	defer cancel()
+	// pdftoppm -v は標準エラーに表示し、終了コードも0とは限らないため、出力があればエラーにしません
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("バージョンを表示しませんでした")
+	}
+	return "", err
+}
+
+// serveHealth は常に200で状態を返します（プロセスが動いているかの確認用）
+func (h *healthChecker) serveHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, http.StatusOK, h.report(r.Context()))
+}
+
+// serveReady は必須の依存が揃っていなければ503を返します（リクエストを振り分けてよいかの確認用）
+func (h *healthChecker) serveReady(w http.ResponseWriter, r *http.Request) {
+	report := h.report(r.Context())
+	status := http.StatusOK
+	if !report.ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealthReport(w, status, report)
+}
+
+func writeHealthReport(w http.ResponseWriter, status int, report *healthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
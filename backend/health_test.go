@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingStorage は unblock が閉じられるまで Put を止める保存先です
+type blockingStorage struct {
+	blobStorage
+	puts    atomic.Int32
+	unblock chan struct{}
+}
+
+func (s *blockingStorage) Put(ctx context.Context, key string, data []byte) error {
+	s.puts.Add(1)
+	select {
+	case <-s.unblock:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.blobStorage.Put(ctx, key, data)
+}
+
+func TestHealthReportDoesNotWaitForRefresh(t *testing.T) {
+	storage := &blockingStorage{blobStorage: newFSStorage(t.TempDir()), unblock: make(chan struct{})}
+	jobs := newTestJobManager(t, t.TempDir(), nil)
+	defer jobs.shutdown(context.Background())
+	h := newHealthChecker(storage, newAdmissionController(defaultConfig()), jobs, builtinRasterizer{})
+
+	// 一度も調べていなければ最初の結果を待つが、問い合わせが終わればそこで返す
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if report := h.report(ctx); report.Storage.Writable {
+		t.Error("storage reported writable before the first check finished")
+	}
+
+	close(storage.unblock)
+	if report := h.report(context.Background()); !report.Storage.Writable {
+		t.Fatalf("storage = %+v, want writable", report.Storage)
+	}
+
+	// 結果が古くなっても、調べ直している間は前回の結果をすぐに返す
+	storage.unblock = make(chan struct{})
+	h.mu.Lock()
+	h.checkedAt = time.Now().Add(-2 * healthCheckTTL)
+	h.mu.Unlock()
+	before := storage.puts.Load()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 5 {
+			if report := h.report(context.Background()); !report.Storage.Writable {
+				t.Errorf("cached storage = %+v, want writable", report.Storage)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("report waited for a blocked storage check")
+	}
+
+	// 調べ直しは1つずつ行う
+	deadline := time.Now().Add(5 * time.Second)
+	for storage.puts.Load() == before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	h.mu.Lock()
+	refreshing := h.refreshing
+	h.mu.Unlock()
+	close(storage.unblock)
+	if refreshing != nil {
+		<-refreshing
+	}
+	if puts := storage.puts.Load() - before; puts != 1 {
+		t.Errorf("storage checked %d times while refreshing, want 1", puts)
+	}
+}
+
+func TestHealthReportDegradesWhenStorageHangs(t *testing.T) {
+	storage := &blockingStorage{blobStorage: newFSStorage(t.TempDir()), unblock: make(chan struct{})}
+	jobs := newTestJobManager(t, t.TempDir(), nil)
+	defer jobs.shutdown(context.Background())
+	h := newHealthChecker(storage, newAdmissionController(defaultConfig()), jobs, builtinRasterizer{})
+	h.refreshTimeout = 50 * time.Millisecond
+
+	// 書き込めた後に保存先が応答しなくなった場合
+	close(storage.unblock)
+	if report := h.report(context.Background()); !report.Storage.Writable {
+		t.Fatalf("storage = %+v, want writable", report.Storage)
+	}
+	storage.unblock = make(chan struct{})
+	h.mu.Lock()
+	h.checkedAt = time.Now().Add(-2 * healthCheckTTL)
+	h.mu.Unlock()
+	h.report(context.Background())
+
+	// 調べ直しが時間内に終わらなければ、前回の結果を使い続けずに書き込めない状態にする
+	deadline := time.After(5 * time.Second)
+	for {
+		report := h.report(context.Background())
+		if !report.Storage.Writable {
+			if report.Status != "degraded" || report.ready() {
+				t.Errorf("report = %s with problems %v, want degraded", report.Status, report.Problems)
+			}
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("report still writable while the storage does not respond")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
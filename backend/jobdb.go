@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ジョブの状態を保存するバケットです。再起動後に再実行するためのリクエストはPDFなどを含んで大きいため、
+// BoltDBには入れずにscoreStoreに保存します（storeKindJobInputs）。BoltDBは解放した領域をOSに返さないためです。
+var jobsBucket = []byte("jobs")
+
+// jobDB はジョブをローカルのBoltDBファイルに記録します。
+// ファイルはプロセス間で共有できないため、レプリカごとに別のパスを指定してください。
+type jobDB struct {
+	db *bolt.DB
+}
+
+func openJobDB(path string) (*jobDB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("ジョブの記録ファイル%sを開けません: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &jobDB{db: db}, nil
+}
+
+func (d *jobDB) close() error {
+	return d.db.Close()
+}
+
+// save はジョブの状態を記録します
+func (d *jobDB) save(j *job) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(j.ID), data)
+	})
+}
+
+func (d *jobDB) delete(id string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete([]byte(id))
+	})
+}
+
+// all は記録されている全てのジョブを返します
+func (d *jobDB) all() ([]*job, error) {
+	var jobs []*job
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var j job
+			if err := json.Unmarshal(v, &j); err != nil {
+				return fmt.Errorf("ジョブ%sの記録が壊れています: %w", k, err)
+			}
+			jobs = append(jobs, &j)
+			return nil
+		})
+	})
+	return jobs, err
+}
@@ -0,0 +1,862 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	score "score-splitter/backend/gen/go"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+)
+
+// ジョブの結果は <テナント>/results/ に保存します。
+// 結果はresultTTLで自動的に削除されるため、テナントの容量には数えず、同じ内容でもまとめません。
+const storeKindResults = "results"
+
+// ジョブのリクエストは <テナント>/job-inputs/ に保存し、jobDBにはそのIDだけを記録します。
+// PDFや伴奏を含むため、ジョブが終了したら削除します。
+const storeKindJobInputs = "job-inputs"
+
+// errShuttingDown はサーバーの停止中にジョブを受け付けないことを表します
+var errShuttingDown = errors.New("サーバーを停止しています。しばらくしてから再試行してください")
+
+// jobState はジョブの状態です
+type jobState string
+
+const (
+	jobQueued    jobState = "queued"
+	jobRunning   jobState = "running"
+	jobSucceeded jobState = "succeeded"
+	jobFailed    jobState = "failed"
+)
+
+func (s jobState) finished() bool {
+	return s == jobSucceeded || s == jobFailed
+}
+
+// job は非同期ジョブの状態です。jobDBにJSONで記録します。
+type job struct {
+	ID         string    `json:"id"`
+	Kind       jobKind   `json:"kind"`
+	Tenant     string    `json:"tenant"`
+	Client     string    `json:"client"`
+	Lang       string    `json:"lang,omitempty"`
+	State      jobState  `json:"state"`
+	Stage      string    `json:"stage"`
+	Progress   int32     `json:"progress"`
+	Message    string    `json:"message"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	// InputID は保存したリクエストのIDです
+	InputID string `json:"input_id,omitempty"`
+
+	// 完了したジョブの結果
+	ResultID    string `json:"result_id,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// jobResult はジョブが生成したファイルです
+type jobResult struct {
+	data []byte
+	// file は大きな結果を書いた一時ファイルです。指定した場合はdataの代わりにファイルから少しずつ保存し、
+	// 保存した後に削除します。
+	file        string
+	filename    string
+	contentType string
+}
+
+// jobFunc はジョブの処理本体です。sendで進捗を通知します。
+type jobFunc func(ctx context.Context, send progressSender) (*jobResult, error)
+
+// jobBuilder は記録したリクエストからジョブの処理本体を組み立てます。
+// 受け付ける時はリクエストの検証だけに使い、処理本体は実行枠を確保してから保存したリクエストで組み立て直します。
+// 再起動後にも同じ処理を組み立てられるよう、処理に必要な情報は全てリクエストとjobに含めます。
+type jobBuilder func(j *job, request []byte) (jobFunc, error)
+
+// jobManager は重い処理を非同期ジョブとして実行します。
+// 実行枠はRPCと同じadmissionControllerから確保するため、同期と非同期を合わせて同時実行数が制限されます。
+// ジョブはjobDBに記録し、再起動時に未完了のものを再開します。
+type jobManager struct {
+	store           *scoreStore
+	admission       *admissionController
+	db              *jobDB
+	build           jobBuilder
+	resultTTL       time.Duration
+	maxQueued       int
+	maxQueuedClient int
+
+	// ctx は実行中のジョブに渡す親のcontextです。shutdownで待ちきれなかった場合にキャンセルします。
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopping はshutdownの開始時にキャンセルされ、順番待ちのジョブの開始と期限切れの削除を止めます
+	stopping context.Context
+	stop     context.CancelFunc
+	// wg はジョブと期限切れの削除のgoroutineを数えます
+	wg sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*job
+	// changed はジョブの状態が変わるたびにcloseされ、新しいチャネルに差し替えられます
+	changed chan struct{}
+}
+
+func newJobManager(store *scoreStore, admission *admissionController, cfg *serverConfig, build jobBuilder) (*jobManager, error) {
+	db, err := openJobDB(cfg.JobDBPath)
+	if err != nil {
+		return nil, err
+	}
+	m := &jobManager{
+		store:           store,
+		admission:       admission,
+		db:              db,
+		build:           build,
+		resultTTL:       cfg.JobResultTTL,
+		maxQueued:       cfg.MaxQueuedJobs,
+		maxQueuedClient: cfg.MaxQueuedJobsPerClient,
+		jobs:            make(map[string]*job),
+		changed:         make(chan struct{}),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.stopping, m.stop = context.WithCancel(context.Background())
+	if err := m.recover(); err != nil {
+		m.stop()
+		m.cancel()
+		m.wg.Wait()
+		db.close()
+		return nil, err
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.expireLoop()
+	}()
+	return m, nil
+}
+
+// shutdown は新しいジョブの受け付けと順番待ちのジョブの開始を止め、実行中のジョブの終了を待ちます。
+// ctxが終わるまでに終わらなかったジョブはキャンセルします。終わらなかったジョブと順番待ちのジョブは
+// 未完了のまま記録に残り、次の起動時に再開します。
+func (m *jobManager) shutdown(ctx context.Context) error {
+	// submitは停止中でないことをm.muを持ったまま確かめてからwgを増やすので、これ以降は増えない
+	m.mu.Lock()
+	m.stop()
+	m.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("jobs: shutdown timed out; interrupting running jobs")
+		m.cancel()
+		<-done
+	}
+	m.cancel()
+	return m.db.close()
+}
+
+// recover は記録されているジョブを読み込み、停止時に未完了だったジョブを再開します。
+// リクエストの記録が無いなど再開できないジョブは失敗として記録します。
+func (m *jobManager) recover() error {
+	jobs, err := m.db.all()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	var resumed []string
+	var failed []*job
+
+	// 再開するジョブはm.jobsを全て埋めてから開始する
+	m.mu.Lock()
+	for _, j := range jobs {
+		m.jobs[j.ID] = j
+		if j.State.finished() {
+			continue
+		}
+
+		// リクエストは実行する時に読み込むので、ここでは残っていることだけを確かめる
+		if err := m.checkInput(j); err != nil {
+			j.State = jobFailed
+			j.Stage = "failed"
+			j.Message = "処理に失敗しました"
+			j.Error = fmt.Sprintf("サーバーの再起動により中断されました: %v", err)
+			j.UpdatedAt = now
+			j.FinishedAt = now
+			m.persist(j)
+			failed = append(failed, j)
+			continue
+		}
+		j.State = jobQueued
+		j.Stage = "queued"
+		j.Progress = 0
+		j.Message = "サーバーの再起動後に処理を再開します..."
+		j.UpdatedAt = now
+		m.persist(j)
+		resumed = append(resumed, j.ID)
+	}
+	m.mu.Unlock()
+	for _, id := range resumed {
+		m.start(id)
+	}
+
+	for _, j := range failed {
+		m.deleteInput(context.Background(), j)
+	}
+	if len(jobs) > 0 {
+		log.Printf("jobs: loaded %d job(s), resumed %d", len(jobs), len(resumed))
+	}
+	m.expire(now)
+	return nil
+}
+
+// checkInput はジョブのリクエストが保存されていることを確かめます
+func (m *jobManager) checkInput(j *job) error {
+	if j.InputID == "" {
+		return errors.New("リクエストの記録がありません")
+	}
+	_, err := m.store.stat(context.Background(), j.Tenant, storeKindJobInputs, j.InputID)
+	if errors.Is(err, errObjectNotFound) {
+		return errors.New("リクエストの記録がありません")
+	}
+	return err
+}
+
+// rebuild は保存したリクエストを読み込み、ジョブの処理本体を組み立てます
+func (m *jobManager) rebuild(ctx context.Context, j *job) (jobFunc, error) {
+	if err := m.checkInput(j); err != nil {
+		return nil, err
+	}
+	_, request, err := m.store.get(ctx, j.Tenant, storeKindJobInputs, j.InputID)
+	if errors.Is(err, errObjectNotFound) {
+		return nil, errors.New("リクエストの記録がありません")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.build(j, request)
+}
+
+// deleteInput は終了したジョブのリクエストを削除します
+func (m *jobManager) deleteInput(ctx context.Context, j *job) {
+	if j.InputID == "" {
+		return
+	}
+	_, err := m.store.delete(ctx, j.Tenant, storeKindJobInputs, j.InputID)
+	if err != nil && !errors.Is(err, errObjectNotFound) {
+		log.Printf("job %s: failed to delete request: %v", j.ID, err)
+	}
+}
+
+// submit はジョブを受け付けて記録し、バックグラウンドで実行を開始します
+func (m *jobManager) submit(ctx context.Context, tenant, client, lang string, kind jobKind, request proto.Message) (*job, error) {
+	id, err := newObjectID()
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	j := &job{
+		ID:        id,
+		Kind:      kind,
+		Tenant:    tenant,
+		Client:    client,
+		Lang:      lang,
+		State:     jobQueued,
+		Stage:     "queued",
+		Message:   "処理の順番を待っています...",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// ここでは検証だけを行い、処理本体は順番を待つ間にリクエストを持ち続けないよう実行する時に組み立てる
+	if _, err := m.build(j, data); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if m.stopping.Err() != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, errShuttingDown)
+	}
+	// リクエストの保存はm.muの外で行い、受け付けなかった場合は削除する
+	input, err := m.store.putUnmetered(ctx, tenant, storeKindJobInputs, string(kind), data)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("ジョブのリクエストを保存できません: %w", err))
+	}
+	j.InputID = input.ID
+
+	m.mu.Lock()
+	if err := m.admitLocked(j); err != nil {
+		m.mu.Unlock()
+		m.deleteInput(ctx, j)
+		return nil, err
+	}
+	m.jobs[id] = j
+	snapshot := *j
+	// shutdownのwg.Waitと競合しないよう、m.muを持ったままgoroutineを数える
+	m.start(id)
+	m.notifyLocked()
+	m.mu.Unlock()
+
+	return &snapshot, nil
+}
+
+// admitLocked は未完了のジョブの数を確認してからジョブを記録します。m.muを持って呼び出します。
+func (m *jobManager) admitLocked(j *job) error {
+	if m.stopping.Err() != nil {
+		return connect.NewError(connect.CodeUnavailable, errShuttingDown)
+	}
+	pending, pendingClient := 0, 0
+	for _, other := range m.jobs {
+		if other.State.finished() {
+			continue
+		}
+		pending++
+		if other.Client == j.Client {
+			pendingClient++
+		}
+	}
+	if pending >= m.maxQueued {
+		return m.admission.rejectError(j.Kind, "未完了のジョブが多すぎます")
+	}
+	if pendingClient >= m.maxQueuedClient {
+		return m.admission.rejectError(j.Kind, "このクライアントの未完了のジョブが多すぎます")
+	}
+	if err := m.db.save(j); err != nil {
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("ジョブを記録できません: %w", err))
+	}
+	return nil
+}
+
+// start はジョブをバックグラウンドで実行します
+func (m *jobManager) start(id string) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(id)
+	}()
+}
+
+// run は実行枠を確保してから保存したリクエストを読み込み、ジョブを実行します
+func (m *jobManager) run(id string) {
+	m.mu.Lock()
+	j := *m.jobs[id]
+	kind, client, tenant := j.Kind, j.Client, j.Tenant
+	m.mu.Unlock()
+
+	// 順番待ちの間にshutdownが始まった場合は開始せず、次の起動時に再開する
+	waitCtx, cancelWait := context.WithCancel(m.ctx)
+	stopWaiting := context.AfterFunc(m.stopping, cancelWait)
+	release, err := m.admission.wait(waitCtx, kind, client)
+	stopWaiting()
+	cancelWait()
+	if err != nil {
+		if m.stopping.Err() != nil {
+			log.Printf("job %s: not started because the server is shutting down", id)
+			return
+		}
+		m.fail(id, err)
+		m.deleteInput(context.Background(), &j)
+		return
+	}
+	defer release()
+	if m.stopping.Err() != nil {
+		log.Printf("job %s: not started because the server is shutting down", id)
+		return
+	}
+	ctx := m.ctx
+
+	run, err := m.rebuild(ctx, &j)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Printf("job %s: interrupted by shutdown: %v", id, err)
+			return
+		}
+		m.fail(id, err)
+		m.deleteInput(context.Background(), &j)
+		return
+	}
+	m.update(id, true, func(j *job) {
+		j.State = jobRunning
+		j.Stage = "processing"
+		j.Message = "処理を開始しました"
+	})
+
+	result, err := run(ctx, func(p *score.TrimScoreProgressResponse) error {
+		// 進捗は頻繁に変わるので記録せず、再起動後は最初からやり直す
+		m.update(id, false, func(j *job) {
+			j.Stage = p.GetStage()
+			j.Progress = p.GetProgress()
+			j.Message = p.GetMessage()
+		})
+		return ctx.Err()
+	})
+	if err != nil && ctx.Err() != nil {
+		// 停止のために中断したジョブは失敗にせず、次の起動時に最初からやり直す
+		log.Printf("job %s: interrupted by shutdown: %v", id, err)
+		return
+	}
+	// 成功しても失敗しても、終了したジョブのリクエストは不要になる
+	defer m.deleteInput(context.Background(), &j)
+	if err != nil {
+		m.fail(id, err)
+		return
+	}
+
+	obj, err := m.saveResult(ctx, tenant, result)
+	if err != nil {
+		m.fail(id, fmt.Errorf("結果の保存に失敗しました: %w", err))
+		return
+	}
+	m.update(id, true, func(j *job) {
+		j.State = jobSucceeded
+		j.Stage = "complete"
+		j.Progress = 100
+		j.Message = "完了しました"
+		j.ResultID = obj.ID
+		j.Filename = result.filename
+		j.ContentType = result.contentType
+		j.FinishedAt = j.UpdatedAt
+	})
+}
+
+// saveResult はジョブの結果を保存します。一時ファイルの結果はメモリに読み込まずに保存し、その後で削除します。
+func (m *jobManager) saveResult(ctx context.Context, tenant string, result *jobResult) (*storedObject, error) {
+	if result.file == "" {
+		return m.store.putUnmetered(ctx, tenant, storeKindResults, result.filename, result.data)
+	}
+	defer os.Remove(result.file)
+	f, err := os.Open(result.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return m.store.putUnmeteredStream(ctx, tenant, storeKindResults, result.filename, info.Size(), f)
+}
+
+func (m *jobManager) fail(id string, err error) {
+	log.Printf("job %s failed: %v", id, err)
+	m.update(id, true, func(j *job) {
+		j.State = jobFailed
+		j.Stage = "failed"
+		j.Message = "処理に失敗しました"
+		j.Error = err.Error()
+		j.FinishedAt = j.UpdatedAt
+	})
+}
+
+// update はジョブの状態を変更します。persistがtrueの場合はjobDBにも記録します。
+func (m *jobManager) update(id string, persist bool, fn func(*job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	j.UpdatedAt = time.Now().UTC()
+	fn(j)
+	if persist {
+		m.persist(j)
+	}
+	m.notifyLocked()
+}
+
+func (m *jobManager) persist(j *job) {
+	if err := m.db.save(j); err != nil {
+		log.Printf("job %s: failed to record state: %v", j.ID, err)
+	}
+}
+
+func (m *jobManager) notifyLocked() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// pending は処理の種類ごとの未完了のジョブの数を返します
+func (m *jobManager) pending() map[jobKind]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[jobKind]int)
+	for _, j := range m.jobs {
+		if !j.State.finished() {
+			counts[j.Kind]++
+		}
+	}
+	return counts
+}
+
+// expiresAt は終了したジョブと結果を削除する日時を返します
+func (m *jobManager) expiresAt(j *job) time.Time {
+	if !j.State.finished() {
+		return time.Time{}
+	}
+	return j.FinishedAt.Add(m.resultTTL)
+}
+
+// expire は保持期間を過ぎたジョブと結果を削除します
+func (m *jobManager) expire(now time.Time) {
+	m.mu.Lock()
+	var expired []*job
+	for id, j := range m.jobs {
+		if j.State.finished() && now.After(m.expiresAt(j)) {
+			expired = append(expired, j)
+			delete(m.jobs, id)
+		}
+	}
+	m.mu.Unlock()
+
+	ctx := context.Background()
+	for _, j := range expired {
+		// 終了時に削除できなかったリクエストもここで削除する
+		m.deleteInput(ctx, j)
+		if j.ResultID != "" {
+			_, err := m.store.delete(ctx, j.Tenant, storeKindResults, j.ResultID)
+			if err != nil && !errors.Is(err, errObjectNotFound) {
+				log.Printf("job %s: failed to delete result: %v", j.ID, err)
+				continue
+			}
+		}
+		if err := m.db.delete(j.ID); err != nil {
+			log.Printf("job %s: failed to delete record: %v", j.ID, err)
+		}
+	}
+}
+
+func (m *jobManager) expireLoop() {
+	interval := min(max(m.resultTTL/4, time.Minute), time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.expire(now)
+		case <-m.stopping.Done():
+			return
+		}
+	}
+}
+
+// get はテナントのジョブの状態と、次に状態が変わったときにcloseされるチャネルを返します
+func (m *jobManager) get(tenant, id string) (*job, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok || j.Tenant != tenant {
+		return nil, nil, errObjectNotFound
+	}
+	snapshot := *j
+	return &snapshot, m.changed, nil
+}
+
+func (m *jobManager) jobStatus(j *job) *score.JobStatus {
+	status := &score.JobStatus{
+		Stage:     j.Stage,
+		Progress:  j.Progress,
+		Message:   j.Message,
+		JobId:     j.ID,
+		Kind:      string(j.Kind),
+		State:     string(j.State),
+		Error:     j.Error,
+		CreatedAt: j.CreatedAt.Unix(),
+		UpdatedAt: j.UpdatedAt.Unix(),
+		Filename:  j.Filename,
+	}
+	if expires := m.expiresAt(j); !expires.IsZero() {
+		status.ExpiresAt = expires.Unix()
+	}
+	return status
+}
+
+func jobError(err error) error {
+	if errors.Is(err, errObjectNotFound) {
+		return connect.NewError(connect.CodeNotFound, errors.New("指定されたジョブが見つかりません"))
+	}
+	return storeError(err)
+}
+
+// buildJob はジョブの種類に応じてリクエストを復元し、処理本体を組み立てます
+func (s *scoreService) buildJob(j *job, request []byte) (jobFunc, error) {
+	limits := s.cfg.pdfLimits()
+	switch j.Kind {
+	case jobKindTrim:
+		msg := &score.TrimScoreRequest{}
+		if err := proto.Unmarshal(request, msg); err != nil {
+			return nil, err
+		}
+		defaultAreas, pageOverrides, err := trimAreasFromRequest(msg)
+		if err != nil {
+			return nil, err
+		}
+		split, err := segmentSplitFromRequest(msg.GetSplit())
+		if err != nil {
+			return nil, err
+		}
+		strip, err := stripLayoutFromRequest(msg)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, send progressSender) (*jobResult, error) {
+			trimmed, err := buildTrimmedPDFWithProgress(
+				msg.GetPdfFile(),
+				defaultAreas,
+				msg.GetIncludePages(),
+				msg.GetPassword(),
+				pageOverrides,
+				split,
+				strip,
+				msg.GetOrientation(),
+				limits,
+				send,
+				j.Lang,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return &jobResult{
+				data:        trimmed,
+				filename:    trimmedFilename(msg.GetTitle(), msg.GetOrientation()),
+				contentType: "application/pdf",
+			}, nil
+		}, nil
+
+	case jobKindVideo:
+		msg := &score.GenerateScrollVideoRequest{}
+		if err := proto.Unmarshal(request, msg); err != nil {
+			return nil, err
+		}
+		opts, err := videoOptionsFromRequest(msg, s.cfg.videoLimits())
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, send progressSender) (*jobResult, error) {
+			video, err := renderScrollVideo(ctx, msg.GetPdfFile(), opts, limits, send)
+			if err != nil {
+				return nil, err
+			}
+			return &jobResult{
+				file:        video.path,
+				filename:    video.filename,
+				contentType: video.contentType,
+			}, nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("ジョブの種類%sには対応していません", j.Kind)
+	}
+}
+
+// SubmitTrimJob はトリミングを非同期ジョブとして受け付けます
+func (s *scoreService) SubmitTrimJob(
+	ctx context.Context,
+	req *connect.Request[score.TrimScoreRequest],
+) (*connect.Response[score.SubmitJobResponse], error) {
+	if len(req.Msg.GetPdfFile()) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFファイルが空です"))
+	}
+	j, err := s.jobs.submit(
+		ctx,
+		tenantFromContext(ctx),
+		clientID(ctx, req.Header(), req.Peer(), s.cfg.TrustProxyHeaders),
+		getLanguageFromRequest(req),
+		jobKindTrim,
+		req.Msg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&score.SubmitJobResponse{
+		JobId:  j.ID,
+		Status: s.jobs.jobStatus(j),
+	}), nil
+}
+
+// SubmitVideoJob はスクロール動画の生成を非同期ジョブとして受け付けます
+func (s *scoreService) SubmitVideoJob(
+	ctx context.Context,
+	req *connect.Request[score.GenerateScrollVideoRequest],
+) (*connect.Response[score.SubmitJobResponse], error) {
+	if len(req.Msg.GetPdfFile()) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFファイルが空です"))
+	}
+	j, err := s.jobs.submit(
+		ctx,
+		tenantFromContext(ctx),
+		clientID(ctx, req.Header(), req.Peer(), s.cfg.TrustProxyHeaders),
+		"",
+		jobKindVideo,
+		req.Msg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&score.SubmitJobResponse{
+		JobId:  j.ID,
+		Status: s.jobs.jobStatus(j),
+	}), nil
+}
+
+// GetJob はジョブの状態を返します
+func (s *scoreService) GetJob(
+	ctx context.Context,
+	req *connect.Request[score.GetJobRequest],
+) (*connect.Response[score.JobStatus], error) {
+	j, _, err := s.jobs.get(tenantFromContext(ctx), req.Msg.GetJobId())
+	if err != nil {
+		return nil, jobError(err)
+	}
+	return connect.NewResponse(s.jobs.jobStatus(j)), nil
+}
+
+// WatchJob はジョブの状態が変わるたびに送信し、ジョブが終了したらストリームを閉じます
+func (s *scoreService) WatchJob(
+	ctx context.Context,
+	req *connect.Request[score.GetJobRequest],
+	stream *connect.ServerStream[score.JobStatus],
+) error {
+	tenant := tenantFromContext(ctx)
+	var last *job
+	for {
+		j, changed, err := s.jobs.get(tenant, req.Msg.GetJobId())
+		if err != nil {
+			return jobError(err)
+		}
+		if last == nil || j.UpdatedAt != last.UpdatedAt || j.Stage != last.Stage || j.Progress != last.Progress || j.State != last.State {
+			if err := stream.Send(s.jobs.jobStatus(j)); err != nil {
+				return err
+			}
+		}
+		if j.State.finished() {
+			return nil
+		}
+		last = j
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.jobs.stopping.Done():
+			// サーバーの停止を待たせないようストリームを閉じる。クライアントは再接続して続きを受け取る。
+			return connect.NewError(connect.CodeUnavailable, errShuttingDown)
+		}
+	}
+}
+
+// succeededJob は成功したジョブを返します。失敗や未完了の場合は結果を取得できないエラーを返します。
+func (s *scoreService) succeededJob(tenant, id string) (*job, error) {
+	j, _, err := s.jobs.get(tenant, id)
+	if err != nil {
+		return nil, jobError(err)
+	}
+	switch j.State {
+	case jobSucceeded:
+		return j, nil
+	case jobFailed:
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("ジョブは失敗しました: %s", j.Error))
+	default:
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("ジョブはまだ完了していません（%s %d%%）", j.State, j.Progress))
+	}
+}
+
+// GetJobResult は完了したジョブの結果を返します。
+// 1回の応答に収まらない大きな結果はDownloadJobResultで分割して取得します。
+func (s *scoreService) GetJobResult(
+	ctx context.Context,
+	req *connect.Request[score.GetJobRequest],
+) (*connect.Response[score.GetJobResultResponse], error) {
+	tenant := tenantFromContext(ctx)
+	j, err := s.succeededJob(tenant, req.Msg.GetJobId())
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.store.stat(ctx, tenant, storeKindResults, j.ResultID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if obj.SizeBytes > s.cfg.MaxMessageBytes {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf(
+			"結果のサイズ%dバイトが1回の応答の上限%dバイトを超えています。DownloadJobResultで分割して取得してください",
+			obj.SizeBytes, s.cfg.MaxMessageBytes))
+	}
+	_, data, err := s.store.get(ctx, tenant, storeKindResults, j.ResultID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&score.GetJobResultResponse{
+		JobId:       j.ID,
+		Filename:    j.Filename,
+		ContentType: j.ContentType,
+		Data:        data,
+	}), nil
+}
+
+// DownloadJobResult は完了したジョブの結果をチャンクに分けて返します。
+// 結果全体をメモリに読み込まず、保存先からチャンクごとに読み出して送ります。
+func (s *scoreService) DownloadJobResult(
+	ctx context.Context,
+	req *connect.Request[score.DownloadJobResultRequest],
+	stream *connect.ServerStream[score.JobResultChunk],
+) error {
+	tenant := tenantFromContext(ctx)
+	j, err := s.succeededJob(tenant, req.Msg.GetJobId())
+	if err != nil {
+		return err
+	}
+	obj, err := s.store.stat(ctx, tenant, storeKindResults, j.ResultID)
+	if err != nil {
+		return storeError(err)
+	}
+
+	offset := req.Msg.GetOffset()
+	if offset < 0 || offset > obj.SizeBytes {
+		return connect.NewError(connect.CodeOutOfRange, fmt.Errorf("開始位置%dが結果のサイズ%dバイトの範囲外です", offset, obj.SizeBytes))
+	}
+	chunkSize := req.Msg.GetChunkSize()
+	if chunkSize <= 0 || chunkSize > s.cfg.UploadChunkBytes {
+		chunkSize = s.cfg.UploadChunkBytes
+	}
+
+	// 結果が空の場合や末尾から再開した場合も、メタデータを返すために最初のチャンクは必ず送ります
+	for first := true; first || offset < obj.SizeBytes; first = false {
+		length := min(chunkSize, obj.SizeBytes-offset)
+		data, err := s.store.getRange(ctx, tenant, storeKindResults, j.ResultID, offset, length)
+		if err != nil {
+			return storeError(err)
+		}
+		if int64(len(data)) != length {
+			return connect.NewError(connect.CodeDataLoss, fmt.Errorf("結果の%dバイト目からを読み込めません", offset))
+		}
+		chunk := &score.JobResultChunk{
+			JobId:  j.ID,
+			Offset: offset,
+			Data:   data,
+		}
+		if first {
+			chunk.Filename = j.Filename
+			chunk.ContentType = j.ContentType
+			chunk.TotalSize = obj.SizeBytes
+			chunk.Sha256 = obj.SHA256
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		offset += length
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	score "score-splitter/backend/gen/go"
+	"score-splitter/backend/gen/go/scoreconnect"
+
+	"connectrpc.com/connect"
+)
+
+// newTestJobManager はjobFuncを差し替えたジョブの管理を返します。dirを渡すと同じ記録を使って再起動できます。
+func newTestJobManager(t *testing.T, dir string, run jobFunc) *jobManager {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.JobDBPath = filepath.Join(dir, "jobs.db")
+	store := newScoreStore(newFSStorage(filepath.Join(dir, "store")), 1)
+	m, err := newJobManager(store, newAdmissionController(cfg), cfg, func(*job, []byte) (jobFunc, error) {
+		return run, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// waitJob はジョブがcondを満たすまで待ちます
+func waitJob(t *testing.T, m *jobManager, id string, cond func(*job) bool) *job {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		j, changed, err := m.get("alice", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cond(j) {
+			return j
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("job %s stuck in %s", id, j.State)
+		}
+	}
+}
+
+func TestJobShutdownInterruptsAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	started := make(chan struct{})
+	m := newTestJobManager(t, dir, func(ctx context.Context, send progressSender) (*jobResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	j, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if m.ctx.Err() == nil {
+		t.Error("running job was not cancelled after the shutdown timeout")
+	}
+
+	// 中断したジョブは失敗にせず、次の起動時に再開する
+	m = newTestJobManager(t, dir, func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{data: []byte("result"), filename: "result.pdf", contentType: "application/pdf"}, nil
+	})
+	defer m.shutdown(context.Background())
+	done := waitJob(t, m, j.ID, func(j *job) bool { return j.State.finished() })
+	if done.State != jobSucceeded {
+		t.Fatalf("resumed job state = %s (%s), want succeeded", done.State, done.Error)
+	}
+
+	// 結果はテナントの容量（ここでは1バイト）に数えない
+	obj, data, err := m.store.get(context.Background(), "alice", storeKindResults, done.ResultID)
+	if err != nil || string(data) != "result" || obj.SHA256 == "" {
+		t.Errorf("result = %q, %+v, %v", data, obj, err)
+	}
+	if used, err := m.store.usage(context.Background(), "alice"); err != nil || used != 0 {
+		t.Errorf("usage = %d, %v, want 0", used, err)
+	}
+}
+
+func TestJobResultsAreNotDeduplicated(t *testing.T) {
+	m := newTestJobManager(t, t.TempDir(), func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{data: []byte("same"), filename: "same.pdf"}, nil
+	})
+	defer m.shutdown(context.Background())
+
+	var results []string
+	for range 2 {
+		j, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := waitJob(t, m, j.ID, func(j *job) bool { return j.State.finished() })
+		if done.State != jobSucceeded {
+			t.Fatalf("job state = %s (%s)", done.State, done.Error)
+		}
+		results = append(results, done.ResultID)
+	}
+	if results[0] == results[1] {
+		t.Errorf("jobs share result %s", results[0])
+	}
+
+	// 一方の結果を削除しても他方は残る
+	if _, err := m.store.delete(context.Background(), "alice", storeKindResults, results[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.store.get(context.Background(), "alice", storeKindResults, results[1]); err != nil {
+		t.Errorf("other result: %v", err)
+	}
+}
+
+func TestJobSubmitAfterShutdown(t *testing.T) {
+	m := newTestJobManager(t, t.TempDir(), func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{}, nil
+	})
+	if err := m.shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"}); err == nil {
+		t.Error("submit after shutdown succeeded")
+	}
+}
+
+func TestJobInputIsStoredOutsideTheJobDB(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	m := newTestJobManager(t, dir, func(ctx context.Context, send progressSender) (*jobResult, error) {
+		<-release
+		return &jobResult{data: []byte("result"), filename: "result.pdf"}, nil
+	})
+	defer m.shutdown(context.Background())
+
+	pdf := bytes.Repeat([]byte("%PDF"), 1<<18)
+	j, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score", PdfFile: pdf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PDFはjobDBではなく保存先に置き、テナントの容量には数えない
+	inputs, err := m.store.list(context.Background(), "alice", storeKindJobInputs)
+	if err != nil || len(inputs) != 1 || inputs[0].ID != j.InputID {
+		t.Fatalf("inputs = %v, %v, want %s", inputs, err, j.InputID)
+	}
+	if used, err := m.store.usage(context.Background(), "alice"); err != nil || used != 0 {
+		t.Errorf("usage = %d, %v, want 0", used, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() >= int64(len(pdf)) {
+		t.Errorf("jobs.db is %d bytes; the PDF was written to the job database", info.Size())
+	}
+
+	// 終了したジョブのリクエストは削除する
+	close(release)
+	waitJob(t, m, j.ID, func(j *job) bool { return j.State.finished() })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		inputs, err := m.store.list(context.Background(), "alice", storeKindJobInputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inputs) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("request %s was not deleted after the job finished", j.InputID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobRecoverResumesSeveralJobs(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestJobManager(t, dir, func(ctx context.Context, send progressSender) (*jobResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	var ids []string
+	for range 8 {
+		j, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, j.ID)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	if err := m.shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 再開したジョブが読み込み中のジョブの一覧を参照しても競合しない（-raceで確認する）
+	m = newTestJobManager(t, dir, func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{data: []byte("result"), filename: "result.pdf"}, nil
+	})
+	defer m.shutdown(context.Background())
+	for _, id := range ids {
+		done := waitJob(t, m, id, func(j *job) bool { return j.State.finished() })
+		if done.State != jobSucceeded {
+			t.Errorf("job %s state = %s (%s), want succeeded", id, done.State, done.Error)
+		}
+	}
+}
+
+func TestJobBuildsFromStoredInputAfterAdmission(t *testing.T) {
+	m := newTestJobManager(t, t.TempDir(), func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{data: []byte("result"), filename: "result.pdf"}, nil
+	})
+	defer m.shutdown(context.Background())
+
+	// クライアントの実行枠を埋めておき、ジョブを順番待ちにする
+	var releases []func()
+	for range defaultConfig().PerClientTrimJobs {
+		release, err := m.admission.acquire(context.Background(), jobKindTrim, "client")
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+	j, err := m.submit(context.Background(), "alice", "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 処理本体は実行枠を確保してから保存したリクエストで組み立てるので、待っている間に消えたリクエストは読めない
+	if _, err := m.store.delete(context.Background(), "alice", storeKindJobInputs, j.InputID); err != nil {
+		t.Fatal(err)
+	}
+	for _, release := range releases {
+		release()
+	}
+	done := waitJob(t, m, j.ID, func(j *job) bool { return j.State.finished() })
+	if done.State != jobFailed {
+		t.Errorf("job state = %s, want failed without its stored request", done.State)
+	}
+}
+
+// newTestJobClient はdataを結果とするジョブを1つ完了させ、そのジョブのIDとサービスに接続したクライアントを返します
+func newTestJobClient(t *testing.T, cfg *serverConfig, data []byte) (string, scoreconnect.ScoreServiceClient) {
+	t.Helper()
+	m := newTestJobManager(t, t.TempDir(), func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{data: data, filename: "result.pdf", contentType: "application/pdf"}, nil
+	})
+	t.Cleanup(func() { m.shutdown(context.Background()) })
+	j, err := m.submit(context.Background(), defaultTenant, "client", "", jobKindTrim, &score.TrimScoreRequest{Title: "score"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		done, _, err := m.get(defaultTenant, j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done.State == jobSucceeded {
+			break
+		}
+		if done.State.finished() || time.Now().After(deadline) {
+			t.Fatalf("job state = %s (%s)", done.State, done.Error)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	service := &scoreService{cfg: cfg, store: m.store, jobs: m}
+	path, handler := scoreconnect.NewScoreServiceHandler(service)
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return j.ID, scoreconnect.NewScoreServiceClient(server.Client(), server.URL)
+}
+
+func TestDownloadJobResult(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.UploadChunkBytes = 4
+	data := []byte("0123456789")
+	jobID, client := newTestJobClient(t, cfg, data)
+
+	tests := []struct {
+		name      string
+		offset    int64
+		chunkSize int64
+		want      []string
+	}{
+		{name: "whole result", want: []string{"0123", "4567", "89"}},
+		{name: "smaller chunks", chunkSize: 3, want: []string{"012", "345", "678", "9"}},
+		// 上限より大きなチャンクは上限に揃える
+		{name: "chunk over limit", chunkSize: 100, want: []string{"0123", "4567", "89"}},
+		// 途中から再開した場合は、その位置からのデータだけを返す
+		{name: "resume", offset: 6, want: []string{"6789"}},
+		// 末尾から再開した場合も、メタデータを返すために空のチャンクを1つ返す
+		{name: "offset at end", offset: 10, want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.DownloadJobResult(context.Background(), connect.NewRequest(&score.DownloadJobResultRequest{
+				JobId:     jobID,
+				Offset:    tt.offset,
+				ChunkSize: tt.chunkSize,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			offset := tt.offset
+			for stream.Receive() {
+				chunk := stream.Msg()
+				if chunk.GetOffset() != offset {
+					t.Errorf("chunk offset = %d, want %d", chunk.GetOffset(), offset)
+				}
+				// ファイル名などのメタデータは最初のチャンクにだけ入れる
+				if first := len(got) == 0; first != (chunk.GetTotalSize() == int64(len(data))) || first != (chunk.GetFilename() == "result.pdf") {
+					t.Errorf("chunk %d metadata = %q, %d", len(got), chunk.GetFilename(), chunk.GetTotalSize())
+				}
+				if len(got) == 0 && chunk.GetSha256() != sha256Hex(data) {
+					t.Errorf("sha256 = %q, want %q", chunk.GetSha256(), sha256Hex(data))
+				}
+				got = append(got, string(chunk.GetData()))
+				offset += int64(len(chunk.GetData()))
+			}
+			if err := stream.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	stream, err := client.DownloadJobResult(context.Background(), connect.NewRequest(&score.DownloadJobResultRequest{JobId: jobID, Offset: 11}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+		t.Errorf("chunk received for an offset past the end: %v", stream.Msg())
+	}
+	if connect.CodeOf(stream.Err()) != connect.CodeOutOfRange {
+		t.Errorf("err = %v, want out_of_range", stream.Err())
+	}
+}
+
+func TestGetJobResultRejectsLargeResults(t *testing.T) {
+	data := []byte("0123456789")
+	tests := []struct {
+		name     string
+		maxBytes int64
+		wantCode connect.Code
+	}{
+		{name: "fits", maxBytes: 10},
+		// 1回の応答に収まらない結果はDownloadJobResultを使うよう案内する
+		{name: "too large", maxBytes: 9, wantCode: connect.CodeFailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.MaxMessageBytes = tt.maxBytes
+			jobID, client := newTestJobClient(t, cfg, data)
+			res, err := client.GetJobResult(context.Background(), connect.NewRequest(&score.GetJobRequest{JobId: jobID}))
+			if tt.wantCode != 0 {
+				if connect.CodeOf(err) != tt.wantCode || !strings.Contains(err.Error(), "DownloadJobResult") {
+					t.Errorf("err = %v, want %v mentioning DownloadJobResult", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(res.Msg.GetData(), data) {
+				t.Errorf("data = %q, want %q", res.Msg.GetData(), data)
+			}
+		})
+	}
+}
+
+func TestJobSavesResultFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "video.mp4")
+	data := bytes.Repeat([]byte("frame "), 1000)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestJobManager(t, t.TempDir(), func(ctx context.Context, send progressSender) (*jobResult, error) {
+		return &jobResult{file: file, filename: "video.mp4", contentType: "video/mp4"}, nil
+	})
+	defer m.shutdown(context.Background())
+
+	j, err := m.submit(context.Background(), "alice", "client", "", jobKindVideo, &score.GenerateScrollVideoRequest{Title: "score"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := waitJob(t, m, j.ID, func(j *job) bool { return j.State.finished() })
+	if done.State != jobSucceeded {
+		t.Fatalf("job state = %s (%s)", done.State, done.Error)
+	}
+	// 一時ファイルの結果はそのまま保存先に流し込み、保存した後に削除する
+	obj, stored, err := m.store.get(context.Background(), "alice", storeKindResults, done.ResultID)
+	if err != nil || !bytes.Equal(stored, data) || obj.SizeBytes != int64(len(data)) || obj.SHA256 != sha256Hex(data) {
+		t.Errorf("stored result = %d bytes, %+v, %v", len(stored), obj, err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("result file left after saving: %v", err)
+	}
+}
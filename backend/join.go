@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// errInvalidJoin はつなげられない範囲に join_with_next が指定された場合のエラーです
+var errInvalidJoin = errors.New("つなげる範囲は同じページか次のページにある必要があります")
+
+// segmentPart はつなげる前のトリミング範囲です
+type segmentPart struct {
+	pageIndex int
+	rect      *types.Rectangle
+}
+
+// segmentBuilder はトリミング範囲を出力順に受け取り、出力するページを組み立てます。
+// join_with_next が指定された範囲は次の範囲と縦につなげて1ページにします。
+// 分割の設定がある場合は、つなげた後のページも1つの範囲と同じように分割します。
+type segmentBuilder struct {
+	ctx      *model.Context
+	split    segmentSplit
+	pending  []segmentPart
+	segments [][]byte
+}
+
+func newSegmentBuilder(ctx *model.Context, split segmentSplit) *segmentBuilder {
+	return &segmentBuilder{ctx: ctx, split: split}
+}
+
+// add はトリミング範囲を追加します。joinWithNext が true の場合は次の範囲が追加されるまで保留します。
+// つなげる相手は直前の範囲と同じページか次のページの範囲に限ります。途中のページを飛ばした場合はエラーになります。
+func (b *segmentBuilder) add(pageIndex int, rect *types.Rectangle, joinWithNext bool) error {
+	if len(b.pending) > 0 {
+		last := b.pending[len(b.pending)-1].pageIndex
+		if pageIndex != last && pageIndex != last+1 {
+			return fmt.Errorf("%w（ページ%vの次がページ%vです）", errInvalidJoin, last, pageIndex)
+		}
+	}
+	b.pending = append(b.pending, segmentPart{pageIndex: pageIndex, rect: rect})
+	if joinWithNext {
+		return nil
+	}
+	return b.flush()
+}
+
+func (b *segmentBuilder) flush() error {
+	parts := b.pending
+	b.pending = nil
+	switch len(parts) {
+	case 0:
+		return nil
+	case 1:
+		trimmed, err := extractSegments(b.ctx, parts[0].pageIndex, parts[0].rect, b.split)
+		if err != nil {
+			return err
+		}
+		b.segments = append(b.segments, trimmed...)
+		return nil
+	default:
+		joined, err := extractJoinedSegment(b.ctx, parts)
+		if err != nil {
+			return err
+		}
+		trimmed, err := splitJoinedSegment(joined, b.split)
+		if err != nil {
+			return err
+		}
+		b.segments = append(b.segments, trimmed...)
+		return nil
+	}
+}
+
+// splitJoinedSegment はつなげた1ページのPDFを分割の設定に従って複数ページに分けます
+func splitJoinedSegment(joined []byte, split segmentSplit) ([][]byte, error) {
+	if !split.enabled() {
+		return [][]byte{joined}, nil
+	}
+	// 元のPDFを読み込んだ時に上限を確認済みなので、ここでは確認しない
+	ctx, err := readPDFContext(joined, "", pdfLimits{})
+	if err != nil {
+		return nil, err
+	}
+	_, _, inh, err := ctx.PageDict(1, false)
+	if err != nil {
+		return nil, err
+	}
+	if inh.MediaBox == nil {
+		return nil, fmt.Errorf("つなげたページのサイズ情報を取得できません")
+	}
+	return extractSegments(ctx, 1, inh.MediaBox, split)
+}
+
+// finish は保留中の範囲を出力して、組み立てたページを返します。
+// 最後の範囲に join_with_next が指定されていても、つなげる相手がないのでそのまま出力します。
+func (b *segmentBuilder) finish() ([][]byte, error) {
+	if err := b.flush(); err != nil {
+		return nil, err
+	}
+	return b.segments, nil
+}
+
+// extractJoinedSegment は複数のトリミング範囲を上から順に縦につなげた1ページのPDFを返します。
+// ページをまたいで途切れた段を1つにまとめるために使います。範囲は左端をそろえて配置します。
+func extractJoinedSegment(ctxSrc *model.Context, parts []segmentPart) ([]byte, error) {
+	segments := make([][]byte, len(parts))
+	for i, part := range parts {
+		trimmed, err := extractTrimmedSegment(ctxSrc, part.pageIndex, part.rect)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = trimmed
+	}
+	return composeSegments(segments, func(sizes []*types.Rectangle) (float64, float64, []placement) {
+		var width, height float64
+		for _, size := range sizes {
+			width = math.Max(width, size.Width())
+			height += size.Height()
+		}
+		places := make([]placement, len(sizes))
+		y := height
+		for i, size := range sizes {
+			y -= size.Height()
+			places[i] = placement{x: 0, y: y, scale: 1}
+		}
+		return width, height, places
+	})
+}
+
+// placement は部品のページを描画する位置（左下）と倍率です
+type placement struct {
+	x, y, scale float64
+}
+
+// segmentLayout は部品のページのサイズから、出力するページのサイズと各部品の配置を決めます
+type segmentLayout func(sizes []*types.Rectangle) (width, height float64, places []placement)
+
+// composeSegments は1ページのPDFの部品segmentsを、layoutが決めた位置に描画した1ページのPDFを返します。
+// 部品はフォームXObjectとして描画するので、ベクターのまま配置できます。
+func composeSegments(segments [][]byte, layout segmentLayout) ([]byte, error) {
+	readers := make([]io.ReadSeeker, len(segments))
+	for i, data := range segments {
+		readers[i] = bytes.NewReader(data)
+	}
+
+	var merged bytes.Buffer
+	conf := model.NewDefaultConfiguration()
+	if err := pdfapi.MergeRaw(readers, &merged, false, conf); err != nil {
+		return nil, err
+	}
+	ctx, err := pdfapi.ReadContext(bytes.NewReader(merged.Bytes()), conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+	if ctx.PageCount != len(segments) {
+		return nil, fmt.Errorf("配置するページ数が一致しません（%d / %d）", ctx.PageCount, len(segments))
+	}
+
+	// 各ページをフォームXObjectにします
+	xobjects := types.Dict{}
+	names := make([]string, len(segments))
+	sizes := make([]*types.Rectangle, len(segments))
+	for i := range segments {
+		pageNr := i + 1
+		pageDict, _, inh, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return nil, err
+		}
+		if inh.MediaBox == nil {
+			return nil, fmt.Errorf("ページ%vのサイズ情報を取得できません", pageNr)
+		}
+		pageContent, err := ctx.PageContent(pageDict, pageNr)
+		if err != nil && err != model.ErrNoContent {
+			return nil, err
+		}
+
+		form, err := ctx.NewStreamDictForBuf(pageContent)
+		if err != nil {
+			return nil, err
+		}
+		form.InsertName("Type", "XObject")
+		form.InsertName("Subtype", "Form")
+		form.Insert("BBox", inh.MediaBox.Array())
+		if inh.Resources != nil {
+			form.Insert("Resources", inh.Resources)
+		}
+		if err := form.Encode(); err != nil {
+			return nil, err
+		}
+		indRef, err := ctx.IndRefForNewObject(*form)
+		if err != nil {
+			return nil, err
+		}
+
+		names[i] = fmt.Sprintf("Seg%d", pageNr)
+		xobjects[names[i]] = *indRef
+		sizes[i] = inh.MediaBox
+	}
+
+	// 1ページ目に全ての部品を描画します
+	width, height, places := layout(sizes)
+	var content bytes.Buffer
+	for i, p := range places {
+		fmt.Fprintf(&content, "q %.5f 0 0 %.5f %.5f %.5f cm /%s Do Q ", p.scale, p.scale, p.x, p.y, names[i])
+	}
+
+	pageDict, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		return nil, err
+	}
+	box := types.RectForWidthAndHeight(0, 0, width, height)
+	pageDict["MediaBox"] = box.Array()
+	pageDict["CropBox"] = box.Array()
+	pageDict["Resources"] = types.Dict{"XObject": xobjects}
+
+	streamDict, err := ctx.NewStreamDictForBuf(content.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if err := streamDict.Encode(); err != nil {
+		return nil, err
+	}
+	indRef, err := ctx.IndRefForNewObject(*streamDict)
+	if err != nil {
+		return nil, err
+	}
+	pageDict["Contents"] = *indRef
+
+	// 1ページ目だけを取り出し、部品になった残りのページを除きます
+	ctxComposed, err := pdfcpu.ExtractPages(ctx, []int{1}, false)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := pdfapi.WriteContext(ctxComposed, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// testPDFBytes は幅width・高さheightの白紙に横線を引いたページをpages枚持つPDFを返します
+func testPDFBytes(pages int, width, height float64) []byte {
+	var buf bytes.Buffer
+	offsets := []int{0}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+	buf.WriteString("%PDF-1.7\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := ""
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 3+i*2)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, pages))
+	for i := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Contents %d 0 R >>", width, height, 4+i*2))
+		// pdfcpuはフィルタの無いストリームを展開できないため圧縮しておく
+		var content bytes.Buffer
+		w := zlib.NewWriter(&content)
+		fmt.Fprintf(w, "0 %g m %g %g l S", height/2, width, height/2)
+		w.Close()
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	return buf.Bytes()
+}
+
+// testPDF はtestPDFBytesのPDFを読み込んだものを返します
+func testPDF(t *testing.T, pages int, width, height float64) *model.Context {
+	t.Helper()
+	ctx, err := readPDFContext(testPDFBytes(pages, width, height), "", pdfLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// pageHeight は1ページのPDFの高さを返します
+func pageHeight(t *testing.T, pdf []byte) float64 {
+	t.Helper()
+	dims, err := pdfapi.PageDims(bytes.NewReader(pdf), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dims) != 1 {
+		t.Fatalf("segment has %d pages, want 1", len(dims))
+	}
+	return dims[0].Height
+}
+
+func TestSegmentBuilderSplitsJoinedSegment(t *testing.T) {
+	ctx := testPDF(t, 2, 400, 600)
+	split := segmentSplit{maxHeight: 100, mode: splitModeOverlap}
+	b := newSegmentBuilder(ctx, split)
+
+	// 1ページ目の下端と2ページ目の上端の80ポイントずつをつなげると160ポイントになり、上限の100を超える
+	if err := b.add(1, types.NewRectangle(0, 0, 400, 80), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.add(2, types.NewRectangle(0, 520, 400, 600), false); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("got %d segments, want the joined 160pt segment split into 2", len(segments))
+	}
+	for i, segment := range segments {
+		if h := pageHeight(t, segment); h > 100+0.01 {
+			t.Errorf("segment %d height = %v, want at most 100", i, h)
+		}
+	}
+}
+
+func TestSegmentBuilderKeepsShortJoinedSegment(t *testing.T) {
+	ctx := testPDF(t, 2, 400, 600)
+	b := newSegmentBuilder(ctx, segmentSplit{maxHeight: 200, mode: splitModeOverlap})
+
+	if err := b.add(1, types.NewRectangle(0, 0, 400, 80), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.add(2, types.NewRectangle(0, 520, 400, 600), false); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segments))
+	}
+	if h := pageHeight(t, segments[0]); h < 159.99 || h > 160.01 {
+		t.Errorf("joined height = %v, want 160", h)
+	}
+}
+
+func TestSegmentBuilderRejectsJoinAcrossSkippedPages(t *testing.T) {
+	ctx := testPDF(t, 3, 400, 600)
+	b := newSegmentBuilder(ctx, segmentSplit{})
+
+	// include_pages で2ページ目を除くと、1ページ目の範囲が3ページ目の範囲とつながってしまう
+	if err := b.add(1, types.NewRectangle(0, 0, 400, 80), true); err != nil {
+		t.Fatal(err)
+	}
+	err := b.add(3, types.NewRectangle(0, 520, 400, 600), false)
+	if !errors.Is(err, errInvalidJoin) {
+		t.Fatalf("add: err = %v, want errInvalidJoin", err)
+	}
+	if connect.CodeOf(trimError(err)) != connect.CodeInvalidArgument {
+		t.Errorf("trimError code = %v, want invalid_argument", connect.CodeOf(trimError(err)))
+	}
+}
+
+func TestSegmentBuilderJoinsWithinPage(t *testing.T) {
+	ctx := testPDF(t, 1, 400, 600)
+	b := newSegmentBuilder(ctx, segmentSplit{})
+
+	if err := b.add(1, types.NewRectangle(0, 300, 400, 600), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.add(1, types.NewRectangle(0, 0, 400, 100), false); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segments))
+	}
+	if h := pageHeight(t, segments[0]); h < 399.99 || h > 400.01 {
+		t.Errorf("joined height = %v, want 400", h)
+	}
+}
@@ -4,16 +4,19 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"syscall"
 
 	score "score-splitter/backend/gen/go"
 	"score-splitter/backend/gen/go/scoreconnect"
@@ -25,26 +28,51 @@ import (
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 )
 
-type scoreService struct{}
+type scoreService struct {
+	cfg     *serverConfig
+	store   *scoreStore
+	uploads *uploadManager
+	jobs    *jobManager
+}
+
+func newScoreService(cfg *serverConfig, storage blobStorage, admission *admissionController) (*scoreService, error) {
+	store := newScoreStore(storage, cfg.TenantQuotaBytes)
+	s := &scoreService{
+		cfg:     cfg,
+		store:   store,
+		uploads: newUploadManager(store, cfg),
+	}
+	jobs, err := newJobManager(store, admission, cfg, s.buildJob)
+	if err != nil {
+		return nil, err
+	}
+	s.jobs = jobs
+	return s, nil
+}
 
 // getLanguageFromRequest extracts language from request headers or path
 func getLanguageFromRequest(req *connect.Request[score.TrimScoreRequest]) string {
+	return getLanguageFromHeader(req.Header())
+}
+
+// getLanguageFromHeader extracts language from headers (Accept-Language, X-Language, Referer)
+func getLanguageFromHeader(header http.Header) string {
 	// Check Accept-Language header first
-	if acceptLang := req.Header().Get("Accept-Language"); acceptLang != "" {
+	if acceptLang := header.Get("Accept-Language"); acceptLang != "" {
 		if strings.Contains(strings.ToLower(acceptLang), "ja") {
 			return "ja"
 		}
 	}
 	
 	// Check custom language header
-	if lang := req.Header().Get("X-Language"); lang != "" {
+	if lang := header.Get("X-Language"); lang != "" {
 		if lang == "ja" || lang == "jp" {
 			return "ja"
 		}
 	}
 	
 	// Check Referer header for /ja path
-	if referer := req.Header().Get("Referer"); referer != "" {
+	if referer := header.Get("Referer"); referer != "" {
 		if strings.Contains(referer, "/ja/") || strings.Contains(referer, "/ja") {
 			return "ja"
 		}
@@ -96,10 +124,11 @@ func getLocalizedMessage(messageKey, lang string) string {
 }
 
 type normalizedArea struct {
-	top    float64
-	left   float64
-	width  float64
-	height float64
+	top          float64
+	left         float64
+	width        float64
+	height       float64
+	joinWithNext bool
 }
 
 const minAreaSize = 0.01
@@ -108,50 +137,123 @@ func (s *scoreService) UploadScore(
 	ctx context.Context,
 	req *connect.Request[score.UploadScoreRequest],
 ) (*connect.Response[score.UploadScoreResponse], error) {
-	dir := "uploads"
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+	if len(req.Msg.GetPdfFile()) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFファイルが空です"))
 	}
 
-	filename := req.Msg.GetTitle() + ".pdf"
-	path := filepath.Join(dir, filename)
-
-	if err := os.WriteFile(path, req.Msg.GetPdfFile(), 0644); err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+	obj, duplicate, err := s.store.put(ctx, tenantFromContext(ctx), storeKindScores, req.Msg.GetTitle(), req.Msg.GetPdfFile())
+	if err != nil {
+		return nil, storeError(err)
 	}
 
-	res := connect.NewResponse(&score.UploadScoreResponse{
-		Message: "PDF uploaded successfully",
-		ScoreId: filename,
-	})
+	res := connect.NewResponse(uploadScoreResponse(obj, duplicate))
 	return res, nil
 }
 
-// getLanguageFromTrimRequest extracts language from TrimScore request headers
-func getLanguageFromTrimRequest(req *connect.Request[score.TrimScoreRequest]) string {
-	// Check Accept-Language header first
-	if acceptLang := req.Header().Get("Accept-Language"); acceptLang != "" {
-		if strings.Contains(strings.ToLower(acceptLang), "ja") {
-			return "ja"
-		}
+// ListScores は呼び出し元のテナントが保存したスコアの一覧を返します
+func (s *scoreService) ListScores(
+	ctx context.Context,
+	req *connect.Request[score.ListScoresRequest],
+) (*connect.Response[score.ListScoresResponse], error) {
+	tenant := tenantFromContext(ctx)
+	objects, err := s.store.list(ctx, tenant, storeKindScores)
+	if err != nil {
+		return nil, storeError(err)
 	}
-	
-	// Check custom language header
-	if lang := req.Header().Get("X-Language"); lang != "" {
-		if lang == "ja" || lang == "jp" {
-			return "ja"
-		}
+	used, err := s.store.usage(ctx, tenant)
+	if err != nil {
+		return nil, storeError(err)
 	}
-	
-	// Check Referer header for /ja path
-	if referer := req.Header().Get("Referer"); referer != "" {
-		if strings.Contains(referer, "/ja/") || strings.Contains(referer, "/ja") {
-			return "ja"
-		}
+
+	scores := make([]*score.ScoreInfo, 0, len(objects))
+	for _, obj := range objects {
+		scores = append(scores, scoreInfoFromObject(obj))
 	}
-	
-	// Default to English
-	return "en"
+	return connect.NewResponse(&score.ListScoresResponse{
+		Scores:     scores,
+		UsedBytes:  used,
+		QuotaBytes: s.cfg.TenantQuotaBytes,
+	}), nil
+}
+
+// GetScore は呼び出し元のテナントが保存したスコアを返します
+func (s *scoreService) GetScore(
+	ctx context.Context,
+	req *connect.Request[score.GetScoreRequest],
+) (*connect.Response[score.GetScoreResponse], error) {
+	obj, data, err := s.store.get(ctx, tenantFromContext(ctx), storeKindScores, req.Msg.GetScoreId())
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&score.GetScoreResponse{
+		Score:   scoreInfoFromObject(obj),
+		PdfFile: data,
+	}), nil
+}
+
+// DeleteScore は呼び出し元のテナントが保存したスコアを削除します。
+// 同じ内容が複数回アップロードされている場合は、titleのアップロードだけを削除し、最後の1回を削除するまでPDFを残します。
+func (s *scoreService) DeleteScore(
+	ctx context.Context,
+	req *connect.Request[score.DeleteScoreRequest],
+) (*connect.Response[score.DeleteScoreResponse], error) {
+	removed, err := s.store.deleteUpload(ctx, tenantFromContext(ctx), storeKindScores, req.Msg.GetScoreId(), req.Msg.GetTitle())
+	if err != nil {
+		return nil, storeError(err)
+	}
+	message := "Score deleted"
+	if !removed {
+		message = "同じ内容のPDFが他にもアップロードされているため、PDFは残しています"
+	}
+	return connect.NewResponse(&score.DeleteScoreResponse{
+		Message: message,
+	}), nil
+}
+
+func scoreInfoFromObject(obj *storedObject) *score.ScoreInfo {
+	titles := obj.Titles
+	if len(titles) == 0 {
+		titles = []string{obj.Title}
+	}
+	return &score.ScoreInfo{
+		ScoreId:   obj.ID,
+		Title:     obj.Title,
+		SizeBytes: obj.SizeBytes,
+		CreatedAt: obj.CreatedAt.Unix(),
+		Titles:    titles,
+	}
+}
+
+func uploadScoreResponse(obj *storedObject, duplicate bool) *score.UploadScoreResponse {
+	message := "PDF uploaded successfully"
+	if duplicate {
+		message = "同じ内容のPDFが既に保存されています"
+	}
+	return &score.UploadScoreResponse{
+		Message:   message,
+		ScoreId:   obj.ID,
+		Duplicate: duplicate,
+	}
+}
+
+// storeError は保存処理のエラーをconnectのエラーに変換します
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, errObjectNotFound):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, errQuotaExceeded):
+		return connect.NewError(connect.CodeResourceExhausted, err)
+	case errors.Is(err, errVersionConflict):
+		// 他のレプリカと同時に更新して何度も競合した場合で、再試行すれば成功する
+		return connect.NewError(connect.CodeAborted, err)
+	default:
+		return connect.NewError(connect.CodeInternal, err)
+	}
+}
+
+// getLanguageFromTrimRequest extracts language from TrimScore request headers
+func getLanguageFromTrimRequest(req *connect.Request[score.TrimScoreRequest]) string {
+	return getLanguageFromHeader(req.Header())
 }
 
 func (s *scoreService) TrimScore(
@@ -180,36 +282,17 @@ func (s *scoreService) TrimScore(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFファイルが空です"))
 	}
 
-	defaultAreas, err := normalizeAreas(req.Msg.GetAreas())
+	defaultAreas, pageOverrides, err := trimAreasFromRequest(req.Msg)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
-
-	pageOverrides := make(map[int][]normalizedArea)
-	for _, setting := range req.Msg.GetPageSettings() {
-		if setting == nil {
-			continue
-		}
-		pageNumber := int(setting.GetPageNumber())
-		if pageNumber < 1 {
-			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("ページ番号%vが無効です", setting.GetPageNumber()))
-		}
-		areas := setting.GetAreas()
-		if len(areas) == 0 {
-			continue
-		}
-		normalizedOverride, errNormalize := normalizeAreas(areas)
-		if errNormalize != nil {
-			return nil, connect.NewError(connect.CodeInvalidArgument, errNormalize)
-		}
-		if len(normalizedOverride) == 0 {
-			continue
-		}
-		pageOverrides[pageNumber] = normalizedOverride
+	split, err := segmentSplitFromRequest(req.Msg.GetSplit())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
-
-	if len(defaultAreas) == 0 && len(pageOverrides) == 0 {
-		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("トリミングエリアがありません"))
+	strip, err := stripLayoutFromRequest(req.Msg)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	trimmed, err := buildTrimmedPDF(
@@ -218,12 +301,12 @@ func (s *scoreService) TrimScore(
 		req.Msg.GetIncludePages(),
 		req.Msg.GetPassword(),
 		pageOverrides,
+		split,
+		strip,
+		s.cfg.pdfLimits(),
 	)
 	if err != nil {
-		if errors.Is(err, pdfcpu.ErrWrongPassword) {
-			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFのパスワードが正しくありません"))
-		}
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, trimError(err)
 	}
 
 	filename := deriveFilename(req.Msg.GetTitle())
@@ -280,36 +363,17 @@ func (s *scoreService) TrimScoreWithProgress(
 		return err
 	}
 
-	defaultAreas, err := normalizeAreas(req.Msg.GetAreas())
+	defaultAreas, pageOverrides, err := trimAreasFromRequest(req.Msg)
 	if err != nil {
 		return connect.NewError(connect.CodeInvalidArgument, err)
 	}
-
-	pageOverrides := make(map[int][]normalizedArea)
-	for _, setting := range req.Msg.GetPageSettings() {
-		if setting == nil {
-			continue
-		}
-		pageNumber := int(setting.GetPageNumber())
-		if pageNumber < 1 {
-			return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("ページ番号%vが無効です", setting.GetPageNumber()))
-		}
-		areas := setting.GetAreas()
-		if len(areas) == 0 {
-			continue
-		}
-		normalizedOverride, errNormalize := normalizeAreas(areas)
-		if errNormalize != nil {
-			return connect.NewError(connect.CodeInvalidArgument, errNormalize)
-		}
-		if len(normalizedOverride) == 0 {
-			continue
-		}
-		pageOverrides[pageNumber] = normalizedOverride
+	split, err := segmentSplitFromRequest(req.Msg.GetSplit())
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
 	}
-
-	if len(defaultAreas) == 0 && len(pageOverrides) == 0 {
-		return connect.NewError(connect.CodeInvalidArgument, errors.New("トリミングエリアがありません"))
+	strip, err := stripLayoutFromRequest(req.Msg)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	// 段階3: PDF処理開始
@@ -328,26 +392,19 @@ func (s *scoreService) TrimScoreWithProgress(
 		req.Msg.GetIncludePages(),
 		req.Msg.GetPassword(),
 		pageOverrides,
+		split,
+		strip,
 		req.Msg.GetOrientation(),
-		stream,
+		s.cfg.pdfLimits(),
+		stream.Send,
 		lang,
 	)
 	if err != nil {
-		if errors.Is(err, pdfcpu.ErrWrongPassword) {
-			return connect.NewError(connect.CodeInvalidArgument, errors.New("PDFのパスワードが正しくありません"))
-		}
-		return connect.NewError(connect.CodeInternal, err)
+		return trimError(err)
 	}
 
 	// 段階4: 完了
-	filename := deriveFilename(req.Msg.GetTitle())
-	orientationSuffix := ""
-	if req.Msg.GetOrientation() == "landscape" {
-		orientationSuffix = "-landscape"
-	}
-	if orientationSuffix != "" {
-		filename = strings.Replace(filename, ".pdf", orientationSuffix+".pdf", 1)
-	}
+	filename := trimmedFilename(req.Msg.GetTitle(), req.Msg.GetOrientation())
 
 	if err := stream.Send(&score.TrimScoreProgressResponse{
 		Stage:       "complete",
@@ -370,12 +427,37 @@ func (s *scoreService) SearchYoutubeVideos(
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("YouTube検索機能は削除されました"))
 }
 
-// GenerateScrollVideo は削除された機能のスタブ
+// GenerateScrollVideo はBPMに合わせて横スクロールする動画を生成します。
+// 時間のかかる動画はSubmitVideoJobで非同期に生成してください。
 func (s *scoreService) GenerateScrollVideo(
 	ctx context.Context,
 	req *connect.Request[score.GenerateScrollVideoRequest],
 ) (*connect.Response[score.GenerateScrollVideoResponse], error) {
-	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("動画生成機能は削除されました"))
+	opts, err := videoOptionsFromRequest(req.Msg, s.cfg.videoLimits())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if len(req.Msg.GetPdfFile()) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("PDFファイルが空です"))
+	}
+
+	video, err := renderScrollVideo(ctx, req.Msg.GetPdfFile(), opts, s.cfg.pdfLimits(), func(*score.TrimScoreProgressResponse) error {
+		return nil
+	})
+	if err != nil {
+		return nil, trimError(err)
+	}
+	defer os.Remove(video.path)
+	data, err := os.ReadFile(video.path)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&score.GenerateScrollVideoResponse{
+		Message:         "動画を生成しました",
+		VideoData:       data,
+		Filename:        video.filename,
+		DurationSeconds: int32(video.durationSeconds),
+	}), nil
 }
 
 func clamp(value, min, max float64) float64 {
@@ -443,10 +525,11 @@ func normalizeAreas(areas []*score.CropArea) ([]normalizedArea, error) {
 		}
 
 		normalized = append(normalized, normalizedArea{
-			top:    top,
-			left:   left,
-			width:  width,
-			height: height,
+			top:          top,
+			left:         left,
+			width:        width,
+			height:       height,
+			joinWithNext: area.GetJoinWithNext(),
 		})
 	}
 
@@ -464,29 +547,80 @@ func normalizeAreas(areas []*score.CropArea) ([]normalizedArea, error) {
 	return normalized, nil
 }
 
+// trimAreasFromRequest はリクエストから全ページ共通とページごとのトリミングエリアを取り出します
+func trimAreasFromRequest(msg *score.TrimScoreRequest) ([]normalizedArea, map[int][]normalizedArea, error) {
+	defaultAreas, err := normalizeAreas(msg.GetAreas())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pageOverrides := make(map[int][]normalizedArea)
+	for _, setting := range msg.GetPageSettings() {
+		if setting == nil {
+			continue
+		}
+		pageNumber := int(setting.GetPageNumber())
+		if pageNumber < 1 {
+			return nil, nil, fmt.Errorf("ページ番号%vが無効です", setting.GetPageNumber())
+		}
+		areas := setting.GetAreas()
+		if len(areas) == 0 {
+			continue
+		}
+		normalizedOverride, err := normalizeAreas(areas)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(normalizedOverride) == 0 {
+			continue
+		}
+		pageOverrides[pageNumber] = normalizedOverride
+	}
+
+	if len(defaultAreas) == 0 && len(pageOverrides) == 0 {
+		return nil, nil, errors.New("トリミングエリアがありません")
+	}
+	return defaultAreas, pageOverrides, nil
+}
+
+// trimError はPDF処理のエラーをconnectのエラーに変換します
+func trimError(err error) error {
+	var connectErr *connect.Error
+	switch {
+	case errors.As(err, &connectErr):
+		return err
+	case errors.Is(err, pdfcpu.ErrWrongPassword):
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("PDFのパスワードが正しくありません"))
+	case errors.Is(err, errPDFLimitExceeded):
+		return connect.NewError(connect.CodeResourceExhausted, err)
+	case errors.Is(err, errInvalidJoin), errors.Is(err, errVideoTooLong), errors.Is(err, errVideoTooLarge):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	default:
+		return connect.NewError(connect.CodeInternal, err)
+	}
+}
+
+// progressSender は処理の進捗を通知します。ストリームのSendやジョブの状態更新を渡します。
+type progressSender func(*score.TrimScoreProgressResponse) error
+
 func buildTrimmedPDF(
 	pdfBytes []byte,
 	defaultAreas []normalizedArea,
 	includePages []int32,
 	password string,
 	pageOverrides map[int][]normalizedArea,
+	split segmentSplit,
+	strip stripLayout,
+	limits pdfLimits,
 ) ([]byte, error) {
 	if len(defaultAreas) == 0 && len(pageOverrides) == 0 {
 		return nil, errors.New("トリミングエリアがありません")
 	}
 
-	conf := model.NewDefaultConfiguration()
-	if password != "" {
-		conf.UserPW = password
-		conf.OwnerPW = password
-	}
-	ctx, err := pdfapi.ReadValidateAndOptimize(bytes.NewReader(pdfBytes), conf)
+	ctx, err := readPDFContext(pdfBytes, password, limits)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.EnsurePageCount(); err != nil {
-		return nil, err
-	}
 
 	if ctx.PageCount == 0 {
 		return nil, errors.New("PDFにページがありません")
@@ -503,7 +637,7 @@ func buildTrimmedPDF(
 		}
 	}
 
-	var segments [][]byte
+	builder := newSegmentBuilder(ctx, split)
 
 	for _, pageIndex := range pagesToProcess {
 		areasForPage := pageOverrides[pageIndex]
@@ -533,14 +667,21 @@ func buildTrimmedPDF(
 				return nil, err
 			}
 
-			trimmed, err := extractTrimmedSegment(ctx, pageIndex, rect)
-			if err != nil {
+			if err := builder.add(pageIndex, rect, area.joinWithNext); err != nil {
 				return nil, err
 			}
-			segments = append(segments, trimmed)
 		}
 	}
 
+	segments, err := builder.finish()
+	if err != nil {
+		return nil, err
+	}
+	segments, err = strip.arrange(segments)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(segments) == 0 {
 		return nil, errors.New("トリミング後のページを生成できませんでした")
 	}
@@ -570,8 +711,11 @@ func buildTrimmedPDFWithProgress(
 	includePages []int32,
 	password string,
 	pageOverrides map[int][]normalizedArea,
+	split segmentSplit,
+	strip stripLayout,
 	orientation string,
-	stream *connect.ServerStream[score.TrimScoreProgressResponse],
+	limits pdfLimits,
+	send progressSender,
 	lang string,
 ) ([]byte, error) {
 	if len(defaultAreas) == 0 && len(pageOverrides) == 0 {
@@ -579,7 +723,7 @@ func buildTrimmedPDFWithProgress(
 	}
 
 	// PDFコンテキスト作成
-	if err := stream.Send(&score.TrimScoreProgressResponse{
+	if err := send(&score.TrimScoreProgressResponse{
 		Stage:    "processing",
 		Progress: 45,
 		Message:  "PDFを解析しています...",
@@ -587,25 +731,17 @@ func buildTrimmedPDFWithProgress(
 		return nil, err
 	}
 
-	conf := model.NewDefaultConfiguration()
-	if password != "" {
-		conf.UserPW = password
-		conf.OwnerPW = password
-	}
-	ctx, err := pdfapi.ReadValidateAndOptimize(bytes.NewReader(pdfBytes), conf)
+	ctx, err := readPDFContext(pdfBytes, password, limits)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.EnsurePageCount(); err != nil {
-		return nil, err
-	}
 
 	if ctx.PageCount == 0 {
 		return nil, errors.New("PDFにページがありません")
 	}
 
 	// ページ範囲解決
-	if err := stream.Send(&score.TrimScoreProgressResponse{
+	if err := send(&score.TrimScoreProgressResponse{
 		Stage:    "processing",
 		Progress: 50,
 		Message:  "処理対象ページを決定しています...",
@@ -624,13 +760,13 @@ func buildTrimmedPDFWithProgress(
 		}
 	}
 
-	var segments [][]byte
+	builder := newSegmentBuilder(ctx, split)
 	totalPages := len(pagesToProcess)
 
 	// 各ページを処理
 	for i, pageIndex := range pagesToProcess {
 		progress := 55 + int(float64(i)/float64(totalPages)*25) // 55-80%の範囲
-		if err := stream.Send(&score.TrimScoreProgressResponse{
+		if err := send(&score.TrimScoreProgressResponse{
 			Stage:    "processing",
 			Progress: int32(progress),
 			Message:  fmt.Sprintf("ページ %d/%d を処理しています...", i+1, totalPages),
@@ -665,20 +801,27 @@ func buildTrimmedPDFWithProgress(
 				return nil, err
 			}
 
-			trimmed, err := extractTrimmedSegment(ctx, pageIndex, rect)
-			if err != nil {
+			if err := builder.add(pageIndex, rect, area.joinWithNext); err != nil {
 				return nil, err
 			}
-			segments = append(segments, trimmed)
 		}
 	}
 
+	segments, err := builder.finish()
+	if err != nil {
+		return nil, err
+	}
+	segments, err = strip.arrange(segments)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(segments) == 0 {
 		return nil, errors.New("トリミング後のページを生成できませんでした")
 	}
 
 	// PDF生成
-	if err := stream.Send(&score.TrimScoreProgressResponse{
+	if err := send(&score.TrimScoreProgressResponse{
 		Stage:    "generating",
 		Progress: 85,
 		Message:  getLocalizedMessage("generating_pdf", lang),
@@ -705,7 +848,7 @@ func buildTrimmedPDFWithProgress(
 
 	// 横向き変換
 	if orientation == "landscape" {
-		if err := stream.Send(&score.TrimScoreProgressResponse{
+		if err := send(&score.TrimScoreProgressResponse{
 			Stage:    "generating",
 			Progress: 95,
 			Message:  "スライド形式に変換しています...",
@@ -836,30 +979,43 @@ func deriveFilename(title string) string {
 	return fmt.Sprintf("%s-trimmed.pdf", sanitized)
 }
 
+// trimmedFilename は出力の向きを反映したトリミング結果のファイル名を返します
+func trimmedFilename(title, orientation string) string {
+	filename := deriveFilename(title)
+	if orientation == "landscape" {
+		filename = strings.Replace(filename, ".pdf", "-landscape.pdf", 1)
+	}
+	return filename
+}
+
 // CORSミドルウェアを追加
-func corsMiddleware(next http.Handler) http.Handler {
+func corsMiddleware(cfg *serverConfig, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		origin := r.Header.Get("Origin")
+		if origin != "" && !cfg.originAllowed(origin) {
+			log.Printf("rejected CORS origin: %s", origin)
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if slices.Contains(cfg.AllowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Connect-Protocol-Version")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Connect-Protocol-Version, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxRequestBytes)
+
 		if strings.Contains(r.URL.Path, "TrimScore") {
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err == nil {
-				sample := string(bodyBytes)
-				if len(sample) > 256 {
-					sample = sample[:256]
-				}
-				log.Printf("TrimScore raw request: %s", sample)
-				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-			} else {
-				log.Printf("TrimScore raw request read error: %v", err)
-			}
+			log.Printf("TrimScore raw request: contentType=%s contentLength=%d", r.Header.Get("Content-Type"), r.ContentLength)
 		}
 
 		next.ServeHTTP(w, r)
@@ -867,23 +1023,108 @@ func corsMiddleware(next http.Handler) http.Handler {
 }
 
 func main() {
-	mux := http.NewServeMux()
+	// サブコマンドが無い場合（フラグのみを含む）は従来どおりサーバーを起動する
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runCLI(os.Args[1], os.Args[2:]))
+	}
+	serve(os.Args[1:])
+}
 
-	// ヘルスチェックエンドポイント
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok","service":"score-splitter-backend"}`))
-	})
+// serve はHTTPサーバーを起動します
+func serve(args []string) {
+	cfg, err := loadConfig(args, os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
+	storage, err := newBlobStorage(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	log.Printf("storage: %s", storage.Describe())
+
+	rasterizer, err := selectRasterizer(cfg.Rasterizer)
+	if err != nil {
+		log.Fatalf("failed to select rasterizer: %v", err)
+	}
+	useRasterizer(rasterizer)
+	log.Printf("rasterizer: %s (available: %s)", rasterizer.Name(), strings.Join(availableRasterizers(), ", "))
 
 	// 2つの値（パスとハンドラ）を受け取る
-	path, handler := scoreconnect.NewScoreServiceHandler(&scoreService{})
-	mux.Handle(path, corsMiddleware(handler))
+	var interceptors []connect.Interceptor
+	if cfg.Auth.enabled() {
+		interceptors = append(interceptors, &authInterceptor{auth: newAuthenticator(cfg.Auth)})
+	} else {
+		log.Println("WARNING: authentication is disabled; configure auth.api_keys or jwt-secret to require credentials")
+	}
+	admission := newAdmissionController(cfg)
+	interceptors = append(interceptors, &admissionInterceptor{
+		admission:    admission,
+		trustProxies: cfg.TrustProxyHeaders,
+	})
 
-	log.Println("listening on :8085")
-	if err := http.ListenAndServe(":8085", mux); err != nil {
+	service, err := newScoreService(cfg, storage, admission)
+	if err != nil {
+		log.Fatalf("failed to initialize service: %v", err)
+	}
+	path, handler := scoreconnect.NewScoreServiceHandler(
+		service,
+		connect.WithReadMaxBytes(int(cfg.MaxMessageBytes)),
+		connect.WithInterceptors(interceptors...),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(path, corsMiddleware(cfg, handler))
+
+	// ヘルスチェックエンドポイント。/health は常に200、/ready は必須の依存が無ければ503を返します
+	health := newHealthChecker(storage, admission, service.jobs, rasterizer)
+	mux.HandleFunc("/health", health.serveHealth)
+	mux.HandleFunc("/ready", health.serveReady)
+	if report := health.report(context.Background()); !report.ready() {
+		log.Printf("WARNING: not ready: %s", strings.Join(report.Problems, ", "))
+	}
+
+	server := &http.Server{
+		Addr:    cfg.Addr,
+		Handler: mux,
+	}
+
+	// SIGINT/SIGTERMを受けたら新しい接続の受け付けを止め、処理中のリクエストとジョブの終了を待つ
+	stopCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	go service.uploads.sweepLoop(stopCtx)
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.tlsEnabled() {
+			log.Printf("listening on %s (TLS)", cfg.Addr)
+			serveErr <- server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			log.Printf("listening on %s", cfg.Addr)
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-serveErr:
 		log.Fatalf("server stopped: %v", err)
+	case <-stopCtx.Done():
+	}
+	stopSignals()
+
+	log.Printf("shutting down (waiting up to %v for requests and jobs)", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	// WatchJobのストリームはジョブの停止で閉じるので、サーバーと並行して止める
+	jobsDone := make(chan error, 1)
+	go func() { jobsDone <- service.jobs.shutdown(shutdownCtx) }()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	if err := <-jobsDone; err != nil {
+		log.Printf("jobs shutdown: %v", err)
 	}
+	log.Printf("server stopped")
 }
 
 // rotatePDFToLandscape はトリミング済みページを横向きスライド形式のPDFに変換します
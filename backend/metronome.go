@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+
+	score "score-splitter/backend/gen/go"
+)
+
+const (
+	// maxCountInBars はカウントインの小節数の上限です
+	maxCountInBars = 4
+	// clickSampleRate はクリック音のWAVのサンプリング周波数です
+	clickSampleRate = 44100
+	// clickSeconds は1回のクリック音の長さです
+	clickSeconds = 0.03
+	// 小節の頭は高い音で鳴らします
+	accentFrequency = 1760
+	clickFrequency  = 1320
+)
+
+// metronomeOptions は検証済みのメトロノームの設定です
+type metronomeOptions struct {
+	countInBars int
+	volume      float64
+	// meter は timing が pixels のときとカウントインの1小節の拍数です
+	meter int
+}
+
+// metronomeFromRequest はメトロノームの設定を検証します。指定がなければnilを返します。
+func metronomeFromRequest(msg *score.GenerateScrollVideoRequest) (*metronomeOptions, error) {
+	m := msg.GetMetronome()
+	if m == nil {
+		return nil, nil
+	}
+	signature := msg.GetTimeSignature()
+	if signature == "" {
+		signature = defaultTimeSignature
+	}
+	meter, err := parseTimeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	opts := &metronomeOptions{
+		countInBars: int(m.GetCountInBars()),
+		volume:      m.GetVolume(),
+		meter:       int(meter),
+	}
+	if opts.countInBars < 0 || opts.countInBars > maxCountInBars {
+		return nil, fmt.Errorf("カウントインの小節数%dは0から%dの範囲で指定してください", opts.countInBars, maxCountInBars)
+	}
+	if opts.volume == 0 {
+		opts.volume = 1
+	}
+	if opts.volume < 0 || opts.volume > 1 {
+		return nil, fmt.Errorf("メトロノームの音量%vは0から1の範囲で指定してください", opts.volume)
+	}
+	return opts, nil
+}
+
+// click はクリック音を鳴らす時刻です
+type click struct {
+	at     float64
+	accent bool
+}
+
+// metronomeClicks はタイムラインの各拍でクリック音を鳴らす時刻を返します。
+// 拍はセグメントの頭から数え、小節の頭を強く鳴らします。カウントインがあればその分だけタイムラインを遅らせます。
+// 動画の長さの上限より後の拍は鳴らすことがないので数えません。
+func metronomeClicks(opts videoOptions, timeline *scrollTimeline) []click {
+	m := opts.metronome
+	if m == nil {
+		return nil
+	}
+	// timing が beats のときは beatMap の knot がセグメントごとにあるので、セグメントの拍子で数えます
+	meters := []int{m.meter}
+	if opts.timing == videoTimingBeats {
+		meters = opts.segmentMeters
+	}
+
+	endBeat := timeline.beats.beatAt(timeline.position(timeline.end))
+	var clicks []click
+	for i, k := range timeline.beats {
+		next := endBeat
+		if i+1 < len(timeline.beats) {
+			next = math.Min(timeline.beats[i+1].beat, endBeat)
+		}
+		for n := 0; k.beat+float64(n) < next-1e-6; n++ {
+			at := timeline.timeAt(k.x + float64(n)*k.pxPerBeat)
+			if at > float64(opts.limits.maxSeconds) {
+				break
+			}
+			clicks = append(clicks, click{at: at, accent: n%meters[i] == 0})
+		}
+	}
+
+	if m.countInBars > 0 {
+		interval := 60 / float64(startBPM(opts))
+		countIn := make([]click, m.countInBars*meters[0])
+		for n := range countIn {
+			countIn[n] = click{at: float64(n) * interval, accent: n%meters[0] == 0}
+		}
+		lead := float64(len(countIn)) * interval
+		for i := range clicks {
+			clicks[i].at += lead
+		}
+		clicks = append(countIn, clicks...)
+		timeline.delay(lead)
+	}
+	return clicks
+}
+
+// startBPM は楽譜の先頭でのBPMを返します。テンポマップで先頭のBPMが指定されていればそれを使います。
+func startBPM(opts videoOptions) int {
+	for _, c := range opts.tempo {
+		if c.segment != 0 || c.position != 0 {
+			break
+		}
+		if c.bpm > 0 {
+			return c.bpm
+		}
+	}
+	return opts.bpm
+}
+
+// maxClickTrackSeconds はWAVのヘッダーに書けるクリック音の長さの上限です（データの大きさは32bitで表します）
+const maxClickTrackSeconds = (math.MaxUint32 - 36) / (clickSampleRate * 2)
+
+// writeClickTrack は clicks の時刻にクリック音を鳴らす、長さ duration 秒のWAV（16bit モノラル）を書き出します。
+// 全体をメモリに置かないように1秒ずつ書き出します。clicks は時刻の順に並んでいる必要があります。
+func writeClickTrack(path string, clicks []click, duration int, volume float64) error {
+	if duration < 0 || duration > maxClickTrackSeconds {
+		return fmt.Errorf("クリック音の長さ%d秒が上限の%d秒を超えています", duration, maxClickTrackSeconds)
+	}
+	total := duration * clickSampleRate
+	length := int(clickSeconds * clickSampleRate)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	dataSize := uint32(total * 2)
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataSize, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(1),
+		uint32(clickSampleRate), uint32(clickSampleRate * 2), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataSize,
+	}
+	for _, v := range header {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	block := make([]int16, clickSampleRate)
+	next := 0 // まだ書き終えていない最初のクリック音
+	for offset := 0; offset < total; offset += len(block) {
+		samples := block[:min(len(block), total-offset)]
+		clear(samples)
+		for next < len(clicks) && int(math.Round(clicks[next].at*clickSampleRate))+length <= offset {
+			next++
+		}
+		for _, c := range clicks[next:] {
+			start := int(math.Round(c.at * clickSampleRate))
+			if start >= offset+len(samples) {
+				break
+			}
+			freq, gain := float64(clickFrequency), 0.6
+			if c.accent {
+				freq, gain = accentFrequency, 1.0
+			}
+			for i := max(0, offset-start); i < length && start+i < offset+len(samples); i++ {
+				t := float64(i) / clickSampleRate
+				// すぐに減衰する正弦波にして、音楽と重なっても聞き取りやすい短い音にします
+				v := gain * volume * math.Sin(2*math.Pi*freq*t) * math.Exp(-t*5/clickSeconds)
+				samples[start+i-offset] = int16(v * 0.9 * math.MaxInt16)
+			}
+		}
+		if err := binary.Write(w, binary.LittleEndian, samples); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
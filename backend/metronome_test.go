@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteClickTrack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "click.wav")
+	// 2つ目のクリック音は1秒ごとに書き出す区切りをまたぐ
+	clicks := []click{{at: 0, accent: true}, {at: 0.99}}
+	if err := writeClickTrack(path, clicks, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 44+2*2*clickSampleRate {
+		t.Fatalf("file size = %d, want %d", len(data), 44+2*2*clickSampleRate)
+	}
+	if got := binary.LittleEndian.Uint32(data[40:44]); got != 2*2*clickSampleRate {
+		t.Errorf("data size = %d", got)
+	}
+	sample := func(i int) int16 {
+		return int16(binary.LittleEndian.Uint16(data[44+2*i:]))
+	}
+	// 書き出した値は1つずつ計算した値と一致する
+	want := func(c click, i int) int16 {
+		freq, gain := float64(clickFrequency), 0.6
+		if c.accent {
+			freq, gain = accentFrequency, 1.0
+		}
+		t := float64(i) / clickSampleRate
+		return int16(gain * math.Sin(2*math.Pi*freq*t) * math.Exp(-t*5/clickSeconds) * 0.9 * math.MaxInt16)
+	}
+	length := int(clickSeconds * clickSampleRate)
+	for _, c := range clicks {
+		start := int(math.Round(c.at * clickSampleRate))
+		for i := range length {
+			if got := sample(start + i); got != want(c, i) {
+				t.Fatalf("sample %d of click at %v = %d, want %d", i, c.at, got, want(c, i))
+			}
+		}
+	}
+	if got := sample(clickSampleRate / 2); got != 0 {
+		t.Errorf("silence sample = %d", got)
+	}
+}
+
+func TestWriteClickTrackRejectsOverflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "click.wav")
+	// データの大きさがWAVのヘッダーの32bitに収まらない長さは書き出さない
+	if err := writeClickTrack(path, nil, maxClickTrackSeconds+1, 1); err == nil {
+		t.Fatal("writeClickTrack accepted a duration that overflows the WAV header")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("click track was created")
+	}
+}
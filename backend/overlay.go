@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+)
+
+const (
+	defaultPlayheadPosition = 0.25
+	minPlayheadPosition     = 0.05
+	maxPlayheadPosition     = 0.95
+	// overlayFont はテキストの表示に使うフォントです。タイトルに日本語を含むことが多いため、CJKのフォントを指定します。
+	overlayFont   = "Noto Sans CJK JP"
+	playheadColor = "0xE53935@0.85"
+	progressColor = "0x1E88E5"
+)
+
+// overlayOptions は検証済みの動画に重ねて表示するものの設定です
+type overlayOptions struct {
+	// playhead は縦線の画面の幅に対する位置です。0なら表示しません。
+	playhead    float64
+	progressBar bool
+	showTime    bool
+	showTitle   bool
+}
+
+// overlayFromRequest は動画に重ねて表示するものの設定を検証します
+func overlayFromRequest(msg *score.GenerateScrollVideoRequest, mode string) (overlayOptions, error) {
+	o := msg.GetOverlay()
+	if o == nil {
+		return overlayOptions{}, nil
+	}
+	opts := overlayOptions{
+		progressBar: o.GetProgressBar(),
+		showTime:    o.GetShowTime(),
+		showTitle:   o.GetShowTitle(),
+	}
+	if !o.GetPlayhead() {
+		if o.GetPlayheadPosition() != 0 {
+			return opts, errors.New("playhead_position は playhead と一緒に指定してください")
+		}
+		return opts, nil
+	}
+	if mode != videoModeScroll {
+		return opts, errors.New("再生位置の縦線は mode が scroll のときだけ表示できます")
+	}
+	opts.playhead = o.GetPlayheadPosition()
+	if opts.playhead == 0 {
+		opts.playhead = defaultPlayheadPosition
+	}
+	if opts.playhead < minPlayheadPosition || opts.playhead > maxPlayheadPosition {
+		return opts, fmt.Errorf("縦線の位置%vは%vから%vの範囲で指定してください", opts.playhead, minPlayheadPosition, maxPlayheadPosition)
+	}
+	return opts, nil
+}
+
+// playheadX は縦線の画面上のx座標を返します。縦線を表示しない場合は0です。
+func (o overlayOptions) playheadX(width int) int {
+	return int(float64(width) * o.playhead)
+}
+
+// overlayFilter は動画に重ねて表示するもののffmpegのフィルタを返します。
+// 返す文字列は "," から始まり、フィルタの後ろにそのままつなげられます。表示するものが無ければ空を返します。
+// テキストはエスケープを避けるためworkDirにファイルで書き出します。
+func overlayFilter(opts videoOptions, duration int, workDir string) (string, error) {
+	o := opts.overlay
+	var b strings.Builder
+	fontSize := opts.height / 24
+	margin := opts.height / 40
+	barHeight := max(4, opts.height/120)
+
+	if o.playhead > 0 {
+		fmt.Fprintf(&b, ",drawbox=x=%d:y=0:w=%d:h=ih:color=%s:t=fill", o.playheadX(opts.width), max(2, opts.width/640), playheadColor)
+	}
+	if o.showTitle && opts.title != "" {
+		path := filepath.Join(workDir, "title.txt")
+		if err := os.WriteFile(path, []byte(opts.title), 0600); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, ",drawtext=font='%s':textfile='%s':expansion=none:fontsize=%d:fontcolor=black:x=%d:y=%d:box=1:boxcolor=white@0.8:boxborderw=%d",
+			overlayFont, filterPath(path), fontSize, margin, margin, margin/2)
+	}
+	if o.showTime {
+		// 経過時間は描画するフレームの時刻から、動画の長さは固定の文字列で表示します
+		text := fmt.Sprintf("%%{eif:floor(t/60):d:2}:%%{eif:mod(floor(t),60):d:2} / %02d:%02d", duration/60, duration%60)
+		path := filepath.Join(workDir, "time.txt")
+		if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, ",drawtext=font='%s':textfile='%s':expansion=normal:fontsize=%d:fontcolor=black:x=w-tw-%d:y=h-th-%d:box=1:boxcolor=white@0.8:boxborderw=%d",
+			overlayFont, filterPath(path), fontSize*3/4, margin, margin+barHeight, margin/2)
+	}
+	if o.progressBar {
+		// drawboxの幅は時刻で変えられないため、バーの色の映像を時刻に合わせて右へずらして重ねます
+		fmt.Fprintf(&b, ",drawbox=x=0:y=ih-%d:w=iw:h=%d:color=black@0.25:t=fill[ovbase];", barHeight, barHeight)
+		fmt.Fprintf(&b, "color=c=%s:s=%dx%d:r=%d[ovbar];", progressColor, opts.width, barHeight, opts.fps)
+		fmt.Fprintf(&b, "[ovbase][ovbar]overlay=x='-w+W*min(t/%d,1)':y=H-h:eval=frame:shortest=1", duration)
+	}
+	return b.String(), nil
+}
+
+// filterPath はffmpegのフィルタの引数に ' で囲んで書けるようにパスの : をエスケープします。
+// パスは作業用の一時ディレクトリの中なので ' は含みません。
+func filterPath(path string) string {
+	return strings.ReplaceAll(filepath.ToSlash(path), ":", `\:`)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverlayFilter(t *testing.T) {
+	dir := t.TempDir()
+	titlePath := filterPath(filepath.Join(dir, "title.txt"))
+	timePath := filterPath(filepath.Join(dir, "time.txt"))
+	tests := []struct {
+		name    string
+		overlay overlayOptions
+		title   string
+		want    string
+	}{
+		{name: "nothing", overlay: overlayOptions{}, want: ""},
+		{
+			name:    "playhead",
+			overlay: overlayOptions{playhead: 0.25},
+			want:    ",drawbox=x=480:y=0:w=3:h=ih:color=0xE53935@0.85:t=fill",
+		},
+		{
+			name:    "title",
+			overlay: overlayOptions{showTitle: true},
+			title:   "きらきら星: 変奏曲",
+			want: ",drawtext=font='Noto Sans CJK JP':textfile='" + titlePath + "':expansion=none:fontsize=45:fontcolor=black" +
+				":x=27:y=27:box=1:boxcolor=white@0.8:boxborderw=13",
+		},
+		{name: "empty title", overlay: overlayOptions{showTitle: true}, want: ""},
+		{
+			// 経過時間は進捗バーの上に表示する
+			name:    "time",
+			overlay: overlayOptions{showTime: true},
+			want: ",drawtext=font='Noto Sans CJK JP':textfile='" + timePath + "':expansion=normal:fontsize=33:fontcolor=black" +
+				":x=w-tw-27:y=h-th-36:box=1:boxcolor=white@0.8:boxborderw=13",
+		},
+		{
+			name:    "progress bar",
+			overlay: overlayOptions{progressBar: true},
+			want: ",drawbox=x=0:y=ih-9:w=iw:h=9:color=black@0.25:t=fill[ovbase];" +
+				"color=c=0x1E88E5:s=1920x9:r=30[ovbar];" +
+				"[ovbase][ovbar]overlay=x='-w+W*min(t/125,1)':y=H-h:eval=frame:shortest=1",
+		},
+		{
+			// 進捗バーは他のものを描いた後に重ねる
+			name:    "playhead and progress bar",
+			overlay: overlayOptions{playhead: 0.5, progressBar: true},
+			want: ",drawbox=x=960:y=0:w=3:h=ih:color=0xE53935@0.85:t=fill" +
+				",drawbox=x=0:y=ih-9:w=iw:h=9:color=black@0.25:t=fill[ovbase];" +
+				"color=c=0x1E88E5:s=1920x9:r=30[ovbar];" +
+				"[ovbase][ovbar]overlay=x='-w+W*min(t/125,1)':y=H-h:eval=frame:shortest=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := videoOptions{width: 1920, height: 1080, fps: 30, title: tt.title, overlay: tt.overlay}
+			got, err := overlayFilter(opts, 125, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("overlayFilter =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlayFilterTextFiles(t *testing.T) {
+	dir := t.TempDir()
+	opts := videoOptions{width: 1280, height: 720, fps: 30, title: "Op. 10: 'Étude'", overlay: overlayOptions{showTitle: true, showTime: true}}
+	if _, err := overlayFilter(opts, 3599, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// タイトルはエスケープせずにそのまま書き出し、時刻はdrawtextの式で経過時間を表示する
+	tests := map[string]string{
+		"title.txt": "Op. 10: 'Étude'",
+		"time.txt":  "%{eif:floor(t/60):d:2}:%{eif:mod(floor(t),60):d:2} / 59:59",
+	}
+	for name, want := range tests {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestFilterPath(t *testing.T) {
+	if got := filterPath("/tmp/a:b/title.txt"); got != `/tmp/a\:b/title.txt` {
+		t.Errorf("filterPath = %q", got)
+	}
+}
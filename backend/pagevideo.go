@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	// maxSegmentsPerPage は1画面に表示できるセグメント数の上限です
+	maxSegmentsPerPage = 2
+	// maxCrossfadeSeconds はクロスフェードの秒数の上限です
+	maxCrossfadeSeconds = 5
+	// previewHeightRatio は次のセグメントのプレビューに使う画面の高さの割合です
+	previewHeightRatio = 0.2
+	// pageMarginRatio はセグメントの周りの余白の割合です（画面の短辺に対して）
+	pageMarginRatio = 0.02
+)
+
+// previewColor はプレビューとの境目の線の色です
+var previewColor = color.Gray{Y: 0xC0}
+
+// renderPagedVideo はセグメントを1画面に segmentsPerPage 個ずつ表示し、
+// 表示中のセグメントの拍数が終わったら次の画面に切り替える動画を生成して、動画の秒数を返します。
+// 切り替えの時刻は横スクロールと同じタイムラインで、画面の最初のセグメントに着いた時刻です。
+func renderPagedVideo(ctx context.Context, pages []string, workDir, outPath string, opts videoOptions, onProgress func(float64) error) (int, error) {
+	sizes, err := pngSizes(pages)
+	if err != nil {
+		return 0, err
+	}
+	segments, width := segmentPositions(sizes, opts.height)
+	beats, err := videoBeatMap(opts, segments)
+	if err != nil {
+		return 0, err
+	}
+	timeline, err := newScrollTimeline(opts.bpm, opts.tempo, segments, beats, float64(width))
+	if err != nil {
+		return 0, err
+	}
+	duration, audio, err := prepareAudio(ctx, opts, timeline, workDir)
+	if err != nil {
+		return 0, err
+	}
+	if err := videoFormats[opts.format].checkDuration(opts, duration); err != nil {
+		return 0, err
+	}
+	overlay, err := overlayFilter(opts, duration, workDir)
+	if err != nil {
+		return 0, err
+	}
+
+	var frames []string
+	var starts []float64
+	for first := 0; first < len(pages); first += opts.segmentsPerPage {
+		start := timeline.timeAt(float64(segments[first].x))
+		if first == 0 {
+			start = 0
+		} else if start >= float64(duration) {
+			// 動画の長さを伴奏に合わせて短くした場合、それより後の画面は表示されません
+			break
+		}
+		// ページの画像は画面ごとに読み込み、全てを同時にメモリに置かないようにします
+		last := min(first+opts.segmentsPerPage, len(pages))
+		images, err := decodePNGs(pages[first:last])
+		if err != nil {
+			return 0, err
+		}
+		var preview image.Image
+		if opts.previewNext && last < len(pages) {
+			if preview, err = decodePNG(pages[last]); err != nil {
+				return 0, err
+			}
+		}
+		path := filepath.Join(workDir, fmt.Sprintf("frame-%04d.png", len(frames)+1))
+		if err := renderPageFrame(path, images, preview, opts.width, opts.height); err != nil {
+			return 0, err
+		}
+		frames = append(frames, path)
+		starts = append(starts, start)
+	}
+
+	if fade := crossfadeSeconds(starts, opts.crossfade); fade > 0 && len(frames) > 1 {
+		err = encodeCrossfadeVideo(ctx, frames, starts, fade, audio, outPath, opts, overlay, duration, onProgress)
+	} else {
+		// 同じ時刻に始まる画面があってクロスフェードする時間が無い場合も、切り替えでつなぎます
+		err = encodeCutVideo(ctx, frames, starts, workDir, audio, outPath, opts, overlay, duration, onProgress)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
+
+// renderPageFrame は1画面分の画像を書き出します。セグメントは上から順に同じ高さの枠に収め、
+// preview があれば画面の下に薄く表示します。
+func renderPageFrame(path string, segments []image.Image, preview image.Image, width, height int) error {
+	frame := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	margin := int(math.Round(float64(min(width, height)) * pageMarginRatio))
+
+	area := frame.Bounds()
+	if preview != nil {
+		top := height - int(float64(height)*previewHeightRatio)
+		area.Max.Y = top
+		previewArea := image.Rect(0, top, width, height)
+		drawFitted(frame, previewArea.Inset(margin), preview)
+		// 次のセグメントだと分かるように薄くして、境目に線を引きます
+		draw.Draw(frame, previewArea, image.NewUniform(color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0x99}), image.Point{}, draw.Over)
+		draw.Draw(frame, image.Rect(0, top, width, top+max(1, height/540)), image.NewUniform(previewColor), image.Point{}, draw.Src)
+	}
+
+	slot := area.Dy() / len(segments)
+	for i, img := range segments {
+		r := image.Rect(0, area.Min.Y+i*slot, width, area.Min.Y+(i+1)*slot)
+		drawFitted(frame, r.Inset(margin), img)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, frame); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// drawFitted はsrcの縦横比を保ったままrに収まる大きさで中央に描画します
+func drawFitted(dst *image.RGBA, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if r.Empty() || sb.Empty() {
+		return
+	}
+	scale := math.Min(float64(r.Dx())/float64(sb.Dx()), float64(r.Dy())/float64(sb.Dy()))
+	w := int(math.Round(float64(sb.Dx()) * scale))
+	h := int(math.Round(float64(sb.Dy()) * scale))
+	x := r.Min.X + (r.Dx()-w)/2
+	y := r.Min.Y + (r.Dy()-h)/2
+	// 縮小すると細い五線や符尾が消えやすいので、最近傍法ではなく補間して描画します
+	xdraw.CatmullRom.Scale(dst, image.Rect(x, y, x+w, y+h), src, sb, draw.Over, nil)
+}
+
+// encodeCutVideo は各画面をその表示時間だけ並べ、切り替えでつなぐ動画をエンコードします
+func encodeCutVideo(ctx context.Context, frames []string, starts []float64, workDir string, audio []videoAudio, outPath string, opts videoOptions, overlay string, duration int, onProgress func(float64) error) error {
+	listPath := filepath.Join(workDir, "frames.txt")
+	if err := os.WriteFile(listPath, []byte(cutConcatList(frames, starts, duration)), 0600); err != nil {
+		return err
+	}
+	return runFFmpeg(ctx,
+		[]string{"-f", "concat", "-safe", "0", "-i", listPath},
+		[]string{"-vf", fmt.Sprintf("fps=%d%s,%s", opts.fps, overlay, videoFormats[opts.format].pixelFilter), "-map", "0:v"},
+		audio, outPath, opts, duration, onProgress)
+}
+
+// cutConcatList は各画面を表示時間だけ並べるffconcatのリストを返します。
+// 次の画面と同じ時刻に始まる画面は表示されないので、リストに入れません。
+func cutConcatList(frames []string, starts []float64, duration int) string {
+	var list strings.Builder
+	list.WriteString("ffconcat version 1.0\n")
+	for i, frame := range frames {
+		end := float64(duration)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		if end <= starts[i] {
+			continue
+		}
+		fmt.Fprintf(&list, "file '%s'\nduration %.6f\n", filepath.Base(frame), end-starts[i])
+	}
+	// concatでは最後の画像のdurationが使われないため、最後の画像をもう一度指定します
+	fmt.Fprintf(&list, "file '%s'\n", filepath.Base(frames[len(frames)-1]))
+	return list.String()
+}
+
+// crossfadeSeconds はクロスフェードの秒数を返します。フェードが前の切り替えと重ならないように、
+// 最も短い画面の半分までに抑えます。同じ時刻に始まる画面がある場合は0を返します。
+func crossfadeSeconds(starts []float64, crossfade float64) float64 {
+	fade := crossfade
+	for i := 1; i < len(starts); i++ {
+		fade = math.Min(fade, (starts[i]-starts[i-1])/2)
+	}
+	return math.Max(fade, 0)
+}
+
+// encodeCrossfadeVideo は各画面を次の画面とクロスフェードでつなぐ動画をエンコードします
+func encodeCrossfadeVideo(ctx context.Context, frames []string, starts []float64, fade float64, audio []videoAudio, outPath string, opts videoOptions, overlay string, duration int, onProgress func(float64) error) error {
+	args, filter := crossfadeArgs(frames, starts, fade, opts, overlay, duration)
+	return runFFmpeg(ctx, args, []string{"-filter_complex", filter, "-map", "[v]"}, audio, outPath, opts, duration, onProgress)
+}
+
+// crossfadeArgs はクロスフェードでつなぐためのffmpegの入力の引数とfilter_complexを返します。
+// 次の画面の開始時刻にフェードが終わるように、開始時刻の fade 秒前から重ねます。
+func crossfadeArgs(frames []string, starts []float64, fade float64, opts videoOptions, overlay string, duration int) ([]string, string) {
+	var args []string
+	for i, frame := range frames {
+		begin := math.Max(starts[i]-fade, 0)
+		end := float64(duration)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		args = append(args,
+			"-loop", "1", "-framerate", strconv.Itoa(opts.fps),
+			"-t", strconv.FormatFloat(end-begin, 'f', 6, 64),
+			"-i", frame,
+		)
+	}
+
+	var filter strings.Builder
+	prev := "[0:v]"
+	for i := 1; i < len(frames); i++ {
+		out := fmt.Sprintf("[x%d]", i)
+		fmt.Fprintf(&filter, "%s[%d:v]xfade=transition=fade:duration=%.6f:offset=%.6f%s;", prev, i, fade, starts[i]-fade, out)
+		prev = out
+	}
+	fmt.Fprintf(&filter, "%snull%s,%s[v]", prev, overlay, videoFormats[opts.format].pixelFilter)
+	return args, filter.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCrossfadeSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		starts    []float64
+		crossfade float64
+		want      float64
+	}{
+		{name: "as requested", starts: []float64{0, 4, 10}, crossfade: 1, want: 1},
+		// 最も短い画面（4秒）の半分までに抑える
+		{name: "limited by shortest page", starts: []float64{0, 4, 10}, crossfade: 5, want: 2},
+		{name: "single page", starts: []float64{0}, crossfade: 1, want: 1},
+		// 同じ時刻に始まる画面があればクロスフェードせずに切り替える
+		{name: "equal starts", starts: []float64{0, 4, 4, 10}, crossfade: 1, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crossfadeSeconds(tt.starts, tt.crossfade); got != tt.want {
+				t.Errorf("crossfadeSeconds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrossfadeArgs(t *testing.T) {
+	opts := videoOptions{format: "mp4", fps: 30}
+	frames := []string{"frame-0001.png", "frame-0002.png", "frame-0003.png"}
+	args, filter := crossfadeArgs(frames, []float64{0, 4, 10}, 1, opts, ",drawbox=x=480", 12)
+
+	// 各画面は前の画面とのフェードの分だけ早く始め、次の画面の開始時刻まで表示する
+	wantArgs := []string{
+		"-loop", "1", "-framerate", "30", "-t", "4.000000", "-i", "frame-0001.png",
+		"-loop", "1", "-framerate", "30", "-t", "7.000000", "-i", "frame-0002.png",
+		"-loop", "1", "-framerate", "30", "-t", "3.000000", "-i", "frame-0003.png",
+	}
+	if !slices.Equal(args, wantArgs) {
+		t.Errorf("args = %q\nwant %q", args, wantArgs)
+	}
+	// xfadeのoffsetはつないだ映像の先頭からの時刻で、次の画面の開始時刻にフェードが終わる
+	wantFilter := "[0:v][1:v]xfade=transition=fade:duration=1.000000:offset=3.000000[x1];" +
+		"[x1][2:v]xfade=transition=fade:duration=1.000000:offset=9.000000[x2];" +
+		"[x2]null,drawbox=x=480,format=yuv420p[v]"
+	if filter != wantFilter {
+		t.Errorf("filter = %q\nwant %q", filter, wantFilter)
+	}
+}
+
+func TestCutConcatList(t *testing.T) {
+	frames := []string{"/tmp/work/frame-0001.png", "/tmp/work/frame-0002.png", "/tmp/work/frame-0003.png"}
+	tests := []struct {
+		name   string
+		starts []float64
+		want   string
+	}{
+		{
+			name:   "each page until the next starts",
+			starts: []float64{0, 4, 7.5},
+			want: "ffconcat version 1.0\n" +
+				"file 'frame-0001.png'\nduration 4.000000\n" +
+				"file 'frame-0002.png'\nduration 3.500000\n" +
+				"file 'frame-0003.png'\nduration 2.500000\n" +
+				"file 'frame-0003.png'\n",
+		},
+		{
+			// 次の画面と同じ時刻に始まる画面は表示されないので入れない
+			name:   "equal starts",
+			starts: []float64{0, 4, 4},
+			want: "ffconcat version 1.0\n" +
+				"file 'frame-0001.png'\nduration 4.000000\n" +
+				"file 'frame-0003.png'\nduration 6.000000\n" +
+				"file 'frame-0003.png'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutConcatList(frames, tt.starts, 10); got != tt.want {
+				t.Errorf("cutConcatList =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/hhrutter/lzw"
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	pdfcpu "github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// errPDFLimitExceeded はPDFが処理上限を超えた場合のエラーです
+var errPDFLimitExceeded = errors.New("PDFが処理上限を超えています")
+
+// pdfLimits はアップロードされたPDFを処理する際の上限です
+type pdfLimits struct {
+	maxPages       int
+	maxStreamBytes int64
+	// maxDecodedBytes は全てのストリームの展開後のサイズの合計の上限です
+	maxDecodedBytes int64
+}
+
+func (c *serverConfig) pdfLimits() pdfLimits {
+	return pdfLimits{
+		maxPages:        c.MaxPDFPages,
+		maxStreamBytes:  c.MaxPDFStreamBytes,
+		maxDecodedBytes: c.MaxPDFDecodedBytes,
+	}
+}
+
+// readPDFContext はPDFを読み込み、ページ数と展開後のストリームサイズを確認してから
+// 検証と最適化を行います。ストリームの展開はpdfcpuの検証より前に上限付きで行うため、
+// 圧縮爆弾のようなPDFでもメモリを使い切る前に拒否できます。
+// pdfcpuが読み込み時に展開するオブジェクトストリームと相互参照ストリームは、読み込む前に確認します。
+func readPDFContext(pdfBytes []byte, password string, limits pdfLimits) (*model.Context, error) {
+	conf := model.NewDefaultConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	budget := &streamBudget{limits: limits}
+	if err := checkStructureStreamSizes(pdfBytes, budget); err != nil {
+		return nil, err
+	}
+	ctx, err := pdfapi.ReadContext(bytes.NewReader(pdfBytes), conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+	if limits.maxPages > 0 && ctx.PageCount > limits.maxPages {
+		return nil, fmt.Errorf("%w: ページ数%dが上限%dを超えています", errPDFLimitExceeded, ctx.PageCount, limits.maxPages)
+	}
+	if err := checkStreamSizes(ctx, budget); err != nil {
+		return nil, err
+	}
+
+	if err := pdfapi.ValidateContext(ctx); err != nil {
+		return nil, err
+	}
+	if conf.Optimize {
+		if err := pdfapi.OptimizeContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := pdfcpu.CacheFormFonts(ctx); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+// streamBudget は展開したストリームのサイズを数え、1つごとの上限と合計の上限を確かめます。
+// 読み込む前と後の確認で同じものを使い、合計は両方を通して数えます。
+type streamBudget struct {
+	limits  pdfLimits
+	decoded int64
+}
+
+func (b *streamBudget) enabled() bool {
+	return b.limits.maxStreamBytes > 0 || b.limits.maxDecodedBytes > 0
+}
+
+// add はストリームを上限付きで展開してサイズを数えます。name はエラーに表示するストリームの説明です。
+func (b *streamBudget) add(sd types.StreamDict, name string) error {
+	limit := int64(math.MaxInt64 - 1)
+	if b.limits.maxStreamBytes > 0 {
+		limit = b.limits.maxStreamBytes
+	}
+	remaining := int64(math.MaxInt64 - 1)
+	if b.limits.maxDecodedBytes > 0 {
+		remaining = b.limits.maxDecodedBytes - b.decoded
+	}
+	size, err := decodedStreamSize(sd, min(limit, remaining))
+	if err != nil {
+		// 壊れたストリームの扱いはpdfcpuに任せる
+		return nil
+	}
+	if size > limit {
+		return fmt.Errorf("%w: %sの展開後サイズが上限%dバイトを超えています", errPDFLimitExceeded, name, limit)
+	}
+	b.decoded += size
+	if size > remaining {
+		return fmt.Errorf("%w: ストリームの展開後サイズの合計が上限%dバイトを超えています", errPDFLimitExceeded, b.limits.maxDecodedBytes)
+	}
+	return nil
+}
+
+// checkStreamSizes は全てのストリームを上限付きで展開し、上限を超えるものがあればエラーを返します
+func checkStreamSizes(ctx *model.Context, budget *streamBudget) error {
+	if !budget.enabled() {
+		return nil
+	}
+	for objNr, entry := range ctx.XRefTable.Table {
+		if entry == nil || entry.Free || entry.Object == nil {
+			continue
+		}
+		// オブジェクトストリームと相互参照ストリームは別の型になるので、読み込む前に数えたものは含まれない
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		if err := budget.add(sd, fmt.Sprintf("オブジェクト%d", objNr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectHeader は間接オブジェクトの始まり（"12 0 obj"）です
+var objectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+// maxStructureDictBytes はオブジェクトストリームと相互参照ストリームの辞書を探す範囲です
+const maxStructureDictBytes = 64 << 10
+
+// checkStructureStreamSizes はPDFを解析する前に、ファイル中の/ObjStmと/XRefのストリームを
+// 上限付きで展開して確認します。pdfcpuはこれらを読み込みの途中で上限なしに展開するためです。
+// 相互参照表を使わずにファイルを先頭から探すので、どこからも参照されていないストリームも対象になります。
+// 暗号化されたオブジェクトストリームは展開できないため、ここでは確認しません。
+func checkStructureStreamSizes(pdfBytes []byte, budget *streamBudget) error {
+	if !budget.enabled() {
+		return nil
+	}
+	for _, m := range objectHeader.FindAllSubmatchIndex(pdfBytes, -1) {
+		sd, ok := structureStream(pdfBytes, m[1])
+		if !ok {
+			continue
+		}
+		objNr, _ := strconv.Atoi(string(pdfBytes[m[2]:m[3]]))
+		if err := budget.add(sd, fmt.Sprintf("オブジェクト%d（%s）", objNr, *sd.Type())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structureStream はstartから始まるオブジェクトが/ObjStmか/XRefのストリームであれば、
+// 辞書と展開前のデータを返します。データはpdfBytesをそのまま参照します。
+func structureStream(pdfBytes []byte, start int) (types.StreamDict, bool) {
+	// 辞書は"stream"の前にあり、ストリームでなければ先に"endobj"が現れる
+	head := pdfBytes[start:min(start+maxStructureDictBytes, len(pdfBytes))]
+	if end := bytes.Index(head, []byte("endobj")); end >= 0 {
+		head = head[:end]
+	}
+	streamInd := bytes.Index(head, []byte("stream"))
+	if streamInd <= 0 {
+		return types.StreamDict{}, false
+	}
+	head = head[:streamInd]
+	if !bytes.Contains(head, []byte("/ObjStm")) && !bytes.Contains(head, []byte("/XRef")) {
+		return types.StreamDict{}, false
+	}
+	line := string(head)
+	o, err := model.ParseObject(&line)
+	if err != nil {
+		return types.StreamDict{}, false
+	}
+	d, ok := o.(types.Dict)
+	if !ok || d.Type() == nil || (*d.Type() != "ObjStm" && *d.Type() != "XRef") {
+		return types.StreamDict{}, false
+	}
+
+	// "stream"の後の改行からデータが始まる
+	data := pdfBytes[start+streamInd+len("stream"):]
+	if bytes.HasPrefix(data, []byte("\r\n")) {
+		data = data[2:]
+	} else if bytes.HasPrefix(data, []byte("\n")) || bytes.HasPrefix(data, []byte("\r")) {
+		data = data[1:]
+	}
+	if length, _ := d.Length(); length != nil && *length >= 0 && *length <= int64(len(data)) {
+		data = data[:*length]
+	} else if end := bytes.Index(data, []byte("endstream")); end >= 0 {
+		data = data[:end]
+	}
+
+	return types.StreamDict{
+		Dict:           d,
+		Raw:            data,
+		FilterPipeline: filterPipeline(d),
+	}, true
+}
+
+// filterPipeline はストリームの辞書の/Filterと/DecodeParmsからフィルタの並びを返します
+func filterPipeline(d types.Dict) []types.PDFFilter {
+	var names, parms types.Array
+	switch f := d["Filter"].(type) {
+	case types.Name:
+		names = types.Array{f}
+	case types.Array:
+		names = f
+	}
+	switch p := d["DecodeParms"].(type) {
+	case types.Dict:
+		parms = types.Array{p}
+	case types.Array:
+		parms = p
+	}
+
+	var pipeline []types.PDFFilter
+	for i, o := range names {
+		name, ok := o.(types.Name)
+		if !ok {
+			return nil
+		}
+		f := types.PDFFilter{Name: name.Value()}
+		if i < len(parms) {
+			if p, ok := parms[i].(types.Dict); ok {
+				f.DecodeParms = p
+			}
+		}
+		pipeline = append(pipeline, f)
+	}
+	return pipeline
+}
+
+// decodedStreamSize はストリームを展開した際のサイズを返します。
+// maxBytesを超えた時点で読み込みを打ち切るため、戻り値は最大でmaxBytes+1です。
+func decodedStreamSize(sd types.StreamDict, maxBytes int64) (int64, error) {
+	var r io.Reader = bytes.NewReader(sd.Raw)
+	for i, f := range sd.FilterPipeline {
+		// 前段の出力も上限で打ち切り、後段のフィルタに巨大な入力を渡さない。
+		// 展開前のデータは既にメモリにあるので、そのまま渡す
+		if i > 0 {
+			r = io.LimitReader(r, maxBytes+1)
+		}
+		switch f.Name {
+		case filter.Flate:
+			zr, err := zlib.NewReader(r)
+			if err != nil {
+				return 0, err
+			}
+			defer zr.Close()
+			r = zr
+		case filter.LZW:
+			earlyChange := true
+			if f.DecodeParms != nil {
+				if ec := f.DecodeParms.IntEntry("EarlyChange"); ec != nil {
+					earlyChange = *ec == 1
+				}
+			}
+			lr := lzw.NewReader(r, earlyChange)
+			defer lr.Close()
+			r = lr
+		case filter.ASCII85, filter.ASCIIHex, filter.RunLength:
+			fi, err := filter.NewFilter(f.Name, nil)
+			if err != nil {
+				return 0, err
+			}
+			if r, err = fi.Decode(r); err != nil {
+				return 0, err
+			}
+		default:
+			// 画像用フィルタなどはここでは展開しないので、そこまでのサイズで判定する
+			return io.Copy(io.Discard, io.LimitReader(r, maxBytes+1))
+		}
+	}
+	return io.Copy(io.Discard, io.LimitReader(r, maxBytes+1))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// objStmPDFBytes はカタログ・ページツリー・ページをオブジェクトストリームに入れ、
+// 相互参照ストリームで参照するPDFを返します。オブジェクトストリームは展開するとpaddingバイトの空白が続きます。
+func objStmPDFBytes(padding int) []byte {
+	deflate := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] >>",
+	}
+	var header, body string
+	for i, o := range objects {
+		header += fmt.Sprintf("%d %d ", i+1, len(body))
+		body += o + "\n"
+	}
+	content := append([]byte(header+body), bytes.Repeat([]byte(" "), padding)...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	objStm := buf.Len()
+	data := deflate(content)
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /ObjStm /N %d /First %d /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n",
+		len(objects), len(header), len(data), data)
+
+	xref := buf.Len()
+	var entries bytes.Buffer
+	entry := func(kind byte, field2 uint32, field3 uint16) {
+		entries.WriteByte(kind)
+		binary.Write(&entries, binary.BigEndian, field2)
+		binary.Write(&entries, binary.BigEndian, field3)
+	}
+	entry(0, 0, 65535)
+	for i := range objects {
+		entry(2, 4, uint16(i))
+	}
+	entry(1, uint32(objStm), 0)
+	entry(1, uint32(xref), 0)
+	data = deflate(entries.Bytes())
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /XRef /Size 6 /W [1 4 2] /Root 1 0 R /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n",
+		len(data), data)
+	fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+func TestReadPDFContextLimitsObjectStreams(t *testing.T) {
+	limits := pdfLimits{maxStreamBytes: 1 << 20}
+	tests := []struct {
+		name    string
+		padding int
+		wantErr error
+	}{
+		{name: "under limit", padding: 1 << 10},
+		// 圧縮すると数KBになるが、展開すると上限を超える
+		{name: "bomb in object stream", padding: 64 << 20, wantErr: errPDFLimitExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := readPDFContext(objStmPDFBytes(tt.padding), "", limits)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ctx.PageCount != 1 {
+				t.Errorf("page count = %d, want 1", ctx.PageCount)
+			}
+		})
+	}
+}
+
+func TestCheckStructureStreamSizesIgnoresOtherStreams(t *testing.T) {
+	// ページの内容のストリームはここでは確認せず、読み込み後のcheckStreamSizesに任せる
+	budget := &streamBudget{limits: pdfLimits{maxStreamBytes: 1}}
+	if err := checkStructureStreamSizes(testPDFBytes(1, 200, 200), budget); err != nil || budget.decoded != 0 {
+		t.Errorf("content stream checked before parsing: decoded=%d err=%v", budget.decoded, err)
+	}
+	budget = &streamBudget{limits: pdfLimits{maxStreamBytes: 16}}
+	if err := checkStructureStreamSizes(objStmPDFBytes(0), budget); !errors.Is(err, errPDFLimitExceeded) {
+		t.Errorf("err = %v, want errPDFLimitExceeded", err)
+	}
+}
+
+func TestReadPDFContextLimitsTotalDecodedBytes(t *testing.T) {
+	// 1ページの内容は展開すると20バイト前後で、1つごとの上限には収まる
+	pdf := testPDFBytes(10, 200, 200)
+	tests := []struct {
+		name    string
+		limits  pdfLimits
+		wantErr bool
+	}{
+		{name: "under total", limits: pdfLimits{maxStreamBytes: 100, maxDecodedBytes: 1000}},
+		{name: "over total", limits: pdfLimits{maxStreamBytes: 100, maxDecodedBytes: 100}, wantErr: true},
+		{name: "no limits", limits: pdfLimits{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readPDFContext(pdf, "", tt.limits)
+			if tt.wantErr && !errors.Is(err, errPDFLimitExceeded) {
+				t.Errorf("err = %v, want errPDFLimitExceeded", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("readPDFContext: %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamBudgetSpansBothPasses(t *testing.T) {
+	// 読み込む前に数えたオブジェクトストリームと、読み込んだ後のページの内容を合わせて数える
+	budget := &streamBudget{limits: pdfLimits{maxDecodedBytes: 1200}}
+	if err := checkStructureStreamSizes(objStmPDFBytes(1000), budget); err != nil {
+		t.Fatal(err)
+	}
+	if budget.decoded < 1000 {
+		t.Fatalf("decoded = %d after the object stream, want at least 1000", budget.decoded)
+	}
+	if err := checkStreamSizes(testPDF(t, 10, 200, 200), budget); !errors.Is(err, errPDFLimitExceeded) {
+		t.Errorf("err = %v, want errPDFLimitExceeded after %d bytes", err, budget.decoded)
+	}
+}
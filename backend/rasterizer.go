@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	rasterizerAuto        = "auto"
+	rasterizerPdftoppm    = "pdftoppm"
+	rasterizerImageMagick = "imagemagick"
+	rasterizerBuiltin     = "builtin"
+)
+
+// pageRasterizer はPDFの各ページを画像に変換する方法です
+type pageRasterizer interface {
+	// Name は設定やヘルスチェックで使う名前を返します
+	Name() string
+	// Available はこの環境で使えるかどうかを返します
+	Available() bool
+	// Rasterize はPDFの各ページを高さheightのPNGとしてdirに書き出し、ページ順のパスを返します。
+	// PDFを自分で読み込む変換方法は limits の上限を確かめます。
+	Rasterize(ctx context.Context, pdfBytes []byte, dir string, height int, limits pdfLimits) ([]string, error)
+}
+
+// rasterizers は自動で選ぶときの優先順です。組み込みの変換は対応できるPDFが限られるため最後にします。
+var rasterizers = []pageRasterizer{
+	pdftoppmRasterizer{},
+	imageMagickRasterizer{},
+	builtinRasterizer{},
+}
+
+var (
+	rasterizerMu     sync.Mutex
+	activeRasterizer pageRasterizer
+)
+
+// selectRasterizer は設定の名前から使う変換方法を選びます。auto の場合は使えるものを優先順に探します。
+func selectRasterizer(name string) (pageRasterizer, error) {
+	if name == "" || name == rasterizerAuto {
+		for _, r := range rasterizers {
+			if r.Available() {
+				return r, nil
+			}
+		}
+		return nil, errors.New("PDFを画像に変換する方法がありません")
+	}
+	for _, r := range rasterizers {
+		if r.Name() != name {
+			continue
+		}
+		if !r.Available() {
+			return nil, fmt.Errorf("PDFを画像に変換する方法%sはこの環境では使えません", name)
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("PDFを画像に変換する方法%sには対応していません（%s）", name, strings.Join(rasterizerNames(), ", "))
+}
+
+// useRasterizer は以降の変換に使う方法を設定します。サーバーの起動時に呼び出します。
+func useRasterizer(r pageRasterizer) {
+	rasterizerMu.Lock()
+	defer rasterizerMu.Unlock()
+	activeRasterizer = r
+}
+
+// currentRasterizer は変換に使う方法を返します。設定されていなければ自動で選びます。
+func currentRasterizer() pageRasterizer {
+	rasterizerMu.Lock()
+	defer rasterizerMu.Unlock()
+	if activeRasterizer == nil {
+		// 組み込みの変換は常に使えるので、自動で選ぶ場合はエラーになりません
+		activeRasterizer, _ = selectRasterizer(rasterizerAuto)
+	}
+	return activeRasterizer
+}
+
+// rasterizerNames は全ての変換方法の名前を返します（auto を含む）
+func rasterizerNames() []string {
+	names := []string{rasterizerAuto}
+	for _, r := range rasterizers {
+		names = append(names, r.Name())
+	}
+	return names
+}
+
+// availableRasterizers はこの環境で使える変換方法の名前を返します
+func availableRasterizers() []string {
+	var names []string
+	for _, r := range rasterizers {
+		if r.Available() {
+			names = append(names, r.Name())
+		}
+	}
+	return names
+}
+
+// rasterizePDF はPDFの各ページを高さheightのPNGに変換し、ページ順のパスを返します
+func rasterizePDF(ctx context.Context, pdfBytes []byte, dir string, height int, limits pdfLimits) ([]string, error) {
+	return currentRasterizer().Rasterize(ctx, pdfBytes, dir, height, limits)
+}
+
+// pdftoppmRasterizer はpoppler の pdftoppm で変換します
+type pdftoppmRasterizer struct{}
+
+func (pdftoppmRasterizer) Name() string { return rasterizerPdftoppm }
+
+func (pdftoppmRasterizer) Available() bool {
+	_, err := exec.LookPath("pdftoppm")
+	return err == nil
+}
+
+func (pdftoppmRasterizer) Rasterize(ctx context.Context, pdfBytes []byte, dir string, height int, _ pdfLimits) ([]string, error) {
+	path, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, errors.New("PDFを画像に変換するためのpdftoppmが見つかりません")
+	}
+	return runRasterizerCommand(dir, pdfBytes, func(input, prefix string) *exec.Cmd {
+		return exec.CommandContext(ctx, path, "-png", "-scale-to-x", "-1", "-scale-to-y", strconv.Itoa(height), input, prefix)
+	})
+}
+
+// imageMagickRasterizer はImageMagickで変換します。PDFの読み込みにはGhostscriptが必要です。
+type imageMagickRasterizer struct{}
+
+func (imageMagickRasterizer) Name() string { return rasterizerImageMagick }
+
+func (imageMagickRasterizer) Available() bool {
+	_, err := lookPathAny("magick", "convert")
+	return err == nil
+}
+
+func (imageMagickRasterizer) Rasterize(ctx context.Context, pdfBytes []byte, dir string, height int, _ pdfLimits) ([]string, error) {
+	path, err := lookPathAny("magick", "convert")
+	if err != nil {
+		return nil, errors.New("PDFを画像に変換するためのImageMagickが見つかりません")
+	}
+	return runRasterizerCommand(dir, pdfBytes, func(input, prefix string) *exec.Cmd {
+		return exec.CommandContext(ctx, path, "-density", "200", input, "-resize", "x"+strconv.Itoa(height), "-background", "white", "-alpha", "remove", prefix+"-%04d.png")
+	})
+}
+
+// runRasterizerCommand はPDFをdirに書き出して外部コマンドで変換し、出力されたPNGをページ順に返します
+func runRasterizerCommand(dir string, pdfBytes []byte, command func(input, prefix string) *exec.Cmd) ([]string, error) {
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, pdfBytes, 0600); err != nil {
+		return nil, err
+	}
+	prefix := filepath.Join(dir, "page")
+	if out, err := command(input, prefix).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("PDFの画像変換に失敗しました: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	pages, err := filepath.Glob(prefix + "-*.png")
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("PDFの画像変換結果がありません")
+	}
+	// pdftoppmはページ数に応じて桁数を揃えるので、辞書順がページ順になる
+	sort.Strings(pages)
+	return pages, nil
+}
+
+func lookPathAny(names ...string) (string, error) {
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", exec.ErrNotFound
+}
@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/tiff"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// errUnsupportedPDFContent は組み込みの変換で描画できない内容がPDFに含まれる場合のエラーです
+var errUnsupportedPDFContent = errors.New("組み込みの変換ではスキャン画像だけのPDFにしか対応していません（文字や図形を含むPDFにはpdftoppmまたはImageMagickが必要です）")
+
+// maxFormDepth はフォームXObjectを入れ子で描画する深さの上限です
+const maxFormDepth = 16
+
+// maxImagePixels は展開する画像1枚の画素数の上限です（A4を600dpiで読み取った画像の約2倍）。
+// JPEGなどはストリームが小さくても展開すると大きくなるので、展開する前に確認します。
+const maxImagePixels = 1 << 26
+
+// builtinRasterizer は外部コマンドを使わずにGoだけで変換します。
+// ページに貼られた画像（スキャンした楽譜）を描画でき、文字や図形を含むページはエラーにします。
+type builtinRasterizer struct{}
+
+func (builtinRasterizer) Name() string { return rasterizerBuiltin }
+
+func (builtinRasterizer) Available() bool { return true }
+
+func (builtinRasterizer) Rasterize(ctx context.Context, pdfBytes []byte, dir string, height int, limits pdfLimits) ([]string, error) {
+	pdfCtx, err := readPDFContext(pdfBytes, "", limits)
+	if err != nil {
+		return nil, err
+	}
+	digits := len(strconv.Itoa(pdfCtx.PageCount))
+	pages := make([]string, 0, pdfCtx.PageCount)
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		img, err := renderPage(pdfCtx, pageNr, height)
+		if err != nil {
+			return nil, fmt.Errorf("ページ%d: %w", pageNr, err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("page-%0*d.png", digits, pageNr))
+		if err := writePNG(path, img); err != nil {
+			return nil, err
+		}
+		pages = append(pages, path)
+	}
+	return pages, nil
+}
+
+// pdfMatrix はPDFの変換行列 [a b c d e f] です（x' = a*x + c*y + e, y' = b*x + d*y + f）
+type pdfMatrix [6]float64
+
+var identityMatrix = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// mul はmを先に、nを後に適用する行列を返します
+func (m pdfMatrix) mul(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m pdfMatrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// bounds は矩形をmで変換したときに、それを囲む画素の範囲を返します
+func (m pdfMatrix) bounds(x0, y0, x1, y1 float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range [][2]float64{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+		x, y := m.apply(p[0], p[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// renderPage は1ページを高さheightの画像に描画します
+func renderPage(pdfCtx *model.Context, pageNr, height int) (image.Image, error) {
+	_, _, attrs, err := pdfCtx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	box := attrs.CropBox
+	if box == nil {
+		box = attrs.MediaBox
+	}
+	if box == nil || box.Width() <= 0 || box.Height() <= 0 {
+		return nil, errors.New("ページのサイズを取得できません")
+	}
+	rotate := ((attrs.Rotate % 360) + 360) % 360
+
+	// 回転後の高さがheightになるように縮尺を決め、回転前の向きで描画してから回転します
+	scale := float64(height) / box.Height()
+	if rotate == 90 || rotate == 270 {
+		scale = float64(height) / box.Width()
+	}
+	w := max(1, int(math.Round(box.Width()*scale)))
+	h := max(1, int(math.Round(box.Height()*scale)))
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	// PDFの座標（左下が原点）から画素の座標（左上が原点）への変換
+	device := pdfMatrix{scale, 0, 0, -scale, -box.LL.X * scale, box.UR.Y * scale}
+	content, err := pdfcpu.ExtractPageContent(pdfCtx, pageNr)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if content != nil {
+		if data, err = io.ReadAll(content); err != nil {
+			return nil, err
+		}
+	}
+	r := &pageRenderer{pdfCtx: pdfCtx, canvas: canvas}
+	if err := r.run(data, attrs.Resources, device, canvas.Bounds(), 0); err != nil {
+		return nil, err
+	}
+	return rotateImage(canvas, rotate), nil
+}
+
+// pageRenderer はコンテンツストリームのうち、画像の描画に必要な演算子だけを解釈します
+type pageRenderer struct {
+	pdfCtx *model.Context
+	canvas *image.RGBA
+}
+
+// graphicsState は q / Q で保存する状態です
+type graphicsState struct {
+	ctm        pdfMatrix
+	clip       image.Rectangle
+	textRender int
+}
+
+// run はコンテンツストリームを解釈して描画します
+func (r *pageRenderer) run(content []byte, resources types.Dict, ctm pdfMatrix, clip image.Rectangle, depth int) error {
+	if depth > maxFormDepth {
+		return errors.New("フォームの入れ子が深すぎます")
+	}
+	state := graphicsState{ctm: ctm, clip: clip}
+	var stack []graphicsState
+	var path []image.Rectangle
+	clipping := false
+	lex := contentLexer{data: content}
+
+	for {
+		operands, op, err := lex.next()
+		if err != nil {
+			return err
+		}
+		if op == "" {
+			return nil
+		}
+		switch op {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			m, ok := matrixOperands(operands)
+			if !ok {
+				return errors.New("cmの引数が不正です")
+			}
+			state.ctm = m.mul(state.ctm)
+		case "re":
+			if nums, ok := numberOperands(operands, 4); ok {
+				path = append(path, state.ctm.bounds(nums[0], nums[1], nums[0]+nums[2], nums[1]+nums[3]))
+			}
+		case "m", "l", "c", "v", "y", "h":
+			// 矩形以外の経路はクリップにだけ使われる場合も範囲を決められないので、描画範囲を狭めません
+			path = append(path, state.clip)
+		case "W", "W*":
+			clipping = true
+		case "n":
+			if clipping {
+				area := image.Rectangle{}
+				for _, p := range path {
+					area = area.Union(p)
+				}
+				state.clip = state.clip.Intersect(area)
+			}
+			path, clipping = nil, false
+		case "f", "F", "f*", "S", "s", "B", "B*", "b", "b*", "sh", "BI":
+			return errUnsupportedPDFContent
+		case "Tr":
+			if nums, ok := numberOperands(operands, 1); ok {
+				state.textRender = int(nums[0])
+			}
+		case "Tj", "TJ", "'", "\"":
+			// 描画モード3（透明）の文字はOCRの結果などで見えないので無視します
+			if state.textRender != 3 {
+				return errUnsupportedPDFContent
+			}
+		case "Do":
+			if len(operands) != 1 {
+				return errors.New("Doの引数が不正です")
+			}
+			name, ok := operands[0].(types.Name)
+			if !ok {
+				return errors.New("Doの引数が不正です")
+			}
+			if err := r.drawXObject(string(name), resources, state, depth); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// drawXObject はリソースの名前nameのXObject（画像またはフォーム）を描画します
+func (r *pageRenderer) drawXObject(name string, resources types.Dict, state graphicsState, depth int) error {
+	xobjects, err := r.pdfCtx.DereferenceDict(resources["XObject"])
+	if err != nil || xobjects == nil {
+		return fmt.Errorf("XObject %sが見つかりません", name)
+	}
+	ref := xobjects[name]
+	sd, _, err := r.pdfCtx.DereferenceStreamDict(ref)
+	if err != nil || sd == nil {
+		return fmt.Errorf("XObject %sが見つかりません", name)
+	}
+	subtype := sd.Dict.Subtype()
+	if subtype == nil {
+		return fmt.Errorf("XObject %sの種類が不明です", name)
+	}
+
+	switch *subtype {
+	case "Image":
+		objNr := 0
+		if ir, ok := ref.(types.IndirectRef); ok {
+			objNr = ir.ObjectNumber.Value()
+		}
+		img, err := decodePDFImage(r.pdfCtx, sd, name, objNr)
+		if err != nil {
+			return err
+		}
+		r.drawImage(img, state)
+		return nil
+	case "Form":
+		if err := sd.Decode(); err != nil {
+			return err
+		}
+		ctm := state.ctm
+		if m, ok := matrixOperands(sd.Dict.ArrayEntry("Matrix")); ok {
+			ctm = m.mul(ctm)
+		}
+		clip := state.clip
+		if nums, ok := numberOperands(sd.Dict.ArrayEntry("BBox"), 4); ok {
+			clip = clip.Intersect(ctm.bounds(nums[0], nums[1], nums[2], nums[3]))
+		}
+		formResources := resources
+		if d, err := r.pdfCtx.DereferenceDict(sd.Dict["Resources"]); err == nil && d != nil {
+			formResources = d
+		}
+		return r.run(sd.Content, formResources, ctm, clip, depth+1)
+	}
+	return nil
+}
+
+// drawImage は画像を単位正方形に貼り、現在の変換行列で描画します
+func (r *pageRenderer) drawImage(img image.Image, state graphicsState) {
+	b := img.Bounds()
+	if b.Empty() || state.clip.Empty() {
+		return
+	}
+	// 画像の画素（左上が原点）から単位正方形（左下が原点）への変換を先に適用します
+	m := pdfMatrix{1 / float64(b.Dx()), 0, 0, -1 / float64(b.Dy()), -float64(b.Min.X) / float64(b.Dx()), 1 + float64(b.Min.Y)/float64(b.Dy())}.mul(state.ctm)
+	s2d := f64.Aff3{m[0], m[2], m[4], m[1], m[3], m[5]}
+	dst, ok := r.canvas.SubImage(state.clip).(*image.RGBA)
+	if !ok {
+		return
+	}
+	xdraw.CatmullRom.Transform(dst, s2d, img, b, draw.Over, nil)
+}
+
+// decodePDFImage は画像XObjectを画像に変換します。
+// 辞書の/Widthと/Height、展開前の画像のヘッダーの両方で画素数を確認してから展開します。
+func decodePDFImage(pdfCtx *model.Context, sd *types.StreamDict, name string, objNr int) (image.Image, error) {
+	width, err := pdfCtx.DereferenceInteger(sd.Dict["Width"])
+	if err != nil || width == nil {
+		return nil, fmt.Errorf("画像%sの幅を取得できません", name)
+	}
+	height, err := pdfCtx.DereferenceInteger(sd.Dict["Height"])
+	if err != nil || height == nil {
+		return nil, fmt.Errorf("画像%sの高さを取得できません", name)
+	}
+	if err := checkImagePixels(name, width.Value(), height.Value()); err != nil {
+		return nil, err
+	}
+
+	extracted, err := pdfcpu.ExtractImage(pdfCtx, sd, false, name, objNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if extracted == nil || extracted.Reader == nil {
+		return nil, fmt.Errorf("画像%sの形式に対応していません", name)
+	}
+	data, err := io.ReadAll(extracted.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch extracted.FileType {
+	case "png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "jpg", "jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "tif", "tiff":
+		decode, decodeConfig = tiff.Decode, tiff.DecodeConfig
+	default:
+		return nil, fmt.Errorf("画像%sの形式%sに対応していません", name, extracted.FileType)
+	}
+	// JPEGなどは辞書と異なる大きさをヘッダーに書けるので、ヘッダーの大きさも確認します
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImagePixels(name, config.Width, config.Height); err != nil {
+		return nil, err
+	}
+	return decode(bytes.NewReader(data))
+}
+
+// checkImagePixels は画像の大きさが正で、画素数がmaxImagePixels以下であることを確認します
+func checkImagePixels(name string, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("画像%sの大きさ%d×%dが不正です", name, width, height)
+	}
+	if width > maxImagePixels/height {
+		return fmt.Errorf("%w: 画像%sの大きさ%d×%dが上限%d画素を超えています", errPDFLimitExceeded, name, width, height, maxImagePixels)
+	}
+	return nil
+}
+
+// rotateImage は画像を時計回りにdegrees度（90の倍数）回転します
+func rotateImage(src *image.RGBA, degrees int) image.Image {
+	if degrees == 0 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	var dst *image.RGBA
+	if degrees == 180 {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := src.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			switch degrees {
+			case 90:
+				dst.SetRGBA(h-1-y, x, c)
+			case 180:
+				dst.SetRGBA(w-1-x, h-1-y, c)
+			case 270:
+				dst.SetRGBA(y, w-1-x, c)
+			}
+		}
+	}
+	return dst
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// matrixOperands は6個の数を変換行列として読み取ります
+func matrixOperands(operands []types.Object) (pdfMatrix, bool) {
+	nums, ok := numberOperands(operands, 6)
+	if !ok {
+		return pdfMatrix{}, false
+	}
+	return pdfMatrix{nums[0], nums[1], nums[2], nums[3], nums[4], nums[5]}, true
+}
+
+// numberOperands はn個の数を読み取ります
+func numberOperands(operands []types.Object, n int) ([]float64, bool) {
+	if len(operands) != n {
+		return nil, false
+	}
+	nums := make([]float64, n)
+	for i, o := range operands {
+		switch v := o.(type) {
+		case types.Integer:
+			nums[i] = float64(v.Value())
+		case types.Float:
+			nums[i] = v.Value()
+		default:
+			return nil, false
+		}
+	}
+	return nums, true
+}
+
+// contentLexer はコンテンツストリームを演算子ごとに読み取ります。
+// 数・名前以外の引数（文字列・配列・辞書）は中身を読み飛ばし、nilとして返します。
+type contentLexer struct {
+	data []byte
+	pos  int
+}
+
+// next は次の演算子とその引数を返します。終わりに達した場合は空の演算子を返します。
+func (l *contentLexer) next() ([]types.Object, string, error) {
+	var operands []types.Object
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return operands, "", nil
+		}
+		c := l.data[l.pos]
+		switch {
+		case c == '/':
+			start := l.pos + 1
+			l.pos++
+			for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) && !isSpace(l.data[l.pos]) {
+				l.pos++
+			}
+			operands = append(operands, types.Name(l.data[start:l.pos]))
+		case c == '(':
+			if err := l.skipString(); err != nil {
+				return nil, "", err
+			}
+			operands = append(operands, nil)
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<', c == '[':
+			if err := l.skipNested(); err != nil {
+				return nil, "", err
+			}
+			operands = append(operands, nil)
+		case c == '<':
+			end := bytes.IndexByte(l.data[l.pos:], '>')
+			if end < 0 {
+				return nil, "", errors.New("コンテンツストリームの文字列が閉じていません")
+			}
+			l.pos += end + 1
+			operands = append(operands, nil)
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := l.pos
+			for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) && !isSpace(l.data[l.pos]) {
+				l.pos++
+			}
+			token := string(l.data[start:l.pos])
+			if i, err := strconv.Atoi(token); err == nil {
+				operands = append(operands, types.Integer(i))
+			} else if f, err := strconv.ParseFloat(token, 64); err == nil {
+				operands = append(operands, types.Float(f))
+			} else {
+				operands = append(operands, nil)
+			}
+		case isDelimiter(c):
+			// 対応しない閉じ括弧などは読み飛ばします
+			l.pos++
+		default:
+			start := l.pos
+			for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) && !isSpace(l.data[l.pos]) {
+				l.pos++
+			}
+			return operands, string(l.data[start:l.pos]), nil
+		}
+	}
+}
+
+func (l *contentLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// skipString は ( から対応する ) までを読み飛ばします
+func (l *contentLexer) skipString() error {
+	depth := 0
+	for ; l.pos < len(l.data); l.pos++ {
+		switch l.data[l.pos] {
+		case '\\':
+			l.pos++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return nil
+			}
+		}
+	}
+	return errors.New("コンテンツストリームの文字列が閉じていません")
+}
+
+// skipNested は配列 [ ] または辞書 << >> を、中の文字列も含めて読み飛ばします
+func (l *contentLexer) skipNested() error {
+	depth := 0
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '(':
+			if err := l.skipString(); err != nil {
+				return err
+			}
+			continue
+		case c == '[' || (c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<'):
+			depth++
+			if c == '<' {
+				l.pos++
+			}
+		case c == ']' || (c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>'):
+			depth--
+			if c == '>' {
+				l.pos++
+			}
+		}
+		l.pos++
+		if depth == 0 {
+			return nil
+		}
+	}
+	return errors.New("コンテンツストリームの配列または辞書が閉じていません")
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
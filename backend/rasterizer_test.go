@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// imagePDFBytes は幅width・高さheightのページ全体にグレースケールの画像を貼ったPDFを返します。
+// 画像はimgW×imgH画素で、左半分が黒・右半分が白です。/Widthと/Heightには辞書に書く大きさを別に指定できます。
+func imagePDFBytes(width, height float64, rotate, imgW, imgH, dictW, dictH int) []byte {
+	deflate := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+	pixels := make([]byte, imgW*imgH)
+	for y := range imgH {
+		for x := imgW / 2; x < imgW; x++ {
+			pixels[y*imgW+x] = 0xff
+		}
+	}
+
+	var buf bytes.Buffer
+	offsets := []int{0}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+	buf.WriteString("%PDF-1.7\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Rotate %d /Resources << /XObject << /Im1 5 0 R >> >> /Contents 4 0 R >>",
+		width, height, rotate))
+	content := deflate(fmt.Appendf(nil, "q %g 0 0 %g 0 0 cm /Im1 Do Q", width, height))
+	object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(content), content))
+	data := deflate(pixels)
+	object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+		dictW, dictH, len(data), data))
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	return buf.Bytes()
+}
+
+// rasterizeOnePage は1ページのPDFを組み込みの変換で描画し、その画像を返します
+func rasterizeOnePage(t *testing.T, pdf []byte, height int) image.Image {
+	t.Helper()
+	pages, err := builtinRasterizer{}.Rasterize(context.Background(), pdf, t.TempDir(), height, pdfLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("rasterized %d pages, want 1", len(pages))
+	}
+	f, err := os.Open(pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// isDark は画素が黒に近いかどうかを返します
+func isDark(img image.Image, x, y int) bool {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return r+g+b < 3*0x4000
+}
+
+func TestBuiltinRasterizerChecksLimits(t *testing.T) {
+	pdf := testPDFBytes(3, 400, 600)
+	_, err := builtinRasterizer{}.Rasterize(context.Background(), pdf, t.TempDir(), 100, pdfLimits{maxPages: 2})
+	if !errors.Is(err, errPDFLimitExceeded) {
+		t.Errorf("err = %v, want errPDFLimitExceeded", err)
+	}
+}
+
+func TestBuiltinRasterizerRendersScannedPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		rotate         int
+		wantW, wantH   int
+		darkX, darkY   int
+		lightX, lightY int
+	}{
+		// 横400×縦200のページを高さ100で描画すると200×100になり、左半分が黒くなる
+		{name: "upright", rotate: 0, wantW: 200, wantH: 100, darkX: 50, darkY: 50, lightX: 150, lightY: 50},
+		// 時計回りに90度回転すると、回転後の高さが100になるように50×100で描画し、左半分が上に来る
+		{name: "rotated", rotate: 90, wantW: 50, wantH: 100, darkX: 25, darkY: 25, lightX: 25, lightY: 75},
+		{name: "upside down", rotate: 180, wantW: 200, wantH: 100, darkX: 150, darkY: 50, lightX: 50, lightY: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := rasterizeOnePage(t, imagePDFBytes(400, 200, tt.rotate, 40, 20, 40, 20), 100)
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			if !isDark(img, tt.darkX, tt.darkY) {
+				t.Errorf("pixel (%d, %d) = %v, want dark", tt.darkX, tt.darkY, img.At(tt.darkX, tt.darkY))
+			}
+			if isDark(img, tt.lightX, tt.lightY) {
+				t.Errorf("pixel (%d, %d) = %v, want light", tt.lightX, tt.lightY, img.At(tt.lightX, tt.lightY))
+			}
+		})
+	}
+}
+
+func TestBuiltinRasterizerRejectsVectorPage(t *testing.T) {
+	// testPDFBytesのページは線を引くだけで画像を含まない
+	_, err := builtinRasterizer{}.Rasterize(context.Background(), testPDFBytes(1, 400, 600), t.TempDir(), 100, pdfLimits{})
+	if !errors.Is(err, errUnsupportedPDFContent) {
+		t.Errorf("err = %v, want errUnsupportedPDFContent", err)
+	}
+}
+
+func TestBuiltinRasterizerRejectsHugeImage(t *testing.T) {
+	// 画像のデータは小さくても、辞書の大きさで展開前に拒否する
+	pdf := imagePDFBytes(400, 200, 0, 40, 20, 100000, 100000)
+	_, err := builtinRasterizer{}.Rasterize(context.Background(), pdf, t.TempDir(), 100, pdfLimits{})
+	if !errors.Is(err, errPDFLimitExceeded) {
+		t.Errorf("err = %v, want errPDFLimitExceeded", err)
+	}
+}
+
+func TestCheckImagePixels(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantErr       bool
+	}{
+		{name: "a4 at 600 dpi", width: 4960, height: 7016},
+		{name: "at limit", width: 1 << 13, height: 1 << 13},
+		{name: "over limit", width: 1<<13 + 1, height: 1 << 13, wantErr: true},
+		// 掛け算があふれる大きさでも拒否する
+		{name: "overflow", width: 1 << 40, height: 1 << 40, wantErr: true},
+		{name: "zero", width: 0, height: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkImagePixels("Im1", tt.width, tt.height)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkImagePixels(%d, %d) = %v, wantErr %v", tt.width, tt.height, err, tt.wantErr)
+			}
+		})
+	}
+}
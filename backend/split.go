@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"os"
+
+	score "score-splitter/backend/gen/go"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	splitModeWhitespace = "whitespace"
+	splitModeOverlap    = "overlap"
+)
+
+const (
+	// defaultSplitOverlap は固定位置で分割するときの既定の重なりです（1ページの高さに対する割合）
+	defaultSplitOverlap = 0.05
+	// minSplitHeight は分割後の1ページの高さの下限です（ポイント）
+	minSplitHeight = 72
+	// splitRasterScale は余白検出のために画像化するときの倍率です（1ポイントあたりのピクセル数）
+	splitRasterScale = 2
+	// maxSplitRasterHeight は余白検出の画像の高さの上限です（ピクセル）
+	maxSplitRasterHeight = 8000
+	// blankRowLuma より明るい画素は余白として扱います
+	blankRowLuma = 0xF0
+	// minGapPoints より高さのある余白だけを分割位置の候補にします（ポイント）
+	minGapPoints = 4
+	// minSplitFill は余白で分割するときの1ページの高さの下限です（上限に対する割合）。
+	// 早すぎる位置の余白で分割してページ数が増えすぎないようにします。
+	minSplitFill = 0.5
+)
+
+// segmentSplit はトリミング範囲を複数ページに分割する設定です
+type segmentSplit struct {
+	maxAspectRatio float64
+	maxHeight      float64
+	mode           string
+	overlap        float64
+}
+
+// segmentSplitFromRequest はリクエストの分割設定を検証します。指定がなければ分割しない設定を返します。
+func segmentSplitFromRequest(msg *score.SegmentSplit) (segmentSplit, error) {
+	if msg == nil {
+		return segmentSplit{}, nil
+	}
+	split := segmentSplit{
+		maxAspectRatio: msg.GetMaxAspectRatio(),
+		maxHeight:      msg.GetMaxHeight(),
+		mode:           msg.GetMode(),
+		overlap:        msg.GetOverlap(),
+	}
+	if split.mode == "" {
+		split.mode = splitModeWhitespace
+	}
+	if split.mode != splitModeWhitespace && split.mode != splitModeOverlap {
+		return segmentSplit{}, fmt.Errorf("分割方法%qには対応していません（whitespace または overlap）", split.mode)
+	}
+	if split.maxAspectRatio < 0 || (split.maxAspectRatio > 0 && split.maxAspectRatio < 0.2) {
+		return segmentSplit{}, fmt.Errorf("分割する縦横比%vが小さすぎます（0.2以上）", split.maxAspectRatio)
+	}
+	if split.maxHeight < 0 || (split.maxHeight > 0 && split.maxHeight < minSplitHeight) {
+		return segmentSplit{}, fmt.Errorf("分割する高さ%vが小さすぎます（%dポイント以上）", split.maxHeight, minSplitHeight)
+	}
+	if split.overlap < 0 || split.overlap > 0.5 {
+		return segmentSplit{}, fmt.Errorf("分割時の重なり%vは0から0.5の範囲で指定してください", split.overlap)
+	}
+	if msg.Overlap == nil {
+		split.overlap = defaultSplitOverlap
+	}
+	return split, nil
+}
+
+func (s segmentSplit) enabled() bool {
+	return s.maxAspectRatio > 0 || s.maxHeight > 0
+}
+
+// pieceHeight は幅widthの範囲を分割するときの1ページの高さの上限を返します
+func (s segmentSplit) pieceHeight(width float64) float64 {
+	limit := math.Inf(1)
+	if s.maxAspectRatio > 0 {
+		limit = width * s.maxAspectRatio
+	}
+	if s.maxHeight > 0 {
+		limit = math.Min(limit, s.maxHeight)
+	}
+	return math.Max(limit, minSplitHeight)
+}
+
+// extractSegments はトリミング範囲を切り出し、分割設定に従って上から順に複数ページに分けます
+func extractSegments(ctxSrc *model.Context, pageIndex int, rect *types.Rectangle, split segmentSplit) ([][]byte, error) {
+	trimmed, err := extractTrimmedSegment(ctxSrc, pageIndex, rect)
+	if err != nil {
+		return nil, err
+	}
+	if !split.enabled() || rect.Height() <= split.pieceHeight(rect.Width()) {
+		return [][]byte{trimmed}, nil
+	}
+
+	var gaps []float64
+	if split.mode == splitModeWhitespace {
+		gaps, err = findWhitespaceGaps(trimmed, rect.Height())
+		if err != nil {
+			// 画像化できない環境では固定位置での分割にします
+			log.Printf("ページ%vの余白を検出できないため固定位置で分割します: %v", pageIndex, err)
+		}
+	}
+
+	cuts := splitOffsets(rect.Height(), split.pieceHeight(rect.Width()), split.overlap, gaps)
+	segments := make([][]byte, 0, len(cuts))
+	for _, cut := range cuts {
+		piece := types.NewRectangle(rect.LL.X, rect.UR.Y-cut[1], rect.UR.X, rect.UR.Y-cut[0])
+		data, err := extractTrimmedSegment(ctxSrc, pageIndex, piece)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, data)
+	}
+	return segments, nil
+}
+
+// splitOffsets は高さtotalの範囲を上端からの位置 [開始, 終了] の組に分割します。
+// 1ページが limit を超えない範囲で最も下にある余白gapsで区切り、
+// 余白が見つからない場合は limit の位置で区切って次のページと overlap の割合だけ重ねます。
+func splitOffsets(total, limit, overlap float64, gaps []float64) [][2]float64 {
+	var cuts [][2]float64
+	start := 0.0
+	for total-start > limit {
+		end := start + limit
+		next := end - limit*overlap
+		for i := len(gaps) - 1; i >= 0; i-- {
+			if gaps[i] <= end && gaps[i] >= start+limit*minSplitFill {
+				end = gaps[i]
+				next = gaps[i]
+				break
+			}
+		}
+		cuts = append(cuts, [2]float64{start, end})
+		start = next
+	}
+	return append(cuts, [2]float64{start, total})
+}
+
+// findWhitespaceGaps は1ページのPDFを画像化し、左右いっぱいに何も描かれていない横帯の中央の位置を
+// 上端からのポイントで返します。小節線や符尾が横切る位置は余白にならないので、段の途中では区切りません。
+func findWhitespaceGaps(pdf []byte, heightPoints float64) ([]float64, error) {
+	dir, err := os.MkdirTemp("", "score-split-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	rasterHeight := int(math.Min(heightPoints*splitRasterScale, maxSplitRasterHeight))
+	// 元のPDFを読み込んだ時に上限を確認済みなので、ここでは確認しない
+	pages, err := rasterizePDF(context.Background(), pdf, dir, rasterHeight, pdfLimits{})
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodePNG(pages[0])
+	if err != nil {
+		return nil, err
+	}
+	return blankRowGaps(img, heightPoints), nil
+}
+
+// blankRowGaps は画像の空白行が続く帯を探し、帯の中央の位置をポイントに換算して返します
+func blankRowGaps(img image.Image, heightPoints float64) []float64 {
+	b := img.Bounds()
+	if b.Dy() == 0 {
+		return nil
+	}
+	scale := heightPoints / float64(b.Dy())
+	minRows := int(math.Ceil(minGapPoints / scale))
+
+	var gaps []float64
+	runStart := -1
+	flush := func(end int) {
+		// 上端と下端に接する余白は分割位置にしても意味がないので除きます
+		if runStart > b.Min.Y && end < b.Max.Y && end-runStart >= minRows {
+			gaps = append(gaps, float64(runStart-b.Min.Y+end-b.Min.Y)/2*scale)
+		}
+		runStart = -1
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		if isBlankRow(img, y) {
+			if runStart < 0 {
+				runStart = y
+			}
+			continue
+		}
+		if runStart >= 0 {
+			flush(y)
+		}
+	}
+	if runStart >= 0 {
+		flush(b.Max.Y)
+	}
+	return gaps
+}
+
+func isBlankRow(img image.Image, y int) bool {
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		r, g, bl, _ := img.At(x, y).RGBA()
+		// ITU-R BT.601 の輝度（16bit）を8bitに戻して比較します
+		luma := (299*r + 587*g + 114*bl) / 1000 >> 8
+		if luma < blankRowLuma {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	score "score-splitter/backend/gen/go"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSplitOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   float64
+		limit   float64
+		overlap float64
+		gaps    []float64
+		want    [][2]float64
+	}{
+		{
+			name: "fits in one piece", total: 100, limit: 100,
+			want: [][2]float64{{0, 100}},
+		},
+		{
+			name: "no overlap", total: 250, limit: 100,
+			want: [][2]float64{{0, 100}, {100, 200}, {200, 250}},
+		},
+		{
+			// 重なりが最大でも次のページは上限の半分ずつ進む
+			name: "maximum overlap still progresses", total: 250, limit: 100, overlap: 0.5,
+			want: [][2]float64{{0, 100}, {50, 150}, {100, 200}, {150, 250}},
+		},
+		{
+			// 上限に収まる中で最も下の余白で区切り、余白の位置から重ねずに続ける
+			name: "lowest gap within limit", total: 250, limit: 100, overlap: 0.1, gaps: []float64{60, 80, 120, 170},
+			want: [][2]float64{{0, 80}, {80, 170}, {170, 250}},
+		},
+		{
+			// 上限の半分より上の余白では区切らず、固定位置で重ねて分割する
+			name: "gap below minimum fill", total: 150, limit: 100, overlap: 0.1, gaps: []float64{30},
+			want: [][2]float64{{0, 100}, {90, 150}},
+		},
+		{
+			name: "gap at minimum fill", total: 150, limit: 100, overlap: 0.1, gaps: []float64{50},
+			want: [][2]float64{{0, 50}, {50, 150}},
+		},
+		{
+			name: "gap beyond limit", total: 150, limit: 100, gaps: []float64{110},
+			want: [][2]float64{{0, 100}, {100, 150}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitOffsets(tt.total, tt.limit, tt.overlap, tt.gaps)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitOffsets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bandImage は高さheightの白い画像に、inkの行だけ黒い横線を引いたものを返します
+func bandImage(height int, ink func(y int) bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 10, height))
+	for y := range height {
+		for x := range 10 {
+			c := color.Gray{Y: 0xFF}
+			if ink(y) {
+				c = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBlankRowGaps(t *testing.T) {
+	between := func(lo, hi int) func(int) bool {
+		return func(y int) bool { return y >= lo && y < hi }
+	}
+	tests := []struct {
+		name   string
+		img    image.Image
+		height float64
+		want   []float64
+	}{
+		{
+			// 上端と下端に接する余白は除き、間の余白の中央を返す
+			name: "edges excluded", img: bandImage(100, func(y int) bool { return between(20, 40)(y) || between(60, 80)(y) }),
+			height: 100, want: []float64{50},
+		},
+		{
+			name: "narrow gap ignored", img: bandImage(100, func(y int) bool { return between(20, 48)(y) || between(51, 80)(y) }),
+			height: 100,
+		},
+		{
+			name: "blank page", img: bandImage(100, func(int) bool { return false }),
+			height: 100,
+		},
+		{
+			// 画像の1画素が2ポイントの場合は位置と最小の高さをポイントで数える
+			name: "scaled", img: bandImage(50, func(y int) bool { return between(0, 20)(y) || between(21, 30)(y) || between(33, 50)(y) }),
+			height: 100, want: []float64{63},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blankRowGaps(tt.img, tt.height); !slices.Equal(got, tt.want) {
+				t.Errorf("blankRowGaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentSplitFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *score.SegmentSplit
+		want    segmentSplit
+		wantErr bool
+	}{
+		{name: "not set", msg: nil, want: segmentSplit{}},
+		{
+			name: "defaults",
+			msg:  &score.SegmentSplit{MaxHeight: 500},
+			want: segmentSplit{maxHeight: 500, mode: splitModeWhitespace, overlap: defaultSplitOverlap},
+		},
+		{
+			name: "explicit zero overlap",
+			msg:  &score.SegmentSplit{MaxAspectRatio: 1.414, Mode: splitModeOverlap, Overlap: proto.Float64(0)},
+			want: segmentSplit{maxAspectRatio: 1.414, mode: splitModeOverlap},
+		},
+		{
+			name: "bounds",
+			msg:  &score.SegmentSplit{MaxAspectRatio: 0.2, MaxHeight: minSplitHeight, Overlap: proto.Float64(0.5)},
+			want: segmentSplit{maxAspectRatio: 0.2, maxHeight: minSplitHeight, mode: splitModeWhitespace, overlap: 0.5},
+		},
+		{name: "unknown mode", msg: &score.SegmentSplit{Mode: "grid"}, wantErr: true},
+		{name: "aspect ratio too small", msg: &score.SegmentSplit{MaxAspectRatio: 0.19}, wantErr: true},
+		{name: "negative aspect ratio", msg: &score.SegmentSplit{MaxAspectRatio: -1}, wantErr: true},
+		{name: "height too small", msg: &score.SegmentSplit{MaxHeight: minSplitHeight - 1}, wantErr: true},
+		{name: "negative height", msg: &score.SegmentSplit{MaxHeight: -1}, wantErr: true},
+		{name: "overlap too large", msg: &score.SegmentSplit{MaxHeight: 500, Overlap: proto.Float64(0.51)}, wantErr: true},
+		{name: "negative overlap", msg: &score.SegmentSplit{MaxHeight: 500, Overlap: proto.Float64(-0.1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := segmentSplitFromRequest(tt.msg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("segmentSplitFromRequest = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("segmentSplitFromRequest = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// blobStorage はデータの保存先です。キーは "/" 区切りの相対パスです。
+// 存在しないキーに対するGetはerrObjectNotFoundを返します。
+type blobStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// PutStream はrから読んだsizeバイトを保存します。全体をメモリに載せずに大きなデータを保存するのに使います。
+	// rの長さがsizeと異なる場合はエラーを返し、何も保存しません。
+	PutStream(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetRange はoffsetからlengthバイトを返します。データの末尾を超える部分は返しません。
+	GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// GetVersion はGetと同じくデータを返し、あわせてPutIfとDeleteIfに渡す版を返します
+	GetVersion(ctx context.Context, key string) ([]byte, string, error)
+	// PutIf はキーの現在の版がversionの場合だけ保存します。versionが空の場合はキーが無い場合だけ保存します。
+	// 他のレプリカが先に書き換えていて条件を満たさない場合はerrVersionConflictを返します。
+	PutIf(ctx context.Context, key string, data []byte, version string) error
+	// DeleteIf はキーの現在の版がversionの場合だけ削除します。条件を満たさない場合やキーが無い場合はerrVersionConflictを返します。
+	DeleteIf(ctx context.Context, key, version string) error
+	// List はprefixで始まるキーを全て返します
+	List(ctx context.Context, prefix string) ([]blobInfo, error)
+	// Describe はヘルスチェックなどに表示する保存先の説明を返します
+	Describe() string
+}
+
+// blobInfo は保存先のキーとサイズです
+type blobInfo struct {
+	Key  string
+	Size int64
+}
+
+const (
+	storageBackendFilesystem = "filesystem"
+	storageBackendS3         = "s3"
+)
+
+// newBlobStorage は設定に応じた保存先を返します
+func newBlobStorage(ctx context.Context, cfg *serverConfig) (blobStorage, error) {
+	switch cfg.Storage.Backend {
+	case storageBackendFilesystem, "":
+		return newFSStorage(cfg.UploadDir), nil
+	case storageBackendS3:
+		return newS3Storage(ctx, cfg.Storage.S3)
+	default:
+		return nil, fmt.Errorf("保存先%sには対応していません", cfg.Storage.Backend)
+	}
+}
+
+// errVersionConflict は条件付きの書き込みや削除で、キーが指定した版から変わっていたことを表します
+var errVersionConflict = errors.New("他の処理が同じデータを更新しました")
+
+// errStreamSize はPutStreamに渡したデータの長さが指定したサイズと異なることを表します
+var errStreamSize = errors.New("データの長さが指定したサイズと一致しません")
+
+// validateKey はキーが保存先の外を指していないかを確認します
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("キー%qが不正です", key)
+	}
+	return nil
+}
+
+// fsStorage はローカルのファイルシステムに保存します。
+// 条件付きの書き込みはプロセス内でだけ排他するので、同じディレクトリを複数のプロセスで共有できません。
+type fsStorage struct {
+	root string
+
+	// mu はPutIfとDeleteIfの版の確認から書き込みまでを直列化します
+	mu sync.Mutex
+}
+
+func newFSStorage(root string) *fsStorage {
+	return &fsStorage{root: root}
+}
+
+func (s *fsStorage) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *fsStorage) Put(ctx context.Context, key string, data []byte) error {
+	return s.PutStream(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+func (s *fsStorage) PutStream(ctx context.Context, key string, r io.Reader, size int64) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// 読み込み中の相手に書きかけのファイルを見せないよう、一時ファイルに書いてから置き換える
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(r, size+1))
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("%w: %dバイト / %dバイト", errStreamSize, n, size)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *fsStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errObjectNotFound
+	}
+	return data, err
+}
+
+func (s *fsStorage) GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data := make([]byte, length)
+	n, err := f.ReadAt(data, offset)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return data[:n], err
+}
+
+func (s *fsStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *fsStorage) List(ctx context.Context, prefix string) ([]blobInfo, error) {
+	// prefixのうちディレクトリ部分から辿り、残りは前方一致で絞り込む
+	dir := s.root
+	if d := path.Dir(prefix); prefix != "" && d != "." {
+		if err := validateKey(d); err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(s.root, filepath.FromSlash(d))
+	}
+
+	var blobs []blobInfo
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blobInfo{Key: key, Size: info.Size()})
+		return nil
+	})
+	return blobs, err
+}
+
+// GetVersion は内容のSHA-256を版として返します
+func (s *fsStorage) GetVersion(ctx context.Context, key string) ([]byte, string, error) {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentVersion(data), nil
+}
+
+func (s *fsStorage) PutIf(ctx context.Context, key string, data []byte, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkVersion(ctx, key, version); err != nil {
+		return err
+	}
+	return s.Put(ctx, key, data)
+}
+
+func (s *fsStorage) DeleteIf(ctx context.Context, key, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version == "" {
+		return errVersionConflict
+	}
+	if err := s.checkVersion(ctx, key, version); err != nil {
+		return err
+	}
+	return s.Delete(ctx, key)
+}
+
+// checkVersion はキーの現在の版がversion（空の場合はキーが無いこと）かを確認します。s.muを持って呼び出します。
+func (s *fsStorage) checkVersion(ctx context.Context, key, version string) error {
+	_, current, err := s.GetVersion(ctx, key)
+	if errors.Is(err, errObjectNotFound) {
+		current, err = "", nil
+	}
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// contentVersion は内容から版を作ります
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *fsStorage) Describe() string {
+	return "filesystem:" + s.root
+}
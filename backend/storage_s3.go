@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// s3Config はS3互換ストレージの設定です。MinIOなどを使う場合はendpointとuse_path_styleを指定します。
+// アクセスキーを指定しない場合は、AWS SDKの標準の順序（環境変数、共有設定ファイル、
+// Web IDトークン、ECS/EC2のロールなど）で認証情報を探します。
+type s3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	UsePathStyle    bool   `yaml:"use_path_style"`
+}
+
+// s3PartSize はPutStreamで大きなデータをマルチパートアップロードする際の1パートのバイト数です。
+// S3では最後以外のパートを5MiB以上にする必要があります。
+const s3PartSize = 8 << 20
+
+// s3Storage はS3互換のオブジェクトストレージに保存します。
+// 複数のバックエンドから同じバケットを共有できます。参照の数などの更新には条件付きの書き込み
+// （If-Match / If-None-Match）を使うので、それに対応したストレージが必要です。
+type s3Storage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Storage(ctx context.Context, cfg s3Config) (*s3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3のバケットが指定されていません")
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return nil, errors.New("S3のアクセスキーIDとシークレットアクセスキーは両方指定してください")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("S3の設定を読み込めません: %w", err)
+	}
+	if awsCfg.Region == "" {
+		awsCfg.Region = "us-east-1"
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &s3Storage{client: client, bucket: cfg.Bucket, prefix: prefix}, nil
+}
+
+func (s *s3Storage) objectKey(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return s.prefix + key, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return fmt.Errorf("S3への保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// PutStream はs3PartSize以下のデータは1回で、それより大きなデータはマルチパートアップロードで保存します。
+// 使うメモリは1パート分だけです。
+func (s *s3Storage) PutStream(ctx context.Context, key string, r io.Reader, size int64) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	if size <= s3PartSize {
+		data, err := io.ReadAll(io.LimitReader(r, size+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) != size {
+			return fmt.Errorf("%w: %dバイト / %dバイト", errStreamSize, len(data), size)
+		}
+		return s.Put(ctx, key, data)
+	}
+
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return fmt.Errorf("S3への保存に失敗しました: %w", err)
+	}
+	if err := s.uploadParts(ctx, objectKey, created.UploadId, r, size); err != nil {
+		// 途中までのパートが残って課金されないよう破棄する
+		s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(objectKey),
+			UploadId: created.UploadId,
+		})
+		return err
+	}
+	return nil
+}
+
+func (s *s3Storage) uploadParts(ctx context.Context, objectKey string, uploadID *string, r io.Reader, size int64) error {
+	var (
+		completed []s3types.CompletedPart
+		total     int64
+	)
+	buf := make([]byte, s3PartSize)
+	r = io.LimitReader(r, size+1)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			total += int64(n)
+			if total > size {
+				break
+			}
+			out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:        aws.String(s.bucket),
+				Key:           aws.String(objectKey),
+				UploadId:      uploadID,
+				PartNumber:    aws.Int32(partNumber),
+				Body:          bytes.NewReader(buf[:n]),
+				ContentLength: aws.Int64(int64(n)),
+			})
+			if err != nil {
+				return fmt.Errorf("S3への保存に失敗しました: %w", err)
+			}
+			completed = append(completed, s3types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(partNumber)})
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if total != size {
+		return fmt.Errorf("%w: %dバイト / %dバイト", errStreamSize, total, size)
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(objectKey),
+		UploadId:        uploadID,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("S3への保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, _, err := s.GetVersion(ctx, key)
+	return data, err
+}
+
+func (s *s3Storage) GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 {
+		return nil, nil
+	}
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, errObjectNotFound
+		}
+		if isS3InvalidRange(err) {
+			// 開始位置が末尾以降の場合、S3は416を返すが、fsStorageと同じく空のデータを返す
+			return nil, nil
+		}
+		return nil, fmt.Errorf("S3からの読み込みに失敗しました: %w", err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil && !isS3NotFound(err) {
+		return fmt.Errorf("S3からの削除に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// GetVersion はETagを版として返します
+func (s *s3Storage) GetVersion(ctx context.Context, key string) ([]byte, string, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, "", errObjectNotFound
+		}
+		return nil, "", fmt.Errorf("S3からの読み込みに失敗しました: %w", err)
+	}
+	defer out.Body.Close()
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, aws.ToString(out.ETag), nil
+}
+
+// PutIf はIf-Match（versionが空の場合はIf-None-Match: *）を付けて保存します
+func (s *s3Storage) PutIf(ctx context.Context, key string, data []byte, version string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	}
+	if version == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		input.IfMatch = aws.String(version)
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		if isS3Conflict(err) || (version != "" && isS3NotFound(err)) {
+			return errVersionConflict
+		}
+		return fmt.Errorf("S3への保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// DeleteIf はIf-Matchを付けて削除します
+func (s *s3Storage) DeleteIf(ctx context.Context, key, version string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return errVersionConflict
+	}
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:  aws.String(s.bucket),
+		Key:     aws.String(objectKey),
+		IfMatch: aws.String(version),
+	})
+	if err != nil {
+		if isS3Conflict(err) || isS3NotFound(err) {
+			return errVersionConflict
+		}
+		return fmt.Errorf("S3からの削除に失敗しました: %w", err)
+	}
+	return nil
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]blobInfo, error) {
+	var blobs []blobInfo
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("S3の一覧取得に失敗しました: %w", err)
+		}
+		for _, obj := range page.Contents {
+			blobs = append(blobs, blobInfo{
+				Key:  strings.TrimPrefix(aws.ToString(obj.Key), s.prefix),
+				Size: aws.ToInt64(obj.Size),
+			})
+		}
+	}
+	return blobs, nil
+}
+
+func (s *s3Storage) Describe() string {
+	return "s3:" + s.bucket + "/" + s.prefix
+}
+
+// isS3Conflict は条件付きの書き込みや削除で条件を満たさなかったエラーかどうかを返します
+func isS3Conflict(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return true
+		}
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			return true
+		}
+	}
+	return false
+}
+
+// isS3InvalidRange は範囲の開始位置がデータの末尾以降だったエラーかどうかを返します
+func isS3InvalidRange(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+		return true
+	}
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusRequestedRangeNotSatisfiable
+}
+
+func isS3NotFound(err error) bool {
+	var noSuchKey *s3types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NotFound":
+			return true
+		}
+	}
+	return false
+}
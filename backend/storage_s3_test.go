@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 はテストのためにS3のAPIのうちs3Storageが使う部分だけを、パス形式のURLでメモリ上に実装します。
+// 条件付きの書き込みと削除（If-Match / If-None-Match）、範囲指定の読み込み、マルチパートアップロードに対応します。
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	// uploads はマルチパートアップロードのIDごとの、パート番号ごとのデータです
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string][]byte), uploads: make(map[string]map[int][]byte)}
+}
+
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func writeS3XML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	query := r.URL.Query()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, query.Get("prefix"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		writeS3XML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: id})
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[n] = body
+		w.Header().Set("ETag", fakeETag(body))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.complete(w, bucket, key, query.Get("uploadId"), body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		current, exists := f.objects[key]
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != fakeETag(current)) {
+			writeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			writeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", fakeETag(body))
+	case r.Method == http.MethodGet:
+		f.get(w, r, key)
+	case r.Method == http.MethodDelete:
+		current, exists := f.objects[key]
+		if match := r.Header.Get("If-Match"); match != "" {
+			if !exists {
+				writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			if match != fakeETag(current) {
+				writeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+				return
+			}
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// get はGetObjectを処理します。Rangeは bytes=<開始>-<終了> の形式だけに対応します。
+func (f *fakeS3) get(w http.ResponseWriter, r *http.Request, key string) {
+	data, ok := f.objects[key]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	w.Header().Set("ETag", fakeETag(data))
+	spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	if !ok {
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+		return
+	}
+	first, last, _ := strings.Cut(spec, "-")
+	start, err1 := strconv.Atoi(first)
+	end, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || start >= len(data) {
+		// 開始位置が末尾以降の範囲は、S3と同じく416を返す
+		writeS3Error(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+		return
+	}
+	end = min(end, len(data)-1)
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+	w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(data[start : end+1])
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key  string
+		Size int
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: f.bucket, Prefix: prefix}
+	for key, data := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{Key: key, Size: len(data)})
+		}
+	}
+	slices.SortFunc(result.Contents, func(a, b content) int { return strings.Compare(a.Key, b.Key) })
+	result.KeyCount = len(result.Contents)
+	writeS3XML(w, result)
+}
+
+func (f *fakeS3) complete(w http.ResponseWriter, bucket, key, uploadID string, body []byte) {
+	parts, ok := f.uploads[uploadID]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	var request struct {
+		Parts []struct {
+			PartNumber int
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	var data bytes.Buffer
+	for _, part := range request.Parts {
+		p, ok := parts[part.PartNumber]
+		if !ok {
+			writeS3Error(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		data.Write(p)
+	}
+	delete(f.uploads, uploadID)
+	f.objects[key] = data.Bytes()
+	writeS3XML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: bucket, Key: key, ETag: fakeETag(data.Bytes())})
+}
+
+// newFakeS3Storage はhttptestで起動したfakeS3に接続したs3Storageを返します
+func newFakeS3Storage(t *testing.T) (*s3Storage, *fakeS3) {
+	t.Helper()
+	fake := newFakeS3("scores")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	storage, err := newS3Storage(context.Background(), s3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "scores",
+		Prefix:          "score-splitter-test",
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage, fake
+}
+
+func TestS3Storage(t *testing.T) {
+	storage, fake := newFakeS3Storage(t)
+	testBlobStorage(t, storage)
+
+	// 途中で失敗したマルチパートアップロードは破棄する
+	large := bytes.Repeat([]byte("0123456789abcdef"), s3PartSize/16+1)
+	if err := storage.PutStream(context.Background(), "alice/scores/short.pdf", bytes.NewReader(large), int64(len(large))+1); err == nil {
+		t.Error("PutStream with short multipart body succeeded")
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.uploads) != 0 {
+		t.Errorf("%d multipart uploads left after a failed PutStream", len(fake.uploads))
+	}
+}
+
+// TestS3StorageWithBucket は実際のS3互換ストレージ（MinIOなど）に対して保存先の動作を確かめます。
+// SCORE_SPLITTER_TEST_S3_BUCKET が設定されている場合だけ実行します。認証情報は AWS SDK の標準の順序で探すため、
+// MinIOでは例えば次のように実行します:
+//
+//	SCORE_SPLITTER_TEST_S3_ENDPOINT=http://localhost:9000 SCORE_SPLITTER_TEST_S3_BUCKET=scores \
+//	AWS_ACCESS_KEY_ID=minioadmin AWS_SECRET_ACCESS_KEY=minioadmin go test -run S3StorageWithBucket .
+func TestS3StorageWithBucket(t *testing.T) {
+	bucket := os.Getenv("SCORE_SPLITTER_TEST_S3_BUCKET")
+	if bucket == "" {
+		t.Skip("SCORE_SPLITTER_TEST_S3_BUCKET が設定されていません")
+	}
+	id, err := newObjectID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := os.Getenv("SCORE_SPLITTER_TEST_S3_ENDPOINT")
+	storage, err := newS3Storage(context.Background(), s3Config{
+		Endpoint: endpoint,
+		Region:   os.Getenv("SCORE_SPLITTER_TEST_S3_REGION"),
+		Bucket:   bucket,
+		// 実行ごとに別の接頭辞を使い、既存のデータや他の実行と重ならないようにする
+		Prefix:       "score-splitter-test/" + id,
+		UsePathStyle: endpoint != "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStorage(t, storage)
+}
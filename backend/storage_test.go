@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testBlobStorage は保存先の実装が blobStorage の約束を守っているかを確かめます
+func testBlobStorage(t *testing.T, storage blobStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, "alice/scores/a.pdf", []byte("0123456789")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := storage.Put(ctx, "alice/scores/b.json", []byte("{}")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := storage.Put(ctx, "bob/scores/a.pdf", []byte("bob")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	data, err := storage.Get(ctx, "alice/scores/a.pdf")
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("Get = %q, %v", data, err)
+	}
+	data, err = storage.GetRange(ctx, "alice/scores/a.pdf", 3, 4)
+	if err != nil || string(data) != "3456" {
+		t.Errorf("GetRange(3, 4) = %q, %v", data, err)
+	}
+	// 末尾を超える部分は返さない
+	data, err = storage.GetRange(ctx, "alice/scores/a.pdf", 8, 10)
+	if err != nil || string(data) != "89" {
+		t.Errorf("GetRange(8, 10) = %q, %v", data, err)
+	}
+	// 開始位置が末尾以降の場合は空のデータを返す
+	for _, offset := range []int64{10, 20} {
+		data, err = storage.GetRange(ctx, "alice/scores/a.pdf", offset, 4)
+		if err != nil || len(data) != 0 {
+			t.Errorf("GetRange(%d, 4) = %q, %v, want empty", offset, data, err)
+		}
+	}
+
+	blobs, err := storage.List(ctx, "alice/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var keys []string
+	for _, blob := range blobs {
+		keys = append(keys, blob.Key)
+		if blob.Key == "alice/scores/a.pdf" && blob.Size != 10 {
+			t.Errorf("size of %s = %d, want 10", blob.Key, blob.Size)
+		}
+	}
+	slices.Sort(keys)
+	if want := []string{"alice/scores/a.pdf", "alice/scores/b.json"}; !slices.Equal(keys, want) {
+		t.Errorf("List = %v, want %v", keys, want)
+	}
+
+	if err := storage.Delete(ctx, "alice/scores/a.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := storage.Get(ctx, "alice/scores/a.pdf"); !errors.Is(err, errObjectNotFound) {
+		t.Errorf("Get after Delete: err = %v, want errObjectNotFound", err)
+	}
+	if _, err := storage.GetRange(ctx, "alice/scores/a.pdf", 0, 1); !errors.Is(err, errObjectNotFound) {
+		t.Errorf("GetRange after Delete: err = %v, want errObjectNotFound", err)
+	}
+	// 存在しないキーの削除はエラーにしない
+	if err := storage.Delete(ctx, "alice/scores/a.pdf"); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+
+	// 長さがsizeと異なる場合は何も保存しない
+	if err := storage.PutStream(ctx, "alice/scores/short.pdf", strings.NewReader("abc"), 4); !errors.Is(err, errStreamSize) {
+		t.Errorf("PutStream with short body: err = %v, want errStreamSize", err)
+	}
+	if err := storage.PutStream(ctx, "alice/scores/long.pdf", strings.NewReader("abcde"), 4); !errors.Is(err, errStreamSize) {
+		t.Errorf("PutStream with long body: err = %v, want errStreamSize", err)
+	}
+	for _, key := range []string{"alice/scores/short.pdf", "alice/scores/long.pdf"} {
+		if _, err := storage.Get(ctx, key); !errors.Is(err, errObjectNotFound) {
+			t.Errorf("Get(%s) after failed PutStream: err = %v, want errObjectNotFound", key, err)
+		}
+	}
+	// S3ではマルチパートアップロードになる大きさ
+	large := bytes.Repeat([]byte("0123456789abcdef"), s3PartSize/16+1)
+	if err := storage.PutStream(ctx, "alice/scores/large.pdf", bytes.NewReader(large), int64(len(large))); err != nil {
+		t.Fatalf("PutStream large: %v", err)
+	}
+	data, err = storage.GetRange(ctx, "alice/scores/large.pdf", int64(len(large))-20, 20)
+	if err != nil || !bytes.Equal(data, large[len(large)-20:]) {
+		t.Errorf("GetRange of large object = %q, %v", data, err)
+	}
+
+	// 条件付きの書き込みと削除は、読んだ時の版から変わっていない場合だけ成功する
+	if err := storage.PutIf(ctx, "alice/scores/c.json", []byte("v1"), ""); err != nil {
+		t.Fatalf("PutIf for new key: %v", err)
+	}
+	if err := storage.PutIf(ctx, "alice/scores/c.json", []byte("v1"), ""); !errors.Is(err, errVersionConflict) {
+		t.Errorf("PutIf for existing key: err = %v, want errVersionConflict", err)
+	}
+	data, version, err := storage.GetVersion(ctx, "alice/scores/c.json")
+	if err != nil || string(data) != "v1" || version == "" {
+		t.Fatalf("GetVersion = %q, %q, %v", data, version, err)
+	}
+	if err := storage.PutIf(ctx, "alice/scores/c.json", []byte("v2"), version); err != nil {
+		t.Fatalf("PutIf with current version: %v", err)
+	}
+	if err := storage.PutIf(ctx, "alice/scores/c.json", []byte("v3"), version); !errors.Is(err, errVersionConflict) {
+		t.Errorf("PutIf with stale version: err = %v, want errVersionConflict", err)
+	}
+	if err := storage.DeleteIf(ctx, "alice/scores/c.json", version); !errors.Is(err, errVersionConflict) {
+		t.Errorf("DeleteIf with stale version: err = %v, want errVersionConflict", err)
+	}
+	if data, err := storage.Get(ctx, "alice/scores/c.json"); err != nil || string(data) != "v2" {
+		t.Errorf("Get after conflicts = %q, %v, want v2", data, err)
+	}
+	_, version, err = storage.GetVersion(ctx, "alice/scores/c.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.DeleteIf(ctx, "alice/scores/c.json", version); err != nil {
+		t.Errorf("DeleteIf with current version: %v", err)
+	}
+	if err := storage.PutIf(ctx, "alice/scores/c.json", []byte("v4"), version); !errors.Is(err, errVersionConflict) {
+		t.Errorf("PutIf after delete: err = %v, want errVersionConflict", err)
+	}
+	if _, _, err := storage.GetVersion(ctx, "alice/scores/c.json"); !errors.Is(err, errObjectNotFound) {
+		t.Errorf("GetVersion after DeleteIf: err = %v, want errObjectNotFound", err)
+	}
+
+	if err := storage.Put(ctx, "../escape", []byte("x")); err == nil {
+		t.Error("Put accepted a key outside the storage")
+	}
+
+	for _, key := range []string{"alice/scores/b.json", "alice/scores/large.pdf", "bob/scores/a.pdf"} {
+		if err := storage.Delete(ctx, key); err != nil {
+			t.Errorf("Delete(%s): %v", key, err)
+		}
+	}
+}
+
+func TestFSStorage(t *testing.T) {
+	testBlobStorage(t, newFSStorage(t.TempDir()))
+}
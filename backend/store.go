@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 保存するデータの種類。テナントのディレクトリの下で種類ごとに分けて保存します。
+const (
+	storeKindScores    = "scores"
+	storeKindTemplates = "templates"
+)
+
+// storeKindExtensions はデータの種類ごとのファイル拡張子です
+var storeKindExtensions = map[string]string{
+	storeKindScores:    ".pdf",
+	storeKindTemplates: ".pb",
+}
+
+const metadataExtension = ".json"
+
+// contentIndexDir は内容のSHA-256からIDを引く索引の置き場所です。種類ごとのディレクトリの下に置きます。
+const contentIndexDir = "by-sha256/"
+
+// maxUpdateAttempts は参照の数や索引を条件付きで更新する際に、他の処理と競合して読み直す回数の上限です
+const maxUpdateAttempts = 10
+
+var (
+	errObjectNotFound = errors.New("指定されたデータが見つかりません")
+	errQuotaExceeded  = errors.New("保存容量の上限を超えています")
+)
+
+// storedObject は保存したデータのメタデータです
+type storedObject struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+	// SHA256 は内容のハッシュです。重複の判定に使います。
+	SHA256 string `json:"sha256,omitempty"`
+	// Titles は同じ内容をアップロードした1回ごとのタイトルを古い順に並べたものです。
+	// 同じタイトルで複数回アップロードした場合はその回数だけ並びます。
+	Titles []string `json:"titles,omitempty"`
+	// Refs は同じ内容をアップロードした回数です。削除するたびに1つ減らし、0になったら本体を削除します。
+	// 参照の数を数える前に保存したデータでは0なので、1として扱います。
+	Refs int `json:"refs,omitempty"`
+}
+
+// refs は参照の数を返します
+func (o *storedObject) refs() int {
+	return max(o.Refs, 1)
+}
+
+// scoreStore はスコアなどのデータをテナントごとに分けて保存します。
+// キーは <テナント>/<種類>/<ID><拡張子> で、メタデータは同じ場所の <ID>.json です。
+type scoreStore struct {
+	storage    blobStorage
+	quotaBytes int64
+
+	// mu は容量の確認からメタデータの書き込みまでを直列化します。
+	// 本体の書き込みは時間がかかるので、muを持たずに行ってからメタデータで公開します。
+	// 複数のレプリカで共有する場合、容量の上限は各レプリカ内でのみ厳密に守られます。
+	// 参照の数と索引はレプリカの間でも壊れないよう、muではなく条件付きの書き込みで更新します。
+	mu sync.Mutex
+}
+
+func newScoreStore(storage blobStorage, quotaBytes int64) *scoreStore {
+	return &scoreStore{storage: storage, quotaBytes: quotaBytes}
+}
+
+var objectIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func newObjectID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// hashedTenantPrefix はキーに使えないテナント名をハッシュにした場合の接頭辞です。
+// この接頭辞で始まるテナント名もハッシュにして、別のテナントのハッシュと重ならないようにします。
+const hashedTenantPrefix = "t-"
+
+// tenantDirName はテナント名をキーとして安全な文字列に変換します
+func tenantDirName(tenant string) string {
+	if tenantNamePattern.MatchString(tenant) && !strings.Contains(tenant, "..") && !strings.HasPrefix(tenant, hashedTenantPrefix) {
+		return tenant
+	}
+	// 衝突を実質的に起こさないように、ハッシュの先頭16バイト（128ビット）を使う
+	sum := sha256.Sum256([]byte(tenant))
+	return hashedTenantPrefix + hex.EncodeToString(sum[:16])
+}
+
+func tenantPrefix(tenant string) string {
+	return tenantDirName(tenant) + "/"
+}
+
+func kindPrefix(tenant, kind string) string {
+	return tenantPrefix(tenant) + kind + "/"
+}
+
+func contentIndexKey(tenant, kind, sum string) string {
+	return kindPrefix(tenant, kind) + contentIndexDir + sum
+}
+
+func objectKeys(tenant, kind, id string) (data, meta string) {
+	ext, ok := storeKindExtensions[kind]
+	if !ok {
+		ext = ".bin"
+	}
+	prefix := kindPrefix(tenant, kind)
+	return prefix + id + ext, prefix + id + metadataExtension
+}
+
+// put はデータを保存し、メタデータを返します。テナントの容量を超える場合はerrQuotaExceededを返します。
+// 同じテナントに同じ内容のデータが既にある場合は保存せずに参照の数とタイトルだけを追加し、duplicateにtrueを返します。
+func (s *scoreStore) put(ctx context.Context, tenant, kind, title string, data []byte) (obj *storedObject, duplicate bool, err error) {
+	hash := sha256.Sum256(data)
+	return s.putStream(ctx, tenant, kind, title, hex.EncodeToString(hash[:]), int64(len(data)), bytes.NewReader(data), "")
+}
+
+// putStream はputと同じくデータを保存しますが、内容をbodyから少しずつ読みます。
+// sumはbodyの内容のSHA-256で、呼び出し元で確かめておきます。
+// uploadIDには保存するデータの分割アップロードのIDを渡します。そのアップロードが予約した容量は使用量に数えません。
+func (s *scoreStore) putStream(ctx context.Context, tenant, kind, title, sum string, size int64, body io.Reader, uploadID string) (obj *storedObject, duplicate bool, err error) {
+	if existing, err := s.addReference(ctx, tenant, kind, title, sum); err != nil || existing != nil {
+		return existing, existing != nil, err
+	}
+
+	// 本体の書き込みは大きなデータだと時間がかかるので、他の保存を待たせないようs.muの外で行う
+	obj, err = s.writeData(ctx, tenant, kind, title, sum, size, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(ctx, tenant, kind, obj, uploadID); err != nil {
+		return nil, false, err
+	}
+	// 索引を登録する。書き込んでいる間に同じ内容が（他のレプリカでも）保存されていた場合は、
+	// 書き込んだものを消してそちらの参照を増やす
+	for range maxUpdateAttempts {
+		match, err := s.lookupContent(ctx, tenant, kind, sum)
+		if err != nil {
+			s.discard(ctx, tenant, kind, obj.ID)
+			return nil, false, err
+		}
+		if match.obj != nil {
+			existing, err := s.incrementRefs(ctx, tenant, kind, title, match)
+			if errors.Is(err, errVersionConflict) {
+				continue
+			}
+			s.discard(ctx, tenant, kind, obj.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			return existing, true, nil
+		}
+		err = s.storage.PutIf(ctx, contentIndexKey(tenant, kind, sum), []byte(obj.ID), match.indexVersion)
+		if errors.Is(err, errVersionConflict) {
+			continue
+		}
+		// 索引は重複の判定にしか使わないので、書き込みに失敗しても保存自体は成功とする
+		return obj, false, nil
+	}
+	s.discard(ctx, tenant, kind, obj.ID)
+	return nil, false, fmt.Errorf("%w: 索引を%d回更新できませんでした", errVersionConflict, maxUpdateAttempts)
+}
+
+// addReference は同じ内容のデータが既にあれば、参照の数とタイトルを追加してそのメタデータを返します。
+// 無い場合はnilを返します。
+func (s *scoreStore) addReference(ctx context.Context, tenant, kind, title, sum string) (*storedObject, error) {
+	for range maxUpdateAttempts {
+		match, err := s.lookupContent(ctx, tenant, kind, sum)
+		if err != nil || match.obj == nil {
+			return nil, err
+		}
+		existing, err := s.incrementRefs(ctx, tenant, kind, title, match)
+		if !errors.Is(err, errVersionConflict) {
+			return existing, err
+		}
+	}
+	return nil, fmt.Errorf("%w: 参照の数を%d回更新できませんでした", errVersionConflict, maxUpdateAttempts)
+}
+
+// incrementRefs はmatchのデータに参照とタイトルを1つ追加します。
+// 読んだ後にメタデータが書き換えられていた場合はerrVersionConflictを返します。
+func (s *scoreStore) incrementRefs(ctx context.Context, tenant, kind, title string, match contentMatch) (*storedObject, error) {
+	obj := *match.obj
+	obj.Refs = obj.refs() + 1
+	obj.Titles = append(slices.Clone(obj.Titles), title)
+	if err := s.writeMetadataIf(ctx, tenant, kind, &obj, match.version); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// discard はpublishしたデータのメタデータと本体を削除します
+func (s *scoreStore) discard(ctx context.Context, tenant, kind, id string) {
+	dataKey, metaKey := objectKeys(tenant, kind, id)
+	s.storage.Delete(ctx, metaKey)
+	s.storage.Delete(ctx, dataKey)
+}
+
+// putNew は内容が同じデータがあっても新しいIDで保存します。テンプレートのように
+// 名前ごとに別のものとして扱うデータに使います。
+func (s *scoreStore) putNew(ctx context.Context, tenant, kind, title string, data []byte) (*storedObject, error) {
+	obj, err := s.writeData(ctx, tenant, kind, title, "", int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(ctx, tenant, kind, obj, ""); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// putUnmetered は容量を確認せず、同じ内容のデータがあっても新しいIDで保存します。
+// kindはusageで数えない種類（unmeteredKinds）にします。
+func (s *scoreStore) putUnmetered(ctx context.Context, tenant, kind, title string, data []byte) (*storedObject, error) {
+	return s.putUnmeteredStream(ctx, tenant, kind, title, int64(len(data)), bytes.NewReader(data))
+}
+
+// putUnmeteredStream はputUnmeteredと同じですが、内容をbodyから少しずつ読みます。
+// SHA-256は書き込みながら求めます。
+func (s *scoreStore) putUnmeteredStream(ctx context.Context, tenant, kind, title string, size int64, body io.Reader) (*storedObject, error) {
+	hash := sha256.New()
+	obj, err := s.writeData(ctx, tenant, kind, title, "", size, io.TeeReader(body, hash))
+	if err != nil {
+		return nil, err
+	}
+	obj.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	obj.Titles = []string{title}
+	if err := s.writeMetadataOrDelete(ctx, tenant, kind, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// writeData は新しいIDで本体だけを書き込み、まだ保存していないメタデータを返します。
+// メタデータが存在するものだけを一覧や使用量の対象にするので、書き込んだ本体はpublishするまで見えません。
+func (s *scoreStore) writeData(ctx context.Context, tenant, kind, title, sum string, size int64, body io.Reader) (*storedObject, error) {
+	id, err := newObjectID()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &storedObject{
+		ID:        id,
+		Title:     title,
+		SizeBytes: size,
+		CreatedAt: time.Now().UTC(),
+		SHA256:    sum,
+		Refs:      1,
+	}
+	if sum != "" {
+		obj.Titles = []string{title}
+	}
+
+	dataKey, _ := objectKeys(tenant, kind, id)
+	if err := s.storage.PutStream(ctx, dataKey, body, size); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// publish はwriteDataで書き込んだ本体の分の容量を確認してからメタデータを書き込みます。
+// 失敗した場合は本体を削除します。s.muを持って呼び出します。
+func (s *scoreStore) publish(ctx context.Context, tenant, kind string, obj *storedObject, uploadID string) error {
+	if err := s.checkQuota(ctx, tenant, obj.SizeBytes, uploadID); err != nil {
+		dataKey, _ := objectKeys(tenant, kind, obj.ID)
+		s.storage.Delete(ctx, dataKey)
+		return err
+	}
+	return s.writeMetadataOrDelete(ctx, tenant, kind, obj)
+}
+
+// writeMetadataOrDelete はメタデータを書き込み、失敗した場合は本体を削除します
+func (s *scoreStore) writeMetadataOrDelete(ctx context.Context, tenant, kind string, obj *storedObject) error {
+	if err := s.writeMetadata(ctx, tenant, kind, obj); err != nil {
+		dataKey, _ := objectKeys(tenant, kind, obj.ID)
+		s.storage.Delete(ctx, dataKey)
+		return err
+	}
+	return nil
+}
+
+// checkQuota はsizeバイトを追加してもテナントの容量を超えないかを確認します。s.muを持って呼び出します。
+// uploadIDのアップロードが予約した容量は、これから保存するデータ自身の分なので使用量に数えません。
+func (s *scoreStore) checkQuota(ctx context.Context, tenant string, size int64, uploadID string) error {
+	if s.quotaBytes <= 0 {
+		return nil
+	}
+	used, err := s.usageExcept(ctx, tenant, uploadID)
+	if err != nil {
+		return err
+	}
+	if used+size > s.quotaBytes {
+		return fmt.Errorf("%w: 使用量%dバイト + %dバイト > 上限%dバイト", errQuotaExceeded, used, size, s.quotaBytes)
+	}
+	return nil
+}
+
+func (s *scoreStore) writeMetadata(ctx context.Context, tenant, kind string, obj *storedObject) error {
+	meta, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, metaKey := objectKeys(tenant, kind, obj.ID)
+	return s.storage.Put(ctx, metaKey, meta)
+}
+
+// writeMetadataIf はメタデータの版がversionのままの場合だけ書き込みます
+func (s *scoreStore) writeMetadataIf(ctx context.Context, tenant, kind string, obj *storedObject, version string) error {
+	meta, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, metaKey := objectKeys(tenant, kind, obj.ID)
+	return s.storage.PutIf(ctx, metaKey, meta, version)
+}
+
+// contentMatch は内容の索引を引いた結果です
+type contentMatch struct {
+	// obj は同じ内容のデータのメタデータで、無い場合はnilです
+	obj *storedObject
+	// version はobjのメタデータの版です
+	version string
+	// indexVersion は索引の版です。索引が無い場合は空です。
+	indexVersion string
+}
+
+// findByContent は同じ内容のデータのメタデータを返します。見つからない場合はnilを返します。
+func (s *scoreStore) findByContent(ctx context.Context, tenant, kind, sum string) (*storedObject, error) {
+	match, err := s.lookupContent(ctx, tenant, kind, sum)
+	return match.obj, err
+}
+
+// lookupContent は同じ内容のデータを索引から探し、条件付きで更新するための版と合わせて返します
+func (s *scoreStore) lookupContent(ctx context.Context, tenant, kind, sum string) (contentMatch, error) {
+	id, indexVersion, err := s.storage.GetVersion(ctx, contentIndexKey(tenant, kind, sum))
+	if errors.Is(err, errObjectNotFound) {
+		return contentMatch{}, nil
+	}
+	if err != nil {
+		return contentMatch{}, err
+	}
+	match := contentMatch{indexVersion: indexVersion}
+	obj, version, err := s.statVersion(ctx, tenant, kind, string(id))
+	if errors.Is(err, errObjectNotFound) {
+		// 削除済みのデータを指す古い索引
+		return match, nil
+	}
+	if err != nil {
+		return contentMatch{}, err
+	}
+	if obj.SHA256 == sum {
+		match.obj, match.version = obj, version
+	}
+	return match, nil
+}
+
+// stat は保存したデータのメタデータを返します
+func (s *scoreStore) stat(ctx context.Context, tenant, kind, id string) (*storedObject, error) {
+	obj, _, err := s.statVersion(ctx, tenant, kind, id)
+	return obj, err
+}
+
+// statVersion はstatと同じくメタデータを返し、あわせて条件付きで更新するための版を返します
+func (s *scoreStore) statVersion(ctx context.Context, tenant, kind, id string) (*storedObject, string, error) {
+	if !objectIDPattern.MatchString(id) {
+		return nil, "", errObjectNotFound
+	}
+	_, metaKey := objectKeys(tenant, kind, id)
+	meta, version, err := s.storage.GetVersion(ctx, metaKey)
+	if err != nil {
+		return nil, "", err
+	}
+	var obj storedObject
+	if err := json.Unmarshal(meta, &obj); err != nil {
+		return nil, "", fmt.Errorf("メタデータ%sが壊れています: %w", id, err)
+	}
+	return &obj, version, nil
+}
+
+// get は保存したデータとメタデータを返します
+func (s *scoreStore) get(ctx context.Context, tenant, kind, id string) (*storedObject, []byte, error) {
+	obj, err := s.stat(ctx, tenant, kind, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataKey, _ := objectKeys(tenant, kind, id)
+	data, err := s.storage.Get(ctx, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, data, nil
+}
+
+// getRange は保存したデータのoffsetからlengthバイトを返します
+func (s *scoreStore) getRange(ctx context.Context, tenant, kind, id string, offset, length int64) ([]byte, error) {
+	if !objectIDPattern.MatchString(id) {
+		return nil, errObjectNotFound
+	}
+	dataKey, _ := objectKeys(tenant, kind, id)
+	return s.storage.GetRange(ctx, dataKey, offset, length)
+}
+
+// list はテナントの指定した種類のデータを新しい順に返します
+func (s *scoreStore) list(ctx context.Context, tenant, kind string) ([]*storedObject, error) {
+	prefix := kindPrefix(tenant, kind)
+	blobs, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*storedObject
+	for _, blob := range blobs {
+		id, ok := strings.CutSuffix(strings.TrimPrefix(blob.Key, prefix), metadataExtension)
+		if !ok || !objectIDPattern.MatchString(id) {
+			continue
+		}
+		obj, err := s.stat(ctx, tenant, kind, id)
+		if errors.Is(err, errObjectNotFound) {
+			// 一覧の取得後に削除されたもの
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].CreatedAt.After(objects[j].CreatedAt)
+	})
+	return objects, nil
+}
+
+// delete は保存したデータの参照を1つ削除します。同じ内容のアップロードが他にも残っている場合は
+// 参照の数だけを減らし、最後の参照を削除した時に本体とメタデータを削除します。
+// 本体を削除した場合はremovedにtrueを返します。
+func (s *scoreStore) delete(ctx context.Context, tenant, kind, id string) (removed bool, err error) {
+	return s.deleteUpload(ctx, tenant, kind, id, "")
+}
+
+// deleteUpload はdeleteと同じく参照を1つ削除し、そのアップロードのタイトルをTitlesから取り除きます。
+// titleが空の場合は最後のアップロードを削除します。titleのアップロードが無い場合はerrObjectNotFoundを返します。
+func (s *scoreStore) deleteUpload(ctx context.Context, tenant, kind, id, title string) (removed bool, err error) {
+	for range maxUpdateAttempts {
+		removed, err := s.tryDeleteUpload(ctx, tenant, kind, id, title)
+		if !errors.Is(err, errVersionConflict) {
+			return removed, err
+		}
+	}
+	return false, fmt.Errorf("%w: 参照の数を%d回更新できませんでした", errVersionConflict, maxUpdateAttempts)
+}
+
+// tryDeleteUpload はdeleteUploadを1回試みます。読んだ後にメタデータが書き換えられていた場合はerrVersionConflictを返します。
+func (s *scoreStore) tryDeleteUpload(ctx context.Context, tenant, kind, id, title string) (removed bool, err error) {
+	obj, version, err := s.statVersion(ctx, tenant, kind, id)
+	if err != nil {
+		return false, err
+	}
+	index := len(obj.Titles) - 1
+	if title != "" {
+		index = slices.Index(obj.Titles, title)
+		if index < 0 && (len(obj.Titles) > 0 || obj.Title != title) {
+			return false, fmt.Errorf("%w: タイトル%qのアップロードがありません", errObjectNotFound, title)
+		}
+	}
+	if obj.refs() > 1 {
+		obj.Refs--
+		if index >= 0 {
+			obj.Titles = slices.Delete(obj.Titles, index, index+1)
+		}
+		if len(obj.Titles) > 0 {
+			obj.Title = obj.Titles[0]
+		}
+		return false, s.writeMetadataIf(ctx, tenant, kind, obj, version)
+	}
+
+	// メタデータを消した後は他の処理が参照を増やせないので、本体と索引を消してよい
+	dataKey, metaKey := objectKeys(tenant, kind, id)
+	if err := s.storage.DeleteIf(ctx, metaKey, version); err != nil {
+		return false, err
+	}
+	if obj.SHA256 != "" {
+		// 索引が別のIDを指している場合（索引の書き込みに失敗して同じ内容が2つ保存された場合や、
+		// 他の処理が新しいデータで索引を置き換えた場合）は残す
+		indexKey := contentIndexKey(tenant, kind, obj.SHA256)
+		if indexed, indexVersion, err := s.storage.GetVersion(ctx, indexKey); err == nil && string(indexed) == id {
+			s.storage.DeleteIf(ctx, indexKey, indexVersion)
+		}
+	}
+	return true, s.storage.Delete(ctx, dataKey)
+}
+
+// unmeteredKinds はテナントの容量に数えないデータの種類です。
+// サーバーが生成して保持期間が過ぎると削除するデータなので、利用者の保存容量を圧迫しないようにします。
+var unmeteredKinds = []string{storeKindResults, storeKindJobInputs}
+
+// usage はテナントが保存している全データの合計サイズを返します。
+// 分割アップロードは受信済みのチャンクではなく、開始時に予約したファイルサイズを数えます。
+// メタデータの無い本体は書き込み中（publishの前）のものなので数えません。
+func (s *scoreStore) usage(ctx context.Context, tenant string) (int64, error) {
+	return s.usageExcept(ctx, tenant, "")
+}
+
+// usageExcept はusageと同じですが、uploadIDのアップロードの予約を数えません
+func (s *scoreStore) usageExcept(ctx context.Context, tenant, uploadID string) (int64, error) {
+	blobs, err := s.storage.List(ctx, tenantPrefix(tenant))
+	if err != nil {
+		return 0, err
+	}
+	uploads := kindPrefix(tenant, storeKindUploads)
+	keys := make(map[string]bool, len(blobs))
+	for _, blob := range blobs {
+		keys[blob.Key] = true
+	}
+	var total int64
+	for _, blob := range blobs {
+		if slices.ContainsFunc(unmeteredKinds, func(kind string) bool {
+			return strings.HasPrefix(blob.Key, kindPrefix(tenant, kind))
+		}) {
+			continue
+		}
+		rest, ok := strings.CutPrefix(blob.Key, uploads)
+		if !ok {
+			if ext := path.Ext(blob.Key); ext != metadataExtension && objectIDPattern.MatchString(path.Base(strings.TrimSuffix(blob.Key, ext))) &&
+				!keys[strings.TrimSuffix(blob.Key, ext)+metadataExtension] {
+				continue
+			}
+			total += blob.Size
+			continue
+		}
+		id, ok := strings.CutSuffix(rest, "/"+uploadSessionFile)
+		if !ok || id == uploadID {
+			continue
+		}
+		session, err := s.uploadSession(ctx, tenant, id)
+		if errors.Is(err, errObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if time.Now().Before(session.ExpiresAt) {
+			total += session.TotalSize
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, quotaBytes int64) *scoreStore {
+	t.Helper()
+	return newScoreStore(newFSStorage(t.TempDir()), quotaBytes)
+}
+
+func TestTenantDirName(t *testing.T) {
+	hashed := tenantDirName("a/b")
+	tests := []struct {
+		tenant string
+		want   string
+	}{
+		{tenant: "alice", want: "alice"},
+		{tenant: "team.a-1_b", want: "team.a-1_b"},
+		// ハッシュはSHA-256の先頭16バイトを使う
+		{tenant: "a/b", want: "t-c14cddc033f64b9dea80ea675cf280a0"},
+		// ハッシュにした名前と同じ名前のテナントは、そのまま使うと別のテナントのデータと重なる
+		{tenant: hashed},
+		{tenant: "t-alice"},
+		{tenant: "../alice"},
+		{tenant: "a..b"},
+		{tenant: ""},
+	}
+	for _, tt := range tests {
+		got := tenantDirName(tt.tenant)
+		if tt.want != "" {
+			if got != tt.want {
+				t.Errorf("tenantDirName(%q) = %q, want %q", tt.tenant, got, tt.want)
+			}
+			continue
+		}
+		if !tenantNamePattern.MatchString(got) || got == tt.tenant {
+			t.Errorf("tenantDirName(%q) = %q, want hashed name", tt.tenant, got)
+		}
+	}
+	if tenantDirName(hashed) == hashed {
+		t.Errorf("tenant %q shares the directory of tenant %q", hashed, "a/b")
+	}
+}
+
+func TestStorePutNewKeepsTenantsApart(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 0)
+
+	first, err := store.putNew(ctx, "alice", storeKindTemplates, "A4", []byte("settings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同じ内容でも別のテンプレートとして保存する
+	second, err := store.putNew(ctx, "alice", storeKindTemplates, "A4 copy", []byte("settings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("putNew reused id %s", first.ID)
+	}
+
+	objects, err := store.list(ctx, "alice", storeKindTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("alice has %d templates, want 2", len(objects))
+	}
+	objects, err = store.list(ctx, "bob", storeKindTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("bob has %d templates, want 0", len(objects))
+	}
+	if _, _, err := store.get(ctx, "bob", storeKindTemplates, first.ID); err != errObjectNotFound {
+		t.Errorf("get from other tenant: err = %v, want errObjectNotFound", err)
+	}
+}
+
+func TestStoreDedupRefcount(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 0)
+	pdf := []byte("%PDF-1.7 same content")
+
+	first, duplicate, err := store.put(ctx, "alice", storeKindScores, "first", pdf)
+	if err != nil || duplicate {
+		t.Fatalf("first put: duplicate=%v err=%v", duplicate, err)
+	}
+	second, duplicate, err := store.put(ctx, "alice", storeKindScores, "second", pdf)
+	if err != nil || !duplicate {
+		t.Fatalf("second put: duplicate=%v err=%v", duplicate, err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("duplicate got id %s, want %s", second.ID, first.ID)
+	}
+	// 別のテナントとは共有しない
+	if _, duplicate, err := store.put(ctx, "bob", storeKindScores, "bob", pdf); err != nil || duplicate {
+		t.Fatalf("put for other tenant: duplicate=%v err=%v", duplicate, err)
+	}
+
+	// 1つ目の削除では他の参照が残っているので本体を残し、削除したアップロードのタイトルだけを外す
+	if _, err := store.deleteUpload(ctx, "alice", storeKindScores, first.ID, "missing"); !errors.Is(err, errObjectNotFound) {
+		t.Fatalf("delete of unknown title: err = %v, want errObjectNotFound", err)
+	}
+	removed, err := store.deleteUpload(ctx, "alice", storeKindScores, first.ID, "first")
+	if err != nil || removed {
+		t.Fatalf("first delete: removed=%v err=%v", removed, err)
+	}
+	obj, data, err := store.get(ctx, "alice", storeKindScores, first.ID)
+	if err != nil || string(data) != string(pdf) {
+		t.Fatalf("get after first delete: %q, %v", data, err)
+	}
+	if !slices.Equal(obj.Titles, []string{"second"}) || obj.Title != "second" || obj.refs() != 1 {
+		t.Errorf("after first delete: title=%q titles=%q refs=%d, want second only", obj.Title, obj.Titles, obj.refs())
+	}
+
+	// 最後の参照を削除すると本体と索引も消える
+	removed, err = store.delete(ctx, "alice", storeKindScores, first.ID)
+	if err != nil || !removed {
+		t.Fatalf("last delete: removed=%v err=%v", removed, err)
+	}
+	if _, _, err := store.get(ctx, "alice", storeKindScores, first.ID); err != errObjectNotFound {
+		t.Errorf("get after last delete: err = %v, want errObjectNotFound", err)
+	}
+	if _, err := store.delete(ctx, "alice", storeKindScores, first.ID); err != errObjectNotFound {
+		t.Errorf("delete after last delete: err = %v, want errObjectNotFound", err)
+	}
+	blobs, err := store.storage.List(ctx, kindPrefix("alice", storeKindScores))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 0 {
+		t.Errorf("blobs left after last delete: %v", blobs)
+	}
+
+	// 削除後に同じ内容を保存すると新しいデータになる
+	third, duplicate, err := store.put(ctx, "alice", storeKindScores, "third", pdf)
+	if err != nil || duplicate || third.ID == first.ID {
+		t.Errorf("put after delete: id=%s duplicate=%v err=%v", third.ID, duplicate, err)
+	}
+	if _, data, err := store.get(ctx, "bob", storeKindScores, mustOnlyID(t, store, "bob")); err != nil || string(data) != string(pdf) {
+		t.Errorf("bob's copy: %q, %v", data, err)
+	}
+}
+
+func mustOnlyID(t *testing.T, store *scoreStore, tenant string) string {
+	t.Helper()
+	objects, err := store.list(context.Background(), tenant, storeKindScores)
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("list(%s) = %d objects, %v", tenant, len(objects), err)
+	}
+	return objects[0].ID
+}
+
+func TestStorePutOverQuotaDeletesData(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 10)
+
+	// 本体を書き込んだ後で容量を超えると分かった場合は、書き込んだ本体を消す
+	if _, _, err := store.put(ctx, "alice", storeKindScores, "big", []byte("0123456789abcdef")); !errors.Is(err, errQuotaExceeded) {
+		t.Fatalf("err = %v, want errQuotaExceeded", err)
+	}
+	blobs, err := store.storage.List(ctx, tenantPrefix("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 0 {
+		t.Errorf("blobs left after quota error: %v", blobs)
+	}
+}
+
+func TestStoreUsageIgnoresUnpublishedData(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 10)
+
+	// 書き込み中の本体は使用量に数えないので、自分自身の分を二重に数えない
+	obj, err := store.writeData(ctx, "alice", storeKindScores, "score", "", 8, bytes.NewReader([]byte("01234567")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := store.usage(ctx, "alice"); err != nil || used != 0 {
+		t.Errorf("usage before publish = %d, %v, want 0", used, err)
+	}
+	store.mu.Lock()
+	err = store.publish(ctx, "alice", storeKindScores, obj, "")
+	store.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := store.usage(ctx, "alice"); err != nil || used < 8 {
+		t.Errorf("usage after publish = %d, %v, want at least 8", used, err)
+	}
+}
+
+// blockingReader は最初のReadでreleaseがcloseされるまで待ちます
+type blockingReader struct {
+	started chan struct{}
+	release chan struct{}
+	r       io.Reader
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	if b.started != nil {
+		close(b.started)
+		b.started = nil
+		<-b.release
+	}
+	return b.r.Read(p)
+}
+
+func TestStorePutStreamWritesWithoutLock(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 0)
+	data := []byte("%PDF-1.7 slow upload")
+	body := &blockingReader{started: make(chan struct{}), release: make(chan struct{}), r: bytes.NewReader(data)}
+	started := body.started
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := store.putStream(ctx, "alice", storeKindScores, "slow", sha256Hex(data), int64(len(data)), body, "")
+		done <- err
+	}()
+	<-started
+
+	// 本体を書き込んでいる間も、他の保存は待たされない
+	if _, _, err := store.put(ctx, "bob", storeKindScores, "fast", []byte("%PDF-1.7 fast")); err != nil {
+		t.Fatal(err)
+	}
+	close(body.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreConcurrentPutsShareContent(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, 0)
+	pdf := []byte("%PDF-1.7 same content")
+
+	const n = 8
+	var wg sync.WaitGroup
+	var duplicates atomic.Int32
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, duplicate, err := store.put(ctx, "alice", storeKindScores, fmt.Sprintf("copy %d", i), pdf)
+			if err != nil {
+				t.Error(err)
+			}
+			if duplicate {
+				duplicates.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 同時に書き込んだ本体は1つだけ残り、他は参照として数える
+	if got := duplicates.Load(); got != n-1 {
+		t.Errorf("duplicates = %d, want %d", got, n-1)
+	}
+	obj, err := store.stat(ctx, "alice", storeKindScores, mustOnlyID(t, store, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.refs() != n || len(obj.Titles) != n {
+		t.Errorf("refs = %d, titles = %q, want %d", obj.refs(), obj.Titles, n)
+	}
+	blobs, err := store.storage.List(ctx, kindPrefix("alice", storeKindScores))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 本体・メタデータ・索引の3つ
+	if len(blobs) != 3 {
+		t.Errorf("blobs = %v, want data, metadata and index only", blobs)
+	}
+}
+
+// slowReadStorage は読み込んだ後に少し待ち、読んでから書くまでの間に他の処理が割り込みやすくします
+type slowReadStorage struct {
+	blobStorage
+}
+
+func (s slowReadStorage) GetVersion(ctx context.Context, key string) ([]byte, string, error) {
+	data, version, err := s.blobStorage.GetVersion(ctx, key)
+	time.Sleep(time.Millisecond)
+	return data, version, err
+}
+
+func TestStoreRefcountAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	// 保存先を共有し、muを共有しない2つのレプリカ
+	storage := slowReadStorage{newFSStorage(t.TempDir())}
+	replicas := []*scoreStore{newScoreStore(storage, 0), newScoreStore(storage, 0)}
+	pdf := []byte("%PDF-1.7 shared bucket")
+	if _, _, err := replicas[0].put(ctx, "alice", storeKindScores, "original", pdf); err != nil {
+		t.Fatal(err)
+	}
+
+	// 既にある内容を両方のレプリカから同時に保存しても、参照の数を取りこぼさない
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := replicas[i%2].put(ctx, "alice", storeKindScores, fmt.Sprintf("copy %d", i), pdf); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	id := mustOnlyID(t, replicas[0], "alice")
+	obj, err := replicas[0].stat(ctx, "alice", storeKindScores, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.refs() != n {
+		t.Fatalf("refs = %d after %d puts on two replicas, want %d", obj.refs(), n, n)
+	}
+
+	// 両方のレプリカから同時に削除しても、最後の1回で本体が消える
+	var removed atomic.Int32
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := replicas[i%2].delete(ctx, "alice", storeKindScores, id)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				removed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := removed.Load(); got != 1 {
+		t.Errorf("removed = %d, want exactly 1", got)
+	}
+	blobs, err := storage.List(ctx, kindPrefix("alice", storeKindScores))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 0 {
+		t.Errorf("blobs left after deleting every reference: %v", blobs)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	score "score-splitter/backend/gen/go"
+
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	// maxPDFPageSize はPDFのページの幅と高さの上限です（ポイント）
+	maxPDFPageSize = 14400
+	// minStripHeight はストリップの高さの下限です（ポイント）
+	minStripHeight = 36
+)
+
+// stripLayout はトリミング範囲を横一列に並べる出力の設定です
+type stripLayout struct {
+	enabled      bool
+	height       float64
+	maxPageWidth float64
+	gap          float64
+}
+
+// stripLayoutFromRequest はリクエストのストリップの設定を検証します。指定がなければ無効な設定を返します。
+func stripLayoutFromRequest(msg *score.TrimScoreRequest) (stripLayout, error) {
+	strip := msg.GetStrip()
+	if strip == nil {
+		return stripLayout{}, nil
+	}
+	if msg.GetOrientation() == "landscape" {
+		return stripLayout{}, errors.New("横一列の出力とlandscapeは同時に指定できません")
+	}
+	layout := stripLayout{
+		enabled:      true,
+		height:       strip.GetHeight(),
+		maxPageWidth: strip.GetMaxPageWidth(),
+		gap:          strip.GetGap(),
+	}
+	if layout.height != 0 && (layout.height < minStripHeight || layout.height > maxPDFPageSize) {
+		return stripLayout{}, fmt.Errorf("ストリップの高さ%vは%dから%dポイントの範囲で指定してください", layout.height, minStripHeight, maxPDFPageSize)
+	}
+	if layout.maxPageWidth == 0 {
+		layout.maxPageWidth = maxPDFPageSize
+	}
+	if layout.maxPageWidth < minSplitHeight || layout.maxPageWidth > maxPDFPageSize {
+		return stripLayout{}, fmt.Errorf("ページの幅の上限%vは%dから%dポイントの範囲で指定してください", layout.maxPageWidth, minSplitHeight, maxPDFPageSize)
+	}
+	if layout.gap < 0 || layout.gap > layout.maxPageWidth/2 {
+		return stripLayout{}, fmt.Errorf("範囲の間の余白%vが不正です", layout.gap)
+	}
+	return layout, nil
+}
+
+// arrange は切り出したページを同じ高さにそろえて左から順に並べ、横長のページに組み替えます。
+// 幅が maxPageWidth を超える場合は範囲の途中では区切らず、次のページに続けます。
+func (l stripLayout) arrange(segments [][]byte) ([][]byte, error) {
+	if !l.enabled || len(segments) == 0 {
+		return segments, nil
+	}
+
+	conf := model.NewDefaultConfiguration()
+	dims := make([]types.Dim, len(segments))
+	for i, data := range segments {
+		d, err := pdfapi.PageDims(bytes.NewReader(data), conf)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) != 1 || d[0].Height <= 0 {
+			return nil, fmt.Errorf("範囲%vのサイズを取得できません", i+1)
+		}
+		dims[i] = d[0]
+	}
+
+	height := l.height
+	if height == 0 {
+		for _, d := range dims {
+			height = math.Max(height, d.Height)
+		}
+		height = math.Min(height, maxPDFPageSize)
+	}
+
+	var pages [][]byte
+	var group [][]byte
+	width := 0.0
+	flush := func() error {
+		if len(group) == 0 {
+			return nil
+		}
+		page, err := composeSegments(group, l.rowLayout(height))
+		if err != nil {
+			return err
+		}
+		pages = append(pages, page)
+		group, width = nil, 0
+		return nil
+	}
+	for i, d := range dims {
+		w := d.Width * height / d.Height
+		if w > l.maxPageWidth {
+			return nil, fmt.Errorf("範囲%vを高さ%.0fにそろえると幅%.0fがページの上限%.0fを超えます", i+1, height, w, l.maxPageWidth)
+		}
+		if len(group) > 0 && width+l.gap+w > l.maxPageWidth {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		if len(group) > 0 {
+			width += l.gap
+		}
+		group = append(group, segments[i])
+		width += w
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// rowLayout は部品を高さheightにそろえて左から順に並べる配置を返します
+func (l stripLayout) rowLayout(height float64) segmentLayout {
+	return func(sizes []*types.Rectangle) (float64, float64, []placement) {
+		places := make([]placement, len(sizes))
+		x := 0.0
+		for i, size := range sizes {
+			if i > 0 {
+				x += l.gap
+			}
+			scale := height / size.Height()
+			places[i] = placement{x: x, y: 0, scale: scale}
+			x += size.Width() * scale
+		}
+		return x, height, places
+	}
+}
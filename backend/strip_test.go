@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestStripRowLayout(t *testing.T) {
+	layout := stripLayout{gap: 10}.rowLayout(100)
+	width, height, places := layout([]*types.Rectangle{
+		types.NewRectangle(0, 0, 200, 100),
+		types.NewRectangle(0, 0, 400, 200),
+		types.NewRectangle(0, 0, 30, 50),
+	})
+
+	// 高さを100にそろえ、余白を挟んで左から並べる
+	want := []placement{{x: 0, scale: 1}, {x: 210, scale: 0.5}, {x: 420, scale: 2}}
+	if width != 480 || height != 100 || !slices.Equal(places, want) {
+		t.Errorf("rowLayout = %v x %v %v, want 480 x 100 %v", width, height, places, want)
+	}
+}
+
+// pageSizes はPDFの各ページの幅と高さを返します
+func pageSizes(t *testing.T, pdf []byte) []types.Dim {
+	t.Helper()
+	dims, err := pdfapi.PageDims(bytes.NewReader(pdf), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dims
+}
+
+func TestStripArrange(t *testing.T) {
+	// 幅x高さの1ページのPDF
+	segment := func(width, height float64) []byte { return testPDFBytes(1, width, height) }
+	tests := []struct {
+		name     string
+		layout   stripLayout
+		segments [][]byte
+		want     []types.Dim
+		wantErr  bool
+	}{
+		{
+			// 高さ100にそろえると幅は200ずつになり、3つ目は上限の500を超えるので次のページに続ける
+			name:     "wraps at max page width",
+			layout:   stripLayout{enabled: true, height: 100, maxPageWidth: 500, gap: 10},
+			segments: [][]byte{segment(200, 100), segment(400, 200), segment(100, 50)},
+			want:     []types.Dim{{Width: 410, Height: 100}, {Width: 200, Height: 100}},
+		},
+		{
+			name:     "gap counts toward width",
+			layout:   stripLayout{enabled: true, height: 100, maxPageWidth: 400, gap: 1},
+			segments: [][]byte{segment(200, 100), segment(200, 100)},
+			want:     []types.Dim{{Width: 200, Height: 100}, {Width: 200, Height: 100}},
+		},
+		{
+			// 高さを指定しなければ最も高い範囲にそろえる
+			name:     "height from tallest segment",
+			layout:   stripLayout{enabled: true, maxPageWidth: maxPDFPageSize},
+			segments: [][]byte{segment(100, 50), segment(300, 150)},
+			want:     []types.Dim{{Width: 600, Height: 150}},
+		},
+		{
+			name:     "segment wider than page",
+			layout:   stripLayout{enabled: true, height: 100, maxPageWidth: 300},
+			segments: [][]byte{segment(100, 100), segment(800, 200)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := tt.layout.arrange(tt.segments)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("arrange succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []types.Dim
+			for _, page := range pages {
+				got = append(got, pageSizes(t, page)...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("page sizes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripArrangeDisabled(t *testing.T) {
+	segments := [][]byte{testPDFBytes(1, 100, 100)}
+	pages, err := stripLayout{}.arrange(segments)
+	if err != nil || len(pages) != 1 || !bytes.Equal(pages[0], segments[0]) {
+		t.Errorf("disabled arrange changed the segments: %d pages, %v", len(pages), err)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxTemplateNameLength はテンプレート名の最大文字数です
+const maxTemplateNameLength = 100
+
+// templateSettings は保存するトリミング設定を返します。PDFやパスワードなど項目ごとの値は保存しません。
+func templateSettings(settings *score.TrimScoreRequest) *score.TrimScoreRequest {
+	settings = proto.Clone(settings).(*score.TrimScoreRequest)
+	settings.Title = ""
+	settings.PdfFile = nil
+	settings.Password = ""
+	return settings
+}
+
+// SaveTemplate はトリミング設定を呼び出し元のテナントのテンプレートとして保存します
+func (s *scoreService) SaveTemplate(
+	ctx context.Context,
+	req *connect.Request[score.SaveTemplateRequest],
+) (*connect.Response[score.TemplateInfo], error) {
+	name := strings.TrimSpace(req.Msg.GetName())
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("テンプレート名を指定してください"))
+	}
+	if len([]rune(name)) > maxTemplateNameLength {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("テンプレート名は%d文字までです", maxTemplateNameLength))
+	}
+	if req.Msg.GetSettings() == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("トリミング設定がありません"))
+	}
+
+	settings := templateSettings(req.Msg.GetSettings())
+	// 使う時に失敗しないよう、保存する前に設定を確かめる。
+	// エリアは項目ごとに指定することもできるため、テンプレートに無くてもよい。
+	if len(settings.GetAreas()) > 0 || len(settings.GetPageSettings()) > 0 {
+		if _, _, err := trimAreasFromRequest(settings); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+	if _, err := segmentSplitFromRequest(settings.GetSplit()); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if _, err := stripLayoutFromRequest(settings); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	data, err := proto.Marshal(settings)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	obj, err := s.store.putNew(ctx, tenantFromContext(ctx), storeKindTemplates, name, data)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(templateInfoFromObject(obj)), nil
+}
+
+// ListTemplates は呼び出し元のテナントが保存したテンプレートの一覧を返します
+func (s *scoreService) ListTemplates(
+	ctx context.Context,
+	req *connect.Request[score.ListTemplatesRequest],
+) (*connect.Response[score.ListTemplatesResponse], error) {
+	objects, err := s.store.list(ctx, tenantFromContext(ctx), storeKindTemplates)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	templates := make([]*score.TemplateInfo, 0, len(objects))
+	for _, obj := range objects {
+		templates = append(templates, templateInfoFromObject(obj))
+	}
+	return connect.NewResponse(&score.ListTemplatesResponse{Templates: templates}), nil
+}
+
+// GetTemplate は呼び出し元のテナントが保存したテンプレートを返します
+func (s *scoreService) GetTemplate(
+	ctx context.Context,
+	req *connect.Request[score.GetTemplateRequest],
+) (*connect.Response[score.GetTemplateResponse], error) {
+	obj, settings, err := s.loadTemplate(ctx, tenantFromContext(ctx), req.Msg.GetTemplateId())
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&score.GetTemplateResponse{
+		Template: templateInfoFromObject(obj),
+		Settings: settings,
+	}), nil
+}
+
+// DeleteTemplate は呼び出し元のテナントが保存したテンプレートを削除します
+func (s *scoreService) DeleteTemplate(
+	ctx context.Context,
+	req *connect.Request[score.DeleteTemplateRequest],
+) (*connect.Response[score.DeleteTemplateResponse], error) {
+	if _, err := s.store.delete(ctx, tenantFromContext(ctx), storeKindTemplates, req.Msg.GetTemplateId()); err != nil {
+		return nil, storeError(err)
+	}
+	return connect.NewResponse(&score.DeleteTemplateResponse{
+		Message: "Template deleted",
+	}), nil
+}
+
+// loadTemplate はテナントのテンプレートを読み込みます
+func (s *scoreService) loadTemplate(ctx context.Context, tenant, id string) (*storedObject, *score.TrimScoreRequest, error) {
+	obj, data, err := s.store.get(ctx, tenant, storeKindTemplates, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	var settings score.TrimScoreRequest
+	if err := proto.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("テンプレート%sが壊れています: %w", id, err)
+	}
+	return obj, &settings, nil
+}
+
+func templateInfoFromObject(obj *storedObject) *score.TemplateInfo {
+	return &score.TemplateInfo{
+		TemplateId: obj.ID,
+		Name:       obj.Title,
+		CreatedAt:  obj.CreatedAt.Unix(),
+	}
+}
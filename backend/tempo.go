@@ -0,0 +1,355 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+)
+
+const (
+	// maxTempoChanges はテンポマップに指定できる変化の数の上限です
+	maxTempoChanges = 100
+	// maxHoldSeconds は1か所でスクロールを止められる秒数の上限です
+	maxHoldSeconds = 60
+	minVideoBPM    = 30
+	maxVideoBPM    = 240
+)
+
+// tempoChange は検証済みのテンポの変化です
+type tempoChange struct {
+	segment  int
+	position float64
+	bpm      int
+	hold     float64
+	ramp     bool
+}
+
+// tempoMapFromRequest はテンポマップを検証し、楽譜上の位置の順に並べて返します
+func tempoMapFromRequest(changes []*score.TempoChange) ([]tempoChange, error) {
+	if len(changes) > maxTempoChanges {
+		return nil, fmt.Errorf("テンポの変化は%d件までです（%d件）", maxTempoChanges, len(changes))
+	}
+	tempo := make([]tempoChange, 0, len(changes))
+	for i, c := range changes {
+		if c == nil {
+			continue
+		}
+		if c.GetSegmentIndex() < 0 {
+			return nil, fmt.Errorf("テンポの変化%dのセグメント番号%dが無効です", i+1, c.GetSegmentIndex())
+		}
+		if c.GetPosition() < 0 || c.GetPosition() > 1 {
+			return nil, fmt.Errorf("テンポの変化%dの位置%vは0から1の範囲で指定してください", i+1, c.GetPosition())
+		}
+		if bpm := c.GetBpm(); bpm != 0 && (bpm < minVideoBPM || bpm > maxVideoBPM) {
+			return nil, fmt.Errorf("テンポの変化%dのBPM%dは%dから%dの範囲で指定してください", i+1, bpm, minVideoBPM, maxVideoBPM)
+		}
+		if c.GetHoldSeconds() < 0 || c.GetHoldSeconds() > maxHoldSeconds {
+			return nil, fmt.Errorf("テンポの変化%dの停止時間%vは0から%d秒の範囲で指定してください", i+1, c.GetHoldSeconds(), maxHoldSeconds)
+		}
+		tempo = append(tempo, tempoChange{
+			segment:  int(c.GetSegmentIndex()),
+			position: c.GetPosition(),
+			bpm:      int(c.GetBpm()),
+			hold:     c.GetHoldSeconds(),
+			ramp:     c.GetRamp(),
+		})
+	}
+	sort.SliceStable(tempo, func(i, j int) bool {
+		if tempo[i].segment == tempo[j].segment {
+			return tempo[i].position < tempo[j].position
+		}
+		return tempo[i].segment < tempo[j].segment
+	})
+	return tempo, nil
+}
+
+const (
+	videoTimingPixels = "pixels"
+	videoTimingBeats  = "beats"
+)
+
+const (
+	defaultTimeSignature = "4/4"
+	// maxSegmentBeats は1つのセグメントに指定できる拍数の上限です
+	maxSegmentBeats = 10000
+)
+
+// segmentBeatsFromRequest は各セグメントの拍数と1小節の拍数を計算します。小節数で指定された場合は拍子の分子を掛けます。
+func segmentBeatsFromRequest(segments []*score.SegmentBeats, defaultSignature string) ([]float64, []int, error) {
+	if defaultSignature == "" {
+		defaultSignature = defaultTimeSignature
+	}
+	if _, err := parseTimeSignature(defaultSignature); err != nil {
+		return nil, nil, err
+	}
+	beats := make([]float64, len(segments))
+	meters := make([]int, len(segments))
+	for i, seg := range segments {
+		signature := seg.GetTimeSignature()
+		if signature == "" {
+			signature = defaultSignature
+		}
+		perMeasure, err := parseTimeSignature(signature)
+		if err != nil {
+			return nil, nil, fmt.Errorf("セグメント%d: %w", i, err)
+		}
+		b := seg.GetBeats()
+		if b == 0 && seg.GetMeasures() > 0 {
+			b = float64(seg.GetMeasures() * perMeasure)
+		}
+		if b <= 0 || b > maxSegmentBeats {
+			return nil, nil, fmt.Errorf("セグメント%dの拍数%vが無効です（0より大きく%d以下）", i, b, maxSegmentBeats)
+		}
+		beats[i] = b
+		meters[i] = int(perMeasure)
+	}
+	return beats, meters, nil
+}
+
+// parseTimeSignature は "3/4" のような拍子を読み取り、1小節の拍数を返します
+func parseTimeSignature(signature string) (int32, error) {
+	var num, den int32
+	if _, err := fmt.Sscanf(signature, "%d/%d", &num, &den); err != nil || fmt.Sprintf("%d/%d", num, den) != signature {
+		return 0, fmt.Errorf("拍子%qが不正です（例: 4/4, 6/8）", signature)
+	}
+	if num < 1 || num > 32 {
+		return 0, fmt.Errorf("拍子%qの分子は1から32の範囲で指定してください", signature)
+	}
+	switch den {
+	case 1, 2, 4, 8, 16, 32:
+	default:
+		return 0, fmt.Errorf("拍子%qの分母は2の累乗（1から32）で指定してください", signature)
+	}
+	return num, nil
+}
+
+// beatKnot は結合画像上の位置xが拍の位置beatに当たり、そこから1拍ごとにpxPerBeatピクセル進むことを表します
+type beatKnot struct {
+	x         float64
+	beat      float64
+	pxPerBeat float64
+}
+
+// beatMap は結合画像上の位置と拍の位置の対応です。knotの間は線形に対応します。
+type beatMap []beatKnot
+
+// pixelBeatMap は全体を一定の速さ（1拍 scrollPixelsPerBeat ピクセル）で進む対応を返します
+func pixelBeatMap() beatMap {
+	return beatMap{{x: 0, beat: 0, pxPerBeat: scrollPixelsPerBeat}}
+}
+
+// segmentBeatMap は各セグメントがそれぞれ指定された拍数で進む対応を返します
+func segmentBeatMap(segments []stripSegment, beats []float64) beatMap {
+	m := make(beatMap, len(segments))
+	total := 0.0
+	for i, seg := range segments {
+		m[i] = beatKnot{x: float64(seg.x), beat: total, pxPerBeat: float64(seg.width) / beats[i]}
+		total += beats[i]
+	}
+	return m
+}
+
+// knotIndex はbeatを含む区間のknotの番号を返します。境目の計算誤差を吸収するため少しだけ先の拍で探します。
+func (m beatMap) knotIndex(beat float64) int {
+	i := 0
+	for i+1 < len(m) && m[i+1].beat <= beat+1e-9 {
+		i++
+	}
+	return i
+}
+
+func (m beatMap) beatAt(x float64) float64 {
+	k := m[0]
+	for _, next := range m[1:] {
+		if next.x > x {
+			break
+		}
+		k = next
+	}
+	return k.beat + (x-k.x)/k.pxPerBeat
+}
+
+// scrollPhase はスクロールの1区間です。開始時刻 start からの経過時間 dt での位置は
+// x + speed*dt + accel/2*dt² になります。
+type scrollPhase struct {
+	start float64
+	x     float64
+	speed float64
+	accel float64
+}
+
+// at は区間の開始からdt秒後の位置を返します
+func (p scrollPhase) at(dt float64) float64 {
+	return p.x + p.speed*dt + p.accel/2*dt*dt
+}
+
+// reach は区間の開始から位置targetに着くまでの秒数を返します
+func (p scrollPhase) reach(target float64) float64 {
+	d := target - p.x
+	if p.accel == 0 {
+		return d / p.speed
+	}
+	return (math.Sqrt(p.speed*p.speed+2*p.accel*d) - p.speed) / p.accel
+}
+
+// scrollTimeline は時刻ごとのスクロール位置（画面の左端の結合画像上の位置）です
+type scrollTimeline struct {
+	phases []scrollPhase
+	end    float64 // 最後の位置に着く時刻
+	last   float64 // 最後の位置
+	beats  beatMap // タイムラインを作ったときの位置と拍の対応
+}
+
+// newScrollTimeline は基準のBPMとテンポマップから、結合画像を0からendまでスクロールするタイムラインを作ります。
+// segments は各セグメントの結合画像上の開始位置と幅、beats は結合画像上の位置と拍の対応です。
+// テンポは拍の単位で計算し、最後にピクセルの単位に直します。
+func newScrollTimeline(bpm int, tempo []tempoChange, segments []stripSegment, beats beatMap, end float64) (*scrollTimeline, error) {
+	var phases []scrollPhase // 拍の単位の区間
+	t, b := 0.0, 0.0
+	speed := float64(bpm) / 60
+	ramp := false
+
+	// moveTo は現在の拍からtargetまで進む区間を追加します。
+	// ramp中は次の速度nextSpeedまで時間に対して一定の割合で速度を変えます。
+	moveTo := func(target, nextSpeed float64) {
+		distance := target - b
+		if distance <= 0 {
+			return
+		}
+		if ramp && nextSpeed != speed {
+			duration := 2 * distance / (speed + nextSpeed)
+			phases = append(phases, scrollPhase{start: t, x: b, speed: speed, accel: (nextSpeed - speed) / duration})
+			t += duration
+		} else {
+			phases = append(phases, scrollPhase{start: t, x: b, speed: speed})
+			t += distance / speed
+		}
+		b = target
+	}
+
+	endBeat := beats.beatAt(end)
+	for i, c := range tempo {
+		if c.segment >= len(segments) {
+			return nil, fmt.Errorf("テンポの変化のセグメント番号%dがページ数%dを超えています", c.segment, len(segments))
+		}
+		seg := segments[c.segment]
+		target := math.Min(beats.beatAt(float64(seg.x)+c.position*float64(seg.width)), endBeat)
+
+		nextSpeed := speed
+		if c.bpm > 0 {
+			nextSpeed = float64(c.bpm) / 60
+		}
+		moveTo(target, nextSpeed)
+		speed = nextSpeed
+		if c.hold > 0 {
+			phases = append(phases, scrollPhase{start: t, x: b})
+			t += c.hold
+		}
+		// 最後の変化のrampは向かう先のBPMがないので無視します
+		ramp = c.ramp && i < len(tempo)-1
+	}
+	ramp = false
+	moveTo(endBeat, speed)
+
+	return &scrollTimeline{phases: beats.toPixels(phases, t), end: t, last: end, beats: beats}, nil
+}
+
+// toPixels は拍の単位の区間を、knotの境目で分けてピクセルの単位の区間に直します。endは最後の区間が終わる時刻です。
+func (m beatMap) toPixels(phases []scrollPhase, end float64) []scrollPhase {
+	var out []scrollPhase
+	for i, p := range phases {
+		phaseEnd := end
+		if i+1 < len(phases) {
+			phaseEnd = phases[i+1].start
+		}
+		ki := m.knotIndex(p.x)
+		t := p.start
+		for {
+			k := m[ki]
+			dt := t - p.start
+			out = append(out, scrollPhase{
+				start: t,
+				x:     k.x + (p.at(dt)-k.beat)*k.pxPerBeat,
+				speed: (p.speed + p.accel*dt) * k.pxPerBeat,
+				accel: p.accel * k.pxPerBeat,
+			})
+			if p.speed == 0 && p.accel == 0 || ki+1 >= len(m) {
+				break
+			}
+			// 次のknotに着く時刻から次の区間にします
+			next := p.start + p.reach(m[ki+1].beat)
+			if math.IsNaN(next) || next >= phaseEnd {
+				break
+			}
+			t = next
+			ki++
+		}
+	}
+	return out
+}
+
+// timeAt は画面の左端が位置xに着く時刻を返します。途中で止まる区間がある場合は着いた時点の時刻です。
+func (tl *scrollTimeline) timeAt(x float64) float64 {
+	for i, p := range tl.phases {
+		end := tl.end
+		if i+1 < len(tl.phases) {
+			end = tl.phases[i+1].start
+		}
+		if x <= p.x {
+			return p.start
+		}
+		if p.at(end-p.start) >= x {
+			return p.start + p.reach(x)
+		}
+	}
+	return tl.end
+}
+
+// position は時刻tでの画面の左端の位置を返します
+func (tl *scrollTimeline) position(t float64) float64 {
+	if len(tl.phases) == 0 {
+		return 0
+	}
+	p := tl.phases[0]
+	for _, next := range tl.phases[1:] {
+		if next.start > t {
+			break
+		}
+		p = next
+	}
+	return p.at(t - p.start)
+}
+
+// delay は先頭で seconds 秒止まってからスクロールを始めるようにタイムラインをずらします
+func (tl *scrollTimeline) delay(seconds float64) {
+	if seconds <= 0 {
+		return
+	}
+	for i := range tl.phases {
+		tl.phases[i].start += seconds
+	}
+	tl.phases = append([]scrollPhase{{start: 0, x: 0}}, tl.phases...)
+	tl.end += seconds
+}
+
+// expr はffmpegの式でスクロール位置を返します。
+// ifを入れ子にすると式の深さの上限に達するため、区間ごとの項を足し合わせます。
+func (tl *scrollTimeline) expr() string {
+	var b strings.Builder
+	for i, p := range tl.phases {
+		end := tl.end
+		if i+1 < len(tl.phases) {
+			end = tl.phases[i+1].start
+		}
+		if end <= p.start {
+			continue
+		}
+		fmt.Fprintf(&b, "gte(t,%.6f)*lt(t,%.6f)*(%.4f+%.4f*(t-%.6f)+%.6f*(t-%.6f)*(t-%.6f))+",
+			p.start, end, p.x, p.speed, p.start, p.accel/2, p.start, p.start)
+	}
+	fmt.Fprintf(&b, "gte(t,%.6f)*%.4f", tl.end, tl.last)
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	score "score-splitter/backend/gen/go"
+)
+
+// 幅100と400の2つのセグメントを並べた結合画像
+var testStripSegments = []stripSegment{{x: 0, width: 100}, {x: 100, width: 400}}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestScrollTimeline(t *testing.T) {
+	tests := []struct {
+		name  string
+		bpm   int
+		tempo []tempoChange
+		beats beatMap
+		// 結合画像上の位置と、画面の左端がそこに着く時刻
+		times   map[float64]float64
+		wantEnd float64
+	}{
+		{
+			// 120BPMで1拍120ピクセルなので毎秒240ピクセル
+			name:    "ピクセル",
+			bpm:     120,
+			beats:   pixelBeatMap(),
+			times:   map[float64]float64{0: 0, 240: 1, 480: 2},
+			wantEnd: 500.0 / 240,
+		},
+		{
+			// セグメントの幅に関係なく、4拍ずつを120BPMの2秒で進む
+			name:    "拍",
+			bpm:     120,
+			beats:   segmentBeatMap(testStripSegments, []float64{4, 4}),
+			times:   map[float64]float64{50: 1, 100: 2, 300: 3, 500: 4},
+			wantEnd: 4,
+		},
+		{
+			name:    "テンポの変化",
+			bpm:     120,
+			tempo:   []tempoChange{{segment: 1, bpm: 60}},
+			beats:   segmentBeatMap(testStripSegments, []float64{4, 4}),
+			times:   map[float64]float64{100: 2, 300: 4, 500: 6},
+			wantEnd: 6,
+		},
+		{
+			// 止まっている間の位置は止まり始めた時刻に着いたものとする
+			name:    "停止",
+			bpm:     120,
+			tempo:   []tempoChange{{segment: 1, hold: 1.5}},
+			beats:   segmentBeatMap(testStripSegments, []float64{4, 4}),
+			times:   map[float64]float64{100: 2, 300: 4.5, 500: 5.5},
+			wantEnd: 5.5,
+		},
+		{
+			// 60BPMから120BPMへ一定の割合で速くなるので、4拍の平均は1.5拍/秒
+			name: "ランプ",
+			bpm:  60,
+			tempo: []tempoChange{
+				{segment: 0, ramp: true},
+				{segment: 1, bpm: 120},
+			},
+			beats:   segmentBeatMap(testStripSegments, []float64{4, 4}),
+			times:   map[float64]float64{100: 8.0 / 3, 500: 8.0/3 + 2},
+			wantEnd: 8.0/3 + 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl, err := newScrollTimeline(tt.bpm, tt.tempo, testStripSegments, tt.beats, 500)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !approxEqual(tl.end, tt.wantEnd) {
+				t.Errorf("end = %v, want %v", tl.end, tt.wantEnd)
+			}
+			for x, want := range tt.times {
+				if got := tl.timeAt(x); !approxEqual(got, want) {
+					t.Errorf("timeAt(%v) = %v, want %v", x, got, want)
+				}
+				// 止まっている区間を除けば、その時刻の位置はxになる
+				if got := tl.position(want); !approxEqual(got, x) {
+					t.Errorf("position(%v) = %v, want %v", want, got, x)
+				}
+			}
+			if got := tl.position(tl.end); !approxEqual(got, 500) {
+				t.Errorf("position at end = %v, want 500", got)
+			}
+		})
+	}
+}
+
+func TestScrollTimelineDelay(t *testing.T) {
+	tl, err := newScrollTimeline(120, nil, testStripSegments, segmentBeatMap(testStripSegments, []float64{4, 4}), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.delay(1)
+	if got := tl.position(0.5); got != 0 {
+		t.Errorf("position during delay = %v, want 0", got)
+	}
+	if got := tl.timeAt(100); !approxEqual(got, 3) {
+		t.Errorf("timeAt(100) = %v, want 3", got)
+	}
+	if !approxEqual(tl.end, 5) {
+		t.Errorf("end = %v, want 5", tl.end)
+	}
+}
+
+func TestScrollTimelineRejectsUnknownSegment(t *testing.T) {
+	tempo := []tempoChange{{segment: 2, bpm: 60}}
+	if _, err := newScrollTimeline(120, tempo, testStripSegments, pixelBeatMap(), 500); err == nil {
+		t.Error("tempo change for a missing segment was accepted")
+	}
+}
+
+func TestSegmentBeatsFromRequest(t *testing.T) {
+	beats, meters, err := segmentBeatsFromRequest(nil, "")
+	if err != nil || len(beats) != 0 || len(meters) != 0 {
+		t.Fatalf("empty request: %v, %v, %v", beats, meters, err)
+	}
+	for _, signature := range []string{"4/3", "0/4", "3/4 ", "x"} {
+		if _, err := parseTimeSignature(signature); err == nil {
+			t.Errorf("parseTimeSignature(%q) succeeded", signature)
+		}
+	}
+	if n, err := parseTimeSignature("6/8"); err != nil || n != 6 {
+		t.Errorf("parseTimeSignature(6/8) = %d, %v", n, err)
+	}
+}
+
+func TestTempoMapFromRequest(t *testing.T) {
+	tempo, err := tempoMapFromRequest([]*score.TempoChange{
+		{SegmentIndex: 2, Bpm: 90},
+		{SegmentIndex: 1, Position: 0.5, HoldSeconds: 2},
+		nil,
+		{SegmentIndex: 1, Position: 0.25, Ramp: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 楽譜上の位置の順に並べる
+	want := []tempoChange{
+		{segment: 1, position: 0.25, ramp: true},
+		{segment: 1, position: 0.5, hold: 2},
+		{segment: 2, bpm: 90},
+	}
+	if len(tempo) != len(want) {
+		t.Fatalf("tempo = %v, want %v", tempo, want)
+	}
+	for i := range want {
+		if tempo[i] != want[i] {
+			t.Errorf("tempo[%d] = %v, want %v", i, tempo[i], want[i])
+		}
+	}
+
+	for _, c := range []*score.TempoChange{
+		{SegmentIndex: -1},
+		{Position: 1.5},
+		{Bpm: 29},
+		{Bpm: 241},
+		{HoldSeconds: -1},
+		{HoldSeconds: maxHoldSeconds + 1},
+	} {
+		if _, err := tempoMapFromRequest([]*score.TempoChange{c}); err == nil {
+			t.Errorf("tempoMapFromRequest(%v) succeeded", c)
+		}
+	}
+}
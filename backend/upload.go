@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	score "score-splitter/backend/gen/go"
+
+	"connectrpc.com/connect"
+)
+
+// 分割アップロードの途中のデータは <テナント>/uploads/<アップロードID>/ に保存します。
+// 状態は全て保存先に置くため、複数のレプリカのどれに続きのチャンクが届いても再開できます。
+const storeKindUploads = "uploads"
+
+const (
+	uploadSessionFile = "session.json"
+	uploadPartExt     = ".part"
+)
+
+var (
+	errUploadOffsetMismatch = errors.New("チャンクの開始位置が受信済みのバイト数と一致しません")
+	errUploadExpired        = errors.New("アップロードの有効期限が切れています")
+	errChecksumMismatch     = errors.New("SHA-256が一致しません")
+	errUploadIncomplete     = errors.New("アップロードが完了していません")
+	errTooManyUploads       = errors.New("同時に進められる分割アップロードの数の上限に達しています")
+
+	// 以下はリクエストの内容が正しくない場合のエラーです
+	errUploadSizeInvalid   = errors.New("ファイルサイズが無効です")
+	errUploadSHA256Format  = errors.New("SHA-256の形式が正しくありません")
+	errUploadChunkEmpty    = errors.New("チャンクが空です")
+	errUploadChunkTooLarge = errors.New("チャンクがファイルサイズを超えています")
+)
+
+// uploadSession は分割アップロードの情報です
+type uploadSession struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	TotalSize int64     `json:"total_size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// uploadPart は保存済みのチャンクです
+type uploadPart struct {
+	key    string
+	offset int64
+	size   int64
+}
+
+// uploadManager は分割アップロードを管理します
+type uploadManager struct {
+	store     *scoreStore
+	chunkSize int64
+	maxBytes  int64
+	ttl       time.Duration
+	// maxSessions はテナントごとに同時に開いておける分割アップロードの数です
+	maxSessions int
+	now         func() time.Time
+}
+
+func newUploadManager(store *scoreStore, cfg *serverConfig) *uploadManager {
+	return &uploadManager{
+		store:       store,
+		chunkSize:   cfg.UploadChunkBytes,
+		maxBytes:    cfg.MaxUploadBytes,
+		ttl:         cfg.UploadSessionTTL,
+		maxSessions: cfg.MaxUploadSessions,
+		now:         time.Now,
+	}
+}
+
+func uploadPrefix(tenant, id string) string {
+	return kindPrefix(tenant, storeKindUploads) + id + "/"
+}
+
+func partKey(tenant, id string, offset int64) string {
+	// キーの辞書順がオフセット順になるよう桁を揃える
+	return fmt.Sprintf("%s%020d%s", uploadPrefix(tenant, id), offset, uploadPartExt)
+}
+
+// begin は分割アップロードを開始します
+func (m *uploadManager) begin(ctx context.Context, tenant, title string, totalSize int64, sum string) (*uploadSession, error) {
+	if totalSize <= 0 {
+		return nil, errUploadSizeInvalid
+	}
+	if totalSize > m.maxBytes {
+		return nil, fmt.Errorf("%w: ファイルサイズ%dバイトが上限%dバイトを超えています", errQuotaExceeded, totalSize, m.maxBytes)
+	}
+	sum = strings.ToLower(sum)
+	if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+		return nil, errUploadSHA256Format
+	}
+
+	m.sweepExpired(ctx, tenant)
+
+	// 開いているアップロードの数と予約した容量を確認してから予約するまでを直列化する
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	open, err := m.openSessions(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if open >= m.maxSessions {
+		return nil, fmt.Errorf("%w（%d件）。完了していないアップロードを完了するか、期限切れを待ってください", errTooManyUploads, m.maxSessions)
+	}
+	// 既に同じ内容が保存されている場合は容量を増やさないので上限を確認しない
+	existing, err := m.store.findByContent(ctx, tenant, storeKindScores, sum)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// 開いている他のアップロードが予約した容量も使用量に含めて確認する
+		if err := m.store.checkQuota(ctx, tenant, totalSize, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := newObjectID()
+	if err != nil {
+		return nil, err
+	}
+	now := m.now().UTC()
+	session := &uploadSession{
+		ID:        id,
+		Title:     title,
+		TotalSize: totalSize,
+		SHA256:    sum,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.storage.Put(ctx, uploadPrefix(tenant, id)+uploadSessionFile, data); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// uploadSession は分割アップロードの情報を読み込みます。期限切れかどうかは確認しません。
+func (s *scoreStore) uploadSession(ctx context.Context, tenant, id string) (*uploadSession, error) {
+	if !objectIDPattern.MatchString(id) {
+		return nil, errObjectNotFound
+	}
+	data, err := s.storage.Get(ctx, uploadPrefix(tenant, id)+uploadSessionFile)
+	if err != nil {
+		return nil, err
+	}
+	var session uploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("アップロード%sの情報が壊れています: %w", id, err)
+	}
+	return &session, nil
+}
+
+// openSessions はテナントの期限切れでない分割アップロードの数を返します
+func (m *uploadManager) openSessions(ctx context.Context, tenant string) (int, error) {
+	ids, err := m.sessionIDs(ctx, tenant)
+	if err != nil {
+		return 0, err
+	}
+	open := 0
+	for _, id := range ids {
+		session, err := m.store.uploadSession(ctx, tenant, id)
+		if errors.Is(err, errObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !m.now().After(session.ExpiresAt) {
+			open++
+		}
+	}
+	return open, nil
+}
+
+// sessionIDs はテナントの分割アップロードのIDを返します
+func (m *uploadManager) sessionIDs(ctx context.Context, tenant string) ([]string, error) {
+	prefix := kindPrefix(tenant, storeKindUploads)
+	blobs, err := m.store.storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, blob := range blobs {
+		if id, ok := strings.CutSuffix(strings.TrimPrefix(blob.Key, prefix), "/"+uploadSessionFile); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// session はアップロードの情報と保存済みのチャンクを返します
+func (m *uploadManager) session(ctx context.Context, tenant, id string) (*uploadSession, []uploadPart, error) {
+	session, err := m.store.uploadSession(ctx, tenant, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.now().After(session.ExpiresAt) {
+		return nil, nil, errUploadExpired
+	}
+
+	blobs, err := m.store.storage.List(ctx, uploadPrefix(tenant, id))
+	if err != nil {
+		return nil, nil, err
+	}
+	var parts []uploadPart
+	for _, blob := range blobs {
+		name := strings.TrimPrefix(blob.Key, uploadPrefix(tenant, id))
+		offsetStr, ok := strings.CutSuffix(name, uploadPartExt)
+		if !ok {
+			continue
+		}
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, uploadPart{key: blob.Key, offset: offset, size: blob.Size})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].offset < parts[j].offset })
+	return session, parts, nil
+}
+
+// receivedBytes は先頭から途切れずに受信済みのバイト数を返します
+func receivedBytes(parts []uploadPart) int64 {
+	var received int64
+	for _, part := range parts {
+		if part.offset != received {
+			break
+		}
+		received += part.size
+	}
+	return received
+}
+
+// appendChunk はチャンクを保存し、受信済みのバイト数を返します
+func (m *uploadManager) appendChunk(ctx context.Context, tenant, id string, offset int64, data []byte, chunkSum string) (int64, error) {
+	session, parts, err := m.session(ctx, tenant, id)
+	if err != nil {
+		return 0, err
+	}
+	received := receivedBytes(parts)
+	if offset != received {
+		return received, fmt.Errorf("%w: offset=%d 受信済み=%d", errUploadOffsetMismatch, offset, received)
+	}
+	if len(data) == 0 {
+		return received, errUploadChunkEmpty
+	}
+	if offset+int64(len(data)) > session.TotalSize {
+		return received, fmt.Errorf("%w: ファイルサイズ%dバイト", errUploadChunkTooLarge, session.TotalSize)
+	}
+	if chunkSum != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), chunkSum) {
+			return received, fmt.Errorf("%w: offset=%dのチャンク", errChecksumMismatch, offset)
+		}
+	}
+
+	if err := m.store.storage.Put(ctx, partKey(tenant, id, offset), data); err != nil {
+		return received, err
+	}
+	return received + int64(len(data)), nil
+}
+
+// commit はチャンクを結合してSHA-256を確認し、スコアとして保存します
+func (m *uploadManager) commit(ctx context.Context, tenant, id string) (obj *storedObject, duplicate bool, err error) {
+	session, parts, err := m.session(ctx, tenant, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if received := receivedBytes(parts); received != session.TotalSize {
+		return nil, false, fmt.Errorf("%w: 受信済み%dバイト / %dバイト", errUploadIncomplete, received, session.TotalSize)
+	}
+
+	// 結合したデータをメモリに載せないよう、チャンクを1つずつ読んでSHA-256を確かめてから保存先に流し込む
+	hash := sha256.New()
+	if _, err := io.Copy(hash, m.partsReader(ctx, parts)); err != nil {
+		return nil, false, err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != session.SHA256 {
+		m.abort(ctx, tenant, id)
+		return nil, false, fmt.Errorf("%w: アップロード%sを破棄しました。最初からやり直してください", errChecksumMismatch, id)
+	}
+
+	obj, duplicate, err = m.store.putStream(ctx, tenant, storeKindScores, session.Title, session.SHA256, session.TotalSize, m.partsReader(ctx, parts), id)
+	if err != nil {
+		// 保存に失敗した場合はチャンクを残し、CommitUploadを再試行できるようにする
+		return nil, false, err
+	}
+	m.abort(ctx, tenant, id)
+	return obj, duplicate, nil
+}
+
+// partsReader は受信済みのチャンクを先頭から順に1つずつ読み込むReaderを返します
+func (m *uploadManager) partsReader(ctx context.Context, parts []uploadPart) io.Reader {
+	return &uploadPartsReader{ctx: ctx, storage: m.store.storage, parts: parts}
+}
+
+type uploadPartsReader struct {
+	ctx     context.Context
+	storage blobStorage
+	parts   []uploadPart
+	current *bytes.Reader
+}
+
+func (r *uploadPartsReader) Read(p []byte) (int, error) {
+	for r.current == nil || r.current.Len() == 0 {
+		if len(r.parts) == 0 {
+			return 0, io.EOF
+		}
+		data, err := r.storage.Get(r.ctx, r.parts[0].key)
+		if err != nil {
+			return 0, err
+		}
+		r.current = bytes.NewReader(data)
+		r.parts = r.parts[1:]
+	}
+	return r.current.Read(p)
+}
+
+// abort はアップロードの途中のデータを削除します
+func (m *uploadManager) abort(ctx context.Context, tenant, id string) {
+	m.deletePrefix(ctx, uploadPrefix(tenant, id))
+}
+
+// deletePrefix はprefixで始まるキーを全て削除します
+func (m *uploadManager) deletePrefix(ctx context.Context, prefix string) {
+	blobs, err := m.store.storage.List(ctx, prefix)
+	if err != nil {
+		return
+	}
+	for _, blob := range blobs {
+		m.store.storage.Delete(ctx, blob.Key)
+	}
+}
+
+// sweepExpired はテナントの期限切れのアップロードを削除します
+func (m *uploadManager) sweepExpired(ctx context.Context, tenant string) {
+	ids, err := m.sessionIDs(ctx, tenant)
+	if err != nil {
+		return
+	}
+	for _, id := range ids {
+		if _, _, err := m.session(ctx, tenant, id); errors.Is(err, errUploadExpired) {
+			m.abort(ctx, tenant, id)
+		}
+	}
+}
+
+// sweepAllExpired は全テナントの期限切れのアップロードを削除します。
+// キーからテナント名を復元できない（ハッシュにしたテナント名がある）ので、セッションのキーから直接削除します。
+func (m *uploadManager) sweepAllExpired(ctx context.Context) {
+	blobs, err := m.store.storage.List(ctx, "")
+	if err != nil {
+		log.Printf("uploads: failed to list sessions: %v", err)
+		return
+	}
+	for _, blob := range blobs {
+		// <テナント>/uploads/<アップロードID>/session.json だけを対象にする
+		dir, ok := strings.CutSuffix(blob.Key, "/"+uploadSessionFile)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(dir, "/")
+		if len(parts) != 3 || parts[1] != storeKindUploads || !objectIDPattern.MatchString(parts[2]) {
+			continue
+		}
+		data, err := m.store.storage.Get(ctx, blob.Key)
+		if err != nil {
+			continue
+		}
+		var session uploadSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+		if m.now().After(session.ExpiresAt) {
+			m.deletePrefix(ctx, dir+"/")
+		}
+	}
+}
+
+// sweepLoop はctxが終わるまで、期限切れのアップロードを定期的に削除します。
+// 開始時にもsweepExpiredでそのテナントの分を削除しますが、アップロードを再開しないテナントの分はここで削除します。
+func (m *uploadManager) sweepLoop(ctx context.Context) {
+	interval := min(max(m.ttl/2, time.Minute), time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.sweepAllExpired(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// uploadError は分割アップロードのエラーをconnectのエラーに変換します。
+// 保存先の読み書きの失敗などリクエストに原因のないエラーは、InvalidArgumentにせずstoreErrorで変換します。
+func uploadError(err error) error {
+	switch {
+	case errors.Is(err, errUploadSizeInvalid), errors.Is(err, errUploadSHA256Format),
+		errors.Is(err, errUploadChunkEmpty), errors.Is(err, errUploadChunkTooLarge):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.Is(err, errUploadOffsetMismatch), errors.Is(err, errUploadIncomplete):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, errChecksumMismatch):
+		return connect.NewError(connect.CodeDataLoss, err)
+	case errors.Is(err, errUploadExpired):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, errTooManyUploads):
+		return connect.NewError(connect.CodeResourceExhausted, err)
+	default:
+		return storeError(err)
+	}
+}
+
+// BeginUpload は分割アップロードを開始します
+func (s *scoreService) BeginUpload(
+	ctx context.Context,
+	req *connect.Request[score.BeginUploadRequest],
+) (*connect.Response[score.BeginUploadResponse], error) {
+	session, err := s.uploads.begin(ctx, tenantFromContext(ctx), req.Msg.GetTitle(), req.Msg.GetTotalSize(), req.Msg.GetSha256())
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return connect.NewResponse(&score.BeginUploadResponse{
+		UploadId:  session.ID,
+		ChunkSize: s.uploads.chunkSize,
+		ExpiresAt: session.ExpiresAt.Unix(),
+	}), nil
+}
+
+// AppendUploadChunk は分割アップロードのチャンクを受け取ります
+func (s *scoreService) AppendUploadChunk(
+	ctx context.Context,
+	req *connect.Request[score.AppendUploadChunkRequest],
+) (*connect.Response[score.AppendUploadChunkResponse], error) {
+	received, err := s.uploads.appendChunk(
+		ctx,
+		tenantFromContext(ctx),
+		req.Msg.GetUploadId(),
+		req.Msg.GetOffset(),
+		req.Msg.GetData(),
+		req.Msg.GetChunkSha256(),
+	)
+	if err != nil {
+		connectErr := uploadError(err)
+		if ce := new(connect.Error); errors.As(connectErr, &ce) {
+			ce.Meta().Set("Upload-Offset", strconv.FormatInt(received, 10))
+		}
+		return nil, connectErr
+	}
+	return connect.NewResponse(&score.AppendUploadChunkResponse{
+		ReceivedBytes: received,
+	}), nil
+}
+
+// GetUploadStatus は分割アップロードの受信済みのバイト数を返します
+func (s *scoreService) GetUploadStatus(
+	ctx context.Context,
+	req *connect.Request[score.GetUploadStatusRequest],
+) (*connect.Response[score.GetUploadStatusResponse], error) {
+	session, parts, err := s.uploads.session(ctx, tenantFromContext(ctx), req.Msg.GetUploadId())
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return connect.NewResponse(&score.GetUploadStatusResponse{
+		UploadId:      session.ID,
+		Title:         session.Title,
+		TotalSize:     session.TotalSize,
+		ReceivedBytes: receivedBytes(parts),
+		ExpiresAt:     session.ExpiresAt.Unix(),
+	}), nil
+}
+
+// CommitUpload は分割アップロードを完了し、スコアとして保存します
+func (s *scoreService) CommitUpload(
+	ctx context.Context,
+	req *connect.Request[score.CommitUploadRequest],
+) (*connect.Response[score.UploadScoreResponse], error) {
+	obj, duplicate, err := s.uploads.commit(ctx, tenantFromContext(ctx), req.Msg.GetUploadId())
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return connect.NewResponse(uploadScoreResponse(obj, duplicate)), nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+func newTestUploadManager(t *testing.T, quotaBytes int64) *uploadManager {
+	t.Helper()
+	return &uploadManager{
+		store:       newTestStore(t, quotaBytes),
+		chunkSize:   4,
+		maxBytes:    1 << 20,
+		ttl:         time.Hour,
+		maxSessions: 2,
+		now:         time.Now,
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUploadResumeOffsets(t *testing.T) {
+	ctx := context.Background()
+	m := newTestUploadManager(t, 0)
+	data := []byte("0123456789")
+
+	session, err := m.begin(ctx, "alice", "score", int64(len(data)), sha256Hex(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name         string
+		offset       int64
+		chunk        string
+		wantReceived int64
+		wantFail     bool
+		wantErr      error
+	}{
+		{name: "先頭", offset: 0, chunk: "0123", wantReceived: 4},
+		// 応答が届かずに同じチャンクを再送した場合は、受信済みのバイト数を返して続きから送らせる
+		{name: "再送", offset: 0, chunk: "0123", wantReceived: 4, wantFail: true, wantErr: errUploadOffsetMismatch},
+		{name: "飛ばした", offset: 8, chunk: "89", wantReceived: 4, wantFail: true, wantErr: errUploadOffsetMismatch},
+		{name: "続き", offset: 4, chunk: "4567", wantReceived: 8},
+		{name: "サイズ超過", offset: 8, chunk: "89X", wantReceived: 8, wantFail: true, wantErr: errUploadChunkTooLarge},
+		{name: "空", offset: 8, chunk: "", wantReceived: 8, wantFail: true, wantErr: errUploadChunkEmpty},
+		{name: "最後", offset: 8, chunk: "89", wantReceived: 10},
+	}
+	for _, step := range steps {
+		received, err := m.appendChunk(ctx, "alice", session.ID, step.offset, []byte(step.chunk), "")
+		if received != step.wantReceived {
+			t.Errorf("%s: received = %d, want %d", step.name, received, step.wantReceived)
+		}
+		if (err != nil) != step.wantFail || (step.wantErr != nil && !errors.Is(err, step.wantErr)) {
+			t.Errorf("%s: err = %v", step.name, err)
+		}
+	}
+
+	// 別のレプリカが状態を読み直しても同じ位置から再開できる
+	_, parts, err := m.session(ctx, "alice", session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receivedBytes(parts); got != 10 {
+		t.Errorf("receivedBytes = %d, want 10", got)
+	}
+
+	obj, duplicate, err := m.commit(ctx, "alice", session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duplicate {
+		t.Error("first commit reported duplicate")
+	}
+	_, stored, err := m.store.get(ctx, "alice", storeKindScores, obj.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Errorf("stored = %q, want %q", stored, data)
+	}
+	if _, _, err := m.session(ctx, "alice", session.ID); !errors.Is(err, errObjectNotFound) {
+		t.Errorf("session after commit: err = %v, want errObjectNotFound", err)
+	}
+}
+
+func TestUploadCommitChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestUploadManager(t, 0)
+
+	session, err := m.begin(ctx, "alice", "score", 4, sha256Hex([]byte("abcd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.appendChunk(ctx, "alice", session.ID, 0, []byte("abcX"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.commit(ctx, "alice", session.ID); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("commit: err = %v, want errChecksumMismatch", err)
+	}
+	objects, err := m.store.list(ctx, "alice", storeKindScores)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("stored %d scores after checksum mismatch", len(objects))
+	}
+}
+
+func TestUploadReservesQuota(t *testing.T) {
+	ctx := context.Background()
+	m := newTestUploadManager(t, 10)
+
+	first := []byte("0123456")
+	session, err := m.begin(ctx, "alice", "first", int64(len(first)), sha256Hex(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// チャンクを送る前でも開いているアップロードの分は予約済み
+	if _, err := m.begin(ctx, "alice", "second", 4, sha256Hex([]byte("abcd"))); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("begin over reserved quota: err = %v, want errQuotaExceeded", err)
+	}
+	if _, _, err := m.store.put(ctx, "alice", storeKindScores, "direct", []byte("abcd")); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("put over reserved quota: err = %v, want errQuotaExceeded", err)
+	}
+	if used, err := m.store.usage(ctx, "alice"); err != nil || used != int64(len(first)) {
+		t.Errorf("usage = %d, %v, want %d", used, err, len(first))
+	}
+
+	// 自分の予約は完了時に二重に数えない
+	if _, err := m.appendChunk(ctx, "alice", session.ID, 0, first, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.commit(ctx, "alice", session.ID); err != nil {
+		t.Fatalf("commit within quota: %v", err)
+	}
+}
+
+func TestUploadSessionLimit(t *testing.T) {
+	ctx := context.Background()
+	m := newTestUploadManager(t, 0)
+
+	for i := range m.maxSessions {
+		if _, err := m.begin(ctx, "alice", "score", 4, sha256Hex([]byte{byte(i)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.begin(ctx, "alice", "score", 4, sha256Hex([]byte("x"))); !errors.Is(err, errTooManyUploads) {
+		t.Errorf("begin over limit: err = %v, want errTooManyUploads", err)
+	}
+	// 上限はテナントごと
+	if _, err := m.begin(ctx, "bob", "score", 4, sha256Hex([]byte("x"))); err != nil {
+		t.Errorf("begin for other tenant: %v", err)
+	}
+
+	// 期限切れのアップロードは数えない
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := m.begin(ctx, "alice", "score", 4, sha256Hex([]byte("x"))); err != nil {
+		t.Errorf("begin after expiry: %v", err)
+	}
+}
+
+func TestUploadError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want connect.Code
+	}{
+		{err: errUploadSizeInvalid, want: connect.CodeInvalidArgument},
+		{err: errUploadSHA256Format, want: connect.CodeInvalidArgument},
+		{err: errUploadChunkEmpty, want: connect.CodeInvalidArgument},
+		{err: fmt.Errorf("%w: ファイルサイズ10バイト", errUploadChunkTooLarge), want: connect.CodeInvalidArgument},
+		{err: errUploadOffsetMismatch, want: connect.CodeFailedPrecondition},
+		{err: errTooManyUploads, want: connect.CodeResourceExhausted},
+		{err: errObjectNotFound, want: connect.CodeNotFound},
+		// 保存先の障害はリクエストの誤りとして返さない
+		{err: errors.New("connection reset by peer"), want: connect.CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := connect.CodeOf(uploadError(tt.err)); got != tt.want {
+			t.Errorf("uploadError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestUploadSweepAllExpired(t *testing.T) {
+	ctx := context.Background()
+	m := newTestUploadManager(t, 0)
+
+	// ハッシュにしたテナント名のアップロードも削除する
+	var expired []string
+	for _, tenant := range []string{"alice", "carol@example.com"} {
+		session, err := m.begin(ctx, tenant, "score", 4, sha256Hex([]byte("abcd")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.appendChunk(ctx, tenant, session.ID, 0, []byte("ab"), ""); err != nil {
+			t.Fatal(err)
+		}
+		expired = append(expired, uploadPrefix(tenant, session.ID))
+	}
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	open, err := m.begin(ctx, "bob", "score", 4, sha256Hex([]byte("abcd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.sweepAllExpired(ctx)
+	for _, prefix := range expired {
+		if blobs, err := m.store.storage.List(ctx, prefix); err != nil || len(blobs) != 0 {
+			t.Errorf("%s after sweep: %v, %v", prefix, blobs, err)
+		}
+	}
+	if _, _, err := m.session(ctx, "bob", open.ID); err != nil {
+		t.Errorf("open session removed by sweep: %v", err)
+	}
+}
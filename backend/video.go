@@ -0,0 +1,563 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	score "score-splitter/backend/gen/go"
+)
+
+// スクロール速度 = (BPM ÷ 60) × scrollPixelsPerBeat ピクセル/秒
+const scrollPixelsPerBeat = 120
+
+// 動画の末尾で最後の小節を表示したまま止めておく秒数
+const videoTailSeconds = 2
+
+const (
+	videoModeScroll = "scroll"
+	videoModePage   = "page"
+)
+
+var (
+	// errVideoTooLarge は横に並べたページの画像が上限を超える場合のエラーです
+	errVideoTooLarge = errors.New("動画にするページの画像が大きすぎます")
+	// errVideoTooLong は動画の長さが上限を超えた場合のエラーです
+	errVideoTooLong = errors.New("動画が長さの上限を超えています")
+)
+
+// videoLimits はサーバーの設定による動画生成の上限です
+type videoLimits struct {
+	// maxStripPixels はページを横に並べた画像の最大画素数です
+	maxStripPixels int64
+	// maxSeconds は動画の長さの上限（秒）です
+	maxSeconds int
+}
+
+func (c *serverConfig) videoLimits() videoLimits {
+	return videoLimits{maxStripPixels: c.MaxVideoStripPixels, maxSeconds: c.MaxVideoSeconds}
+}
+
+// videoOptions は正規化した動画生成の設定です
+type videoOptions struct {
+	title  string
+	bpm    int
+	width  int
+	height int
+	fps    int
+	format string
+	// crf と bitrate は画質の設定です。bitrate はkbpsで、0でなければcrfの代わりに使います。
+	crf     int
+	bitrate int
+	tempo   []tempoChange
+	timing  string
+	// segmentBeats と segmentMeters は timing が beats のときの各セグメントの拍数と1小節の拍数です
+	segmentBeats  []float64
+	segmentMeters []int
+	metronome     *metronomeOptions
+	backing       *backingAudio
+	overlay       overlayOptions
+	mode          string
+	// 以下は mode が page のときの設定です
+	segmentsPerPage int
+	crossfade       float64
+	previewNext     bool
+	limits          videoLimits
+}
+
+// videoOptionsFromRequest はリクエストを検証し、省略された項目に既定値を補います
+func videoOptionsFromRequest(msg *score.GenerateScrollVideoRequest, limits videoLimits) (videoOptions, error) {
+	opts := videoOptions{
+		limits:  limits,
+		title:   msg.GetTitle(),
+		bpm:     int(msg.GetBpm()),
+		width:   int(msg.GetVideoWidth()),
+		height:  int(msg.GetVideoHeight()),
+		fps:     int(msg.GetFps()),
+		format:  strings.ToLower(strings.TrimSpace(msg.GetFormat())),
+		crf:     int(msg.GetCrf()),
+		bitrate: int(msg.GetBitrateKbps()),
+	}
+	if opts.format == "" {
+		opts.format = defaultVideoFormat
+	}
+	format, ok := videoFormats[opts.format]
+	if !ok {
+		return opts, fmt.Errorf("出力形式%sには対応していません（%s）", opts.format, strings.Join(videoFormatNames(), ", "))
+	}
+	if opts.width == 0 {
+		opts.width = format.defaultWidth
+	}
+	if opts.height == 0 {
+		opts.height = format.defaultHeight
+	}
+	if opts.fps == 0 {
+		opts.fps = format.defaultFPS
+	}
+
+	if opts.bpm < minVideoBPM || opts.bpm > maxVideoBPM {
+		return opts, fmt.Errorf("BPM%dは%dから%dの範囲で指定してください", opts.bpm, minVideoBPM, maxVideoBPM)
+	}
+	if err := format.validate(&opts); err != nil {
+		return opts, err
+	}
+	tempo, err := tempoMapFromRequest(msg.GetTempoMap())
+	if err != nil {
+		return opts, err
+	}
+	opts.tempo = tempo
+
+	opts.timing = strings.ToLower(strings.TrimSpace(msg.GetTiming()))
+	switch opts.timing {
+	case "", videoTimingPixels:
+		opts.timing = videoTimingPixels
+		if len(msg.GetSegmentBeats()) > 0 {
+			return opts, errors.New("セグメントごとの拍数は timing が beats のときだけ指定できます")
+		}
+	case videoTimingBeats:
+		if len(msg.GetSegmentBeats()) == 0 {
+			return opts, errors.New("timing が beats のときはセグメントごとの拍数を指定してください")
+		}
+		if opts.segmentBeats, opts.segmentMeters, err = segmentBeatsFromRequest(msg.GetSegmentBeats(), msg.GetTimeSignature()); err != nil {
+			return opts, err
+		}
+	default:
+		return opts, fmt.Errorf("スクロールの速さの決め方%sには対応していません（pixels または beats）", opts.timing)
+	}
+	if opts.metronome, err = metronomeFromRequest(msg); err != nil {
+		return opts, err
+	}
+	if opts.backing, err = backingAudioFromRequest(msg); err != nil {
+		return opts, err
+	}
+	if (opts.metronome != nil || opts.backing != nil) && format.audioArgs == nil {
+		return opts, fmt.Errorf("出力形式%sには音声を入れられません", opts.format)
+	}
+
+	opts.mode = strings.ToLower(strings.TrimSpace(msg.GetMode()))
+	opts.segmentsPerPage = int(msg.GetSegmentsPerPage())
+	opts.crossfade = msg.GetCrossfadeSeconds()
+	opts.previewNext = msg.GetPreviewNext()
+	switch opts.mode {
+	case "", videoModeScroll:
+		opts.mode = videoModeScroll
+		if opts.segmentsPerPage != 0 || opts.crossfade != 0 || opts.previewNext {
+			return opts, errors.New("segments_per_page / crossfade_seconds / preview_next は mode が page のときだけ指定できます")
+		}
+	case videoModePage:
+		if opts.segmentsPerPage == 0 {
+			opts.segmentsPerPage = 1
+		}
+		if opts.segmentsPerPage < 1 || opts.segmentsPerPage > maxSegmentsPerPage {
+			return opts, fmt.Errorf("1画面のセグメント数%dは1から%dの範囲で指定してください", opts.segmentsPerPage, maxSegmentsPerPage)
+		}
+		if opts.crossfade < 0 || opts.crossfade > maxCrossfadeSeconds {
+			return opts, fmt.Errorf("クロスフェードの秒数%vは0から%dの範囲で指定してください", opts.crossfade, maxCrossfadeSeconds)
+		}
+	default:
+		return opts, fmt.Errorf("表示方法%sには対応していません（scroll または page）", opts.mode)
+	}
+	if opts.overlay, err = overlayFromRequest(msg, opts.mode); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// checkDuration は動画の長さが上限以内か確かめます。
+// 長さはPDFを変換してタイムラインを作った後でないと決まらないため、音声を作ったりエンコードしたりする前に確かめます。
+func (o videoOptions) checkDuration(seconds int) error {
+	if seconds > o.limits.maxSeconds {
+		return fmt.Errorf("%w（%d秒まで、この動画は%d秒です。BPMを上げるかページを減らしてください）", errVideoTooLong, o.limits.maxSeconds, seconds)
+	}
+	return nil
+}
+
+// videoFilename は動画の推奨ファイル名を返します
+func videoFilename(title, mode, format string) string {
+	suffix := "-scroll."
+	if mode == videoModePage {
+		suffix = "-pages."
+	}
+	return strings.TrimSuffix(deriveFilename(title), "-trimmed.pdf") + suffix + format
+}
+
+// renderedVideo は生成した動画です。動画は大きくなるのでメモリに載せず、一時ファイルpathに置きます。
+// 使い終わったら呼び出し元でpathを削除します。
+type renderedVideo struct {
+	path            string
+	filename        string
+	contentType     string
+	durationSeconds int
+}
+
+// renderScrollVideo はトリミング済みPDFの各ページを横に並べ、BPMとテンポマップに合わせて横スクロールする動画を生成します。
+// mode が page の場合はスクロールせずにページめくり形式の動画を生成します。
+func renderScrollVideo(ctx context.Context, pdfBytes []byte, opts videoOptions, limits pdfLimits, send progressSender) (*renderedVideo, error) {
+	if len(pdfBytes) == 0 {
+		return nil, errors.New("PDFファイルが空です")
+	}
+	if err := send(&score.TrimScoreProgressResponse{
+		Stage:    "parsing",
+		Progress: 5,
+		Message:  "PDFを解析しています...",
+	}); err != nil {
+		return nil, err
+	}
+	pdfCtx, err := readPDFContext(pdfBytes, "", limits)
+	if err != nil {
+		return nil, err
+	}
+	if pdfCtx.PageCount == 0 {
+		return nil, errors.New("PDFにページがありません")
+	}
+
+	workDir, err := os.MkdirTemp("", "score-video-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := send(&score.TrimScoreProgressResponse{
+		Stage:    "rasterizing",
+		Progress: 10,
+		Message:  fmt.Sprintf("%dページを画像に変換しています...", pdfCtx.PageCount),
+	}); err != nil {
+		return nil, err
+	}
+	pages, err := rasterizePDF(ctx, pdfBytes, workDir, opts.height, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	outPath := filepath.Join(workDir, "out."+opts.format)
+	onProgress := func(done float64) error {
+		return send(&score.TrimScoreProgressResponse{
+			Stage:    "encoding",
+			Progress: 35 + int32(done*60),
+			Message:  fmt.Sprintf("動画をエンコードしています... (%d%%)", int(done*100)),
+		})
+	}
+	var duration int
+	if opts.mode == videoModePage {
+		if err := send(&score.TrimScoreProgressResponse{
+			Stage:    "rendering",
+			Progress: 30,
+			Message:  "画面を描画しています...",
+		}); err != nil {
+			return nil, err
+		}
+		duration, err = renderPagedVideo(ctx, pages, workDir, outPath, opts, onProgress)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if err := send(&score.TrimScoreProgressResponse{
+			Stage:    "rendering",
+			Progress: 30,
+			Message:  "ページを結合しています...",
+		}); err != nil {
+			return nil, err
+		}
+		stripPath := filepath.Join(workDir, "strip.png")
+		stripWidth, segments, err := stitchPages(pages, stripPath, opts.width, opts.height, opts.overlay.playheadX(opts.width), opts.limits.maxStripPixels)
+		if err != nil {
+			return nil, err
+		}
+
+		timeline, err := scrollTimelineFor(opts, segments, stripWidth)
+		if err != nil {
+			return nil, err
+		}
+		var audio []videoAudio
+		duration, audio, err = prepareAudio(ctx, opts, timeline, workDir)
+		if err != nil {
+			return nil, err
+		}
+		if err := videoFormats[opts.format].checkDuration(opts, duration); err != nil {
+			return nil, err
+		}
+		overlay, err := overlayFilter(opts, duration, workDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := encodeScrollVideo(ctx, stripPath, audio, outPath, opts, timeline.expr(), overlay, duration, onProgress); err != nil {
+			return nil, err
+		}
+	}
+
+	// 作業ディレクトリは戻る時に削除するので、動画だけを外に移します
+	f, err := os.CreateTemp("", "score-video-*."+opts.format)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	if err := os.Rename(outPath, f.Name()); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &renderedVideo{
+		path:            f.Name(),
+		filename:        videoFilename(opts.title, opts.mode, opts.format),
+		contentType:     videoFormats[opts.format].contentType,
+		durationSeconds: duration,
+	}, nil
+}
+
+// scrollTimelineFor は動画の設定に合わせてスクロールのタイムラインを作ります。
+// pixels では画面の左端が結合画像の右端に着くまで一定の速さで進みます。
+// beats では各セグメントを指定された拍数で進み、最後のセグメントの拍数が終わるまでを動画の長さにします。
+func scrollTimelineFor(opts videoOptions, segments []stripSegment, stripWidth int) (*scrollTimeline, error) {
+	maxOffset := float64(stripWidth - opts.width)
+	beats, err := videoBeatMap(opts, segments)
+	if err != nil {
+		return nil, err
+	}
+	if opts.timing != videoTimingBeats {
+		return newScrollTimeline(opts.bpm, opts.tempo, segments, beats, maxOffset)
+	}
+	last := segments[len(segments)-1]
+	timeline, err := newScrollTimeline(opts.bpm, opts.tempo, segments, beats, float64(last.x+last.width))
+	if err != nil {
+		return nil, err
+	}
+	// 画面の左端は結合画像の右端から1画面分手前で止めます
+	timeline.last = math.Min(timeline.last, maxOffset)
+	return timeline, nil
+}
+
+// videoBeatMap は動画の設定に合わせて結合画像上の位置と拍の対応を返します
+func videoBeatMap(opts videoOptions, segments []stripSegment) (beatMap, error) {
+	if opts.timing != videoTimingBeats {
+		return pixelBeatMap(), nil
+	}
+	if len(opts.segmentBeats) != len(segments) {
+		return nil, fmt.Errorf("セグメントごとの拍数の数%dがページ数%dと一致しません", len(opts.segmentBeats), len(segments))
+	}
+	return segmentBeatMap(segments, opts.segmentBeats), nil
+}
+
+// stripSegment は結合画像の中での1ページ（セグメント）の開始位置と幅です
+type stripSegment struct {
+	x     int
+	width int
+}
+
+// stitchPages はページ画像を高さheightに揃えて横に並べた1枚のPNGを書き出し、その幅と各ページの位置を返します。
+// 幅が画面より狭い場合は画面幅まで白で埋めます。
+// playhead が0でなければ、画面のその位置に楽譜の先頭から末尾までが来るように、左に playhead、右に残りの画面幅の余白を加えます。
+// その場合も返す位置は余白を含まない位置で、画面の左端の位置が縦線の位置の楽譜上の位置になります。
+// 結合画像は全体をメモリに置くため、画素数が maxPixels を超える場合は画像を読み込む前にエラーにします。
+func stitchPages(pages []string, outPath string, screenWidth, height, playhead int, maxPixels int64) (int, []stripSegment, error) {
+	sizes, err := pngSizes(pages)
+	if err != nil {
+		return 0, nil, err
+	}
+	segments, width := segmentPositions(sizes, height)
+	if playhead > 0 {
+		width += screenWidth
+	}
+	if width < screenWidth {
+		width = screenWidth
+	}
+	if pixels := int64(width) * int64(height); pixels > maxPixels {
+		return 0, nil, fmt.Errorf("%w（幅%dピクセルで、上限は%d画素です。動画の高さを下げるかページを減らしてください）", errVideoTooLarge, width, maxPixels)
+	}
+
+	strip := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(strip, strip.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	x := playhead
+	// ページの画像は1枚ずつ読み込んで描画します
+	for i, page := range pages {
+		img, err := decodePNG(page)
+		if err != nil {
+			return 0, nil, err
+		}
+		w := segments[i].width
+		drawScaled(strip, image.Rect(x, 0, x+w, height), img)
+		x += w
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := png.Encode(f, strip); err != nil {
+		f.Close()
+		return 0, nil, err
+	}
+	return width, segments, f.Close()
+}
+
+// segmentPositions は大きさがsizesのページを高さheightに揃えて横に並べたときの各ページの位置と、全体の幅を返します
+func segmentPositions(sizes []image.Rectangle, height int) ([]stripSegment, int) {
+	segments := make([]stripSegment, len(sizes))
+	width := 0
+	for i, size := range sizes {
+		w := scaledWidth(size, height)
+		segments[i] = stripSegment{x: width, width: w}
+		width += w
+	}
+	return segments, width
+}
+
+// pngSizes は画像を読み込まずに各PNGの大きさを返します
+func pngSizes(paths []string) ([]image.Rectangle, error) {
+	sizes := make([]image.Rectangle, len(paths))
+	for i, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		config, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%sの読み込みに失敗しました: %w", filepath.Base(p), err)
+		}
+		sizes[i] = image.Rect(0, 0, config.Width, config.Height)
+	}
+	return sizes, nil
+}
+
+func decodePNGs(paths []string) ([]image.Image, error) {
+	images := make([]image.Image, len(paths))
+	for i, p := range paths {
+		img, err := decodePNG(p)
+		if err != nil {
+			return nil, err
+		}
+		images[i] = img
+	}
+	return images, nil
+}
+
+func decodePNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%sの読み込みに失敗しました: %w", filepath.Base(path), err)
+	}
+	return img, nil
+}
+
+func scaledWidth(b image.Rectangle, height int) int {
+	if b.Dy() == 0 {
+		return 0
+	}
+	return int(math.Round(float64(b.Dx()) * float64(height) / float64(b.Dy())))
+}
+
+// drawScaled はsrcをdstRectに最近傍法で拡大縮小して描画します。
+// ラスタライズ時に高さを揃えているため、通常は等倍でのコピーになります。
+func drawScaled(dst *image.RGBA, dstRect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Dx() == dstRect.Dx() && sb.Dy() == dstRect.Dy() {
+		draw.Draw(dst, dstRect, src, sb.Min, draw.Over)
+		return
+	}
+	for y := 0; y < dstRect.Dy(); y++ {
+		sy := sb.Min.Y + y*sb.Dy()/dstRect.Dy()
+		for x := 0; x < dstRect.Dx(); x++ {
+			sx := sb.Min.X + x*sb.Dx()/dstRect.Dx()
+			dst.Set(dstRect.Min.X+x, dstRect.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
+
+// encodeScrollVideo はffmpegで結合画像を左から右へスクロールする動画にエンコードします。
+// scrollX は時刻tでの画面の左端の位置を表すffmpegの式、overlay は重ねて表示するもののフィルタです。
+// audio は動画に入れる音声で、空なら音声なしの動画にします。
+// onProgressにはエンコードの進み具合を0から1で通知します。
+func encodeScrollVideo(ctx context.Context, stripPath string, audio []videoAudio, outPath string, opts videoOptions, scrollX, overlay string, duration int, onProgress func(float64) error) error {
+	filter := fmt.Sprintf("crop=%d:%d:'min(%s,iw-%d)':0%s,%s", opts.width, opts.height, scrollX, opts.width, overlay, videoFormats[opts.format].pixelFilter)
+	return runFFmpeg(ctx,
+		[]string{"-loop", "1", "-framerate", strconv.Itoa(opts.fps), "-i", stripPath},
+		[]string{"-vf", filter, "-map", "0:v"},
+		audio, outPath, opts, duration, onProgress)
+}
+
+// runFFmpeg はffmpegで動画をエンコードします。inputsには映像の入力を、outputsにはフィルタと映像の -map を指定し、
+// 音声、長さと出力形式ごとの設定はここで加えます。onProgressにはエンコードの進み具合を0から1で通知します。
+func runFFmpeg(ctx context.Context, inputs, outputs []string, audio []videoAudio, outPath string, opts videoOptions, duration int, onProgress func(float64) error) error {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return errors.New("動画のエンコードに必要なffmpegが見つかりません")
+	}
+
+	args := append([]string{"-hide_banner", "-nostats", "-loglevel", "error", "-y"}, inputs...)
+	if len(audio) > 0 {
+		// 音声は映像の入力の後ろに加えるので、最初の音声の入力の番号は映像の入力の数になります
+		index := 0
+		for _, arg := range inputs {
+			if arg == "-i" {
+				index++
+			}
+		}
+		audioInputs, audioOutputs := audioArgs(audio, index)
+		args = append(args, audioInputs...)
+		outputs = append(outputs, audioOutputs...)
+		outputs = append(outputs, videoFormats[opts.format].audioArgs...)
+	}
+	args = append(args, outputs...)
+	args = append(args,
+		"-t", strconv.Itoa(duration),
+		"-r", strconv.Itoa(opts.fps),
+		"-progress", "pipe:1",
+	)
+	args = append(args, videoFormats[opts.format].encodeArgs(opts)...)
+	args = append(args, outPath)
+
+	cmd := exec.CommandContext(ctx, ffmpeg, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// -progress の出力から out_time_us を読み取って進捗を通知する
+	total := float64(duration) * 1e6
+	scanner := bufio.NewScanner(stdout)
+	var progressErr error
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok || progressErr != nil {
+			continue
+		}
+		us, err := strconv.ParseFloat(value, 64)
+		if err != nil || us < 0 {
+			continue
+		}
+		if progressErr = onProgress(math.Min(us/total, 1)); progressErr != nil {
+			// 通知先が切断された場合などはエンコードを打ち切る
+			cmd.Process.Kill()
+		}
+	}
+
+	err = cmd.Wait()
+	if progressErr != nil {
+		return progressErr
+	}
+	if err != nil {
+		return fmt.Errorf("動画のエンコードに失敗しました: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	score "score-splitter/backend/gen/go"
+)
+
+// writeTestPNG は幅width・高さheightの単色のPNGを書き出します
+func writeTestPNG(t *testing.T, path string, width, height int, c color.Color) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStitchPages(t *testing.T) {
+	dir := t.TempDir()
+	pages := []string{filepath.Join(dir, "page-1.png"), filepath.Join(dir, "page-2.png")}
+	writeTestPNG(t, pages[0], 30, 10, color.Black)
+	writeTestPNG(t, pages[1], 10, 20, color.Black)
+	out := filepath.Join(dir, "strip.png")
+
+	// 高さ20に揃えると幅は60と10で、画面幅40の余白を加えて110になる
+	width, segments, err := stitchPages(pages, out, 40, 20, 8, 110*20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 110 {
+		t.Errorf("width = %d, want 110", width)
+	}
+	want := []stripSegment{{x: 0, width: 60}, {x: 60, width: 10}}
+	if len(segments) != 2 || segments[0] != want[0] || segments[1] != want[1] {
+		t.Errorf("segments = %v, want %v", segments, want)
+	}
+	img, err := decodePNG(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 110 || b.Dy() != 20 {
+		t.Errorf("strip size = %v", b)
+	}
+	// 左の余白は白で、ページはその右から始まる
+	if r, _, _, _ := img.At(7, 10).RGBA(); r != 0xFFFF {
+		t.Errorf("playhead margin is not white")
+	}
+	if r, _, _, _ := img.At(8, 10).RGBA(); r != 0 {
+		t.Errorf("first page does not start after the playhead margin")
+	}
+
+	// 画素数の上限を超える場合は画像を作らない
+	os.Remove(out)
+	if _, _, err := stitchPages(pages, out, 40, 20, 8, 110*20-1); !errors.Is(err, errVideoTooLarge) {
+		t.Errorf("err = %v, want errVideoTooLarge", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("strip was written over the pixel limit")
+	}
+}
+
+func TestVideoOptionsFromRequest(t *testing.T) {
+	limits := defaultConfig().videoLimits()
+	opts, err := videoOptionsFromRequest(&score.GenerateScrollVideoRequest{Bpm: 120, Format: " WebM "}, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := videoFormats["webm"]; opts.width != f.defaultWidth || opts.height != f.defaultHeight || opts.fps != f.defaultFPS {
+		t.Errorf("defaults = %dx%d@%d", opts.width, opts.height, opts.fps)
+	}
+	if opts.format != "webm" {
+		t.Errorf("format = %q, want webm", opts.format)
+	}
+
+	tests := []struct {
+		name string
+		msg  *score.GenerateScrollVideoRequest
+	}{
+		{name: "BPMが小さい", msg: &score.GenerateScrollVideoRequest{Bpm: 29}},
+		{name: "BPMが大きい", msg: &score.GenerateScrollVideoRequest{Bpm: 241}},
+		{name: "幅が奇数", msg: &score.GenerateScrollVideoRequest{Bpm: 120, VideoWidth: 1001}},
+		{name: "幅が小さい", msg: &score.GenerateScrollVideoRequest{Bpm: 120, VideoWidth: 14}},
+		{name: "高さが大きい", msg: &score.GenerateScrollVideoRequest{Bpm: 120, VideoHeight: 2162}},
+		{name: "フレームレートが大きい", msg: &score.GenerateScrollVideoRequest{Bpm: 120, Fps: 61}},
+		{name: "未対応の形式", msg: &score.GenerateScrollVideoRequest{Bpm: 120, Format: "avi"}},
+	}
+	for _, tt := range tests {
+		if _, err := videoOptionsFromRequest(tt.msg, limits); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const defaultVideoFormat = "mp4"
+
+// videoFormats は出力できる形式ごとのffmpegのエンコード設定と、指定できる範囲です
+var videoFormats = map[string]videoFormat{
+	"mp4": {
+		contentType: "video/mp4",
+		args:        []string{"-c:v", "libx264", "-preset", "veryfast", "-movflags", "+faststart"},
+		pixelFilter: "format=yuv420p",
+		audioArgs:   []string{"-c:a", "aac", "-b:a", "128k"},
+		crf:         qualityRange{def: 23, max: 51},
+		crfArgs:     func(crf int) []string { return []string{"-crf", strconv.Itoa(crf)} },
+		bitrateArgs: func(kbps int) []string {
+			// CRFを使わない場合もビットレートが大きく振れないように上限を設けます
+			return []string{"-b:v", fmt.Sprintf("%dk", kbps), "-maxrate", fmt.Sprintf("%dk", kbps), "-bufsize", fmt.Sprintf("%dk", kbps*2)}
+		},
+		defaultWidth: 1920, defaultHeight: 1080, defaultFPS: 30,
+		maxWidth: 3840, maxHeight: 2160, maxFPS: 60,
+	},
+	"webm": {
+		contentType: "video/webm",
+		args:        []string{"-c:v", "libvpx-vp9", "-row-mt", "1"},
+		pixelFilter: "format=yuv420p",
+		audioArgs:   []string{"-c:a", "libopus", "-b:a", "96k"},
+		crf:         qualityRange{def: 32, max: 63},
+		// VP9は -b:v 0 を指定したときだけCRFだけで画質を決めます
+		crfArgs:      func(crf int) []string { return []string{"-b:v", "0", "-crf", strconv.Itoa(crf)} },
+		bitrateArgs:  func(kbps int) []string { return []string{"-b:v", fmt.Sprintf("%dk", kbps)} },
+		defaultWidth: 1920, defaultHeight: 1080, defaultFPS: 30,
+		maxWidth: 3840, maxHeight: 2160, maxFPS: 60,
+	},
+	"gif": {
+		contentType: "image/gif",
+		args:        []string{"-c:v", "gif", "-f", "gif", "-loop", "0"},
+		// 256色に減らすため、動画全体から作ったパレットで描画します。
+		// パレットができるまで全てのフレームをメモリに溜めるので、長さとフレームレートを抑えます。
+		pixelFilter:  "split[pa][pb];[pa]palettegen=stats_mode=diff[pal];[pb][pal]paletteuse=dither=bayer",
+		defaultWidth: 640, defaultHeight: 360, defaultFPS: 12,
+		maxWidth: 1280, maxHeight: 720, maxFPS: 15, maxSeconds: 30,
+	},
+	"apng": {
+		contentType: "image/apng",
+		args:        []string{"-c:v", "apng", "-f", "apng", "-plays", "0"},
+		pixelFilter: "format=rgb24",
+		// 圧縮しないフレームを並べるため、GIFと同じくプレビュー向けの長さに抑えます
+		defaultWidth: 640, defaultHeight: 360, defaultFPS: 12,
+		maxWidth: 1280, maxHeight: 720, maxFPS: 15, maxSeconds: 30,
+	},
+}
+
+const (
+	minVideoSize    = 16
+	minVideoBitrate = 100
+	maxVideoBitrate = 50000
+)
+
+type videoFormat struct {
+	contentType string
+	args        []string
+	// pixelFilter は最後に加える画素形式を合わせるフィルタです
+	pixelFilter string
+	// audioArgs は音声を入れるときのエンコード設定です。nilなら音声を入れられません。
+	audioArgs []string
+	// crf と bitrateArgs がnilの形式は画質を指定できません
+	crf         qualityRange
+	crfArgs     func(crf int) []string
+	bitrateArgs func(kbps int) []string
+
+	defaultWidth, defaultHeight, defaultFPS int
+	maxWidth, maxHeight, maxFPS             int
+	// maxSeconds は動画の長さの上限（秒）です。0なら制限しません。
+	maxSeconds int
+}
+
+// qualityRange はCRFの既定値と上限です（下限は0）
+type qualityRange struct {
+	def int
+	max int
+}
+
+// validate は動画の大きさ・フレームレート・画質がこの形式で使える範囲か確かめ、画質の既定値を補います
+func (f videoFormat) validate(opts *videoOptions) error {
+	// yuv420pでエンコードする形式があるため、幅と高さはどの形式でも偶数にそろえます
+	if opts.width < minVideoSize || opts.width > f.maxWidth || opts.width%2 != 0 {
+		return fmt.Errorf("動画の幅%dが無効です（%sでは%dから%dの偶数）", opts.width, opts.format, minVideoSize, f.maxWidth)
+	}
+	if opts.height < minVideoSize || opts.height > f.maxHeight || opts.height%2 != 0 {
+		return fmt.Errorf("動画の高さ%dが無効です（%sでは%dから%dの偶数）", opts.height, opts.format, minVideoSize, f.maxHeight)
+	}
+	if opts.fps < 1 || opts.fps > f.maxFPS {
+		return fmt.Errorf("フレームレート%dが無効です（%sでは1から%d）", opts.fps, opts.format, f.maxFPS)
+	}
+
+	if f.crfArgs == nil && (opts.crf != 0 || opts.bitrate != 0) {
+		return fmt.Errorf("出力形式%sでは crf / bitrate_kbps を指定できません", opts.format)
+	}
+	if opts.crf != 0 && opts.bitrate != 0 {
+		return errors.New("crf と bitrate_kbps は同時に指定できません")
+	}
+	if opts.bitrate != 0 && (opts.bitrate < minVideoBitrate || opts.bitrate > maxVideoBitrate) {
+		return fmt.Errorf("ビットレート%dkbpsは%dから%dの範囲で指定してください", opts.bitrate, minVideoBitrate, maxVideoBitrate)
+	}
+	if opts.crf < 0 || opts.crf > f.crf.max {
+		return fmt.Errorf("画質crf%dは%sでは0から%dの範囲で指定してください", opts.crf, opts.format, f.crf.max)
+	}
+	if opts.crf == 0 && opts.bitrate == 0 {
+		opts.crf = f.crf.def
+	}
+	return nil
+}
+
+// checkDuration は動画の長さがこの形式の上限以内か確かめます。長さはPDFを変換した後でないと決まらないため、validateとは別に確かめます。
+func (f videoFormat) checkDuration(opts videoOptions, seconds int) error {
+	if f.maxSeconds > 0 && seconds > f.maxSeconds {
+		return fmt.Errorf("%w（%sでは%d秒まで、この動画は%d秒です。BPMを上げるかページを減らしてください）", errVideoTooLong, opts.format, f.maxSeconds, seconds)
+	}
+	return nil
+}
+
+// encodeArgs は映像のエンコード設定を返します
+func (f videoFormat) encodeArgs(opts videoOptions) []string {
+	args := append([]string(nil), f.args...)
+	switch {
+	case opts.bitrate != 0:
+		args = append(args, f.bitrateArgs(opts.bitrate)...)
+	case f.crfArgs != nil:
+		args = append(args, f.crfArgs(opts.crf)...)
+	}
+	return args
+}
+
+// videoFormatNames は出力できる形式の名前を返します
+func videoFormatNames() []string {
+	names := make([]string, 0, len(videoFormats))
+	for name := range videoFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
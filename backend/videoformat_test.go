@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestVideoFormatPreviewLimits(t *testing.T) {
+	for _, name := range []string{"gif", "apng"} {
+		f := videoFormats[name]
+		opts := videoOptions{format: name, width: f.defaultWidth, height: f.defaultHeight, fps: f.maxFPS + 1}
+		if err := f.validate(&opts); err == nil {
+			t.Errorf("%s: fps %d was accepted", name, opts.fps)
+		}
+
+		opts.fps = f.defaultFPS
+		if err := f.validate(&opts); err != nil {
+			t.Fatalf("%s: default options: %v", name, err)
+		}
+		if err := f.checkDuration(opts, f.maxSeconds); err != nil {
+			t.Errorf("%s: %d seconds: %v", name, f.maxSeconds, err)
+		}
+		err := f.checkDuration(opts, f.maxSeconds+1)
+		if !errors.Is(err, errVideoTooLong) {
+			t.Fatalf("%s: %d seconds: err = %v, want errVideoTooLong", name, f.maxSeconds+1, err)
+		}
+		if code := connect.CodeOf(trimError(err)); code != connect.CodeInvalidArgument {
+			t.Errorf("%s: trimError code = %v, want invalid_argument", name, code)
+		}
+	}
+
+	// 動画の形式は長さを制限しない
+	if err := videoFormats["mp4"].checkDuration(videoOptions{format: "mp4"}, 3600); err != nil {
+		t.Errorf("mp4: %v", err)
+	}
+}